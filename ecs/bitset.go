@@ -0,0 +1,128 @@
+package ecs
+
+import (
+	"math/bits"
+	"slices"
+)
+
+// EntityBitset is a set of entities stored as one bit per entity index
+// Combining bitsets works a word at a time, which makes intersecting many query
+// results much cheaper than slice intersection
+// Bitsets being combined should come from the same world state so that an index
+// refers to the same entity generation in each of them
+type EntityBitset struct {
+	words    []uint64 // Bit i is set when entity index i is in the set
+	entities []Entity // Entity handle for each index, used to rebuild handles
+}
+
+// NewEntityBitset creates an empty entity bitset
+func NewEntityBitset() *EntityBitset {
+	return &EntityBitset{
+		words:    make([]uint64, 0),
+		entities: make([]Entity, 0),
+	}
+}
+
+// Add inserts an entity into the bitset
+func (b *EntityBitset) Add(entity Entity) {
+	if !entity.IsValid() {
+		return
+	}
+
+	index := entity.Index()
+	if needed := int(index/64) + 1; len(b.words) < needed {
+		b.words = append(b.words, make([]uint64, needed-len(b.words))...)
+	}
+	if needed := int(index) + 1; len(b.entities) < needed {
+		b.entities = append(b.entities, make([]Entity, needed-len(b.entities))...)
+	}
+
+	b.words[index/64] |= 1 << (index % 64)
+	b.entities[index] = entity
+}
+
+// Contains checks if an entity is in the bitset
+func (b *EntityBitset) Contains(entity Entity) bool {
+	if !entity.IsValid() {
+		return false
+	}
+
+	index := entity.Index()
+	if int(index/64) >= len(b.words) || b.words[index/64]&(1<<(index%64)) == 0 {
+		return false
+	}
+	return b.entities[index] == entity
+}
+
+// Count returns the number of entities in the bitset
+func (b *EntityBitset) Count() int {
+	count := 0
+	for _, word := range b.words {
+		count += bits.OnesCount64(word)
+	}
+	return count
+}
+
+// And returns a new bitset with the entities present in both bitsets
+func (b *EntityBitset) And(other *EntityBitset) *EntityBitset {
+	words := make([]uint64, min(len(b.words), len(other.words)))
+	for i := range words {
+		words[i] = b.words[i] & other.words[i]
+	}
+	return &EntityBitset{words: words, entities: slices.Clone(b.entities)}
+}
+
+// Or returns a new bitset with the entities present in either bitset
+func (b *EntityBitset) Or(other *EntityBitset) *EntityBitset {
+	words := make([]uint64, max(len(b.words), len(other.words)))
+	copy(words, b.words)
+	for i, word := range other.words {
+		words[i] |= word
+	}
+
+	// Take handles from b, falling back to other for indices only it holds
+	entities := make([]Entity, max(len(b.entities), len(other.entities)))
+	copy(entities, other.entities)
+	for i, word := range b.words {
+		for word != 0 {
+			bit := bits.TrailingZeros64(word)
+			index := i*64 + bit
+			entities[index] = b.entities[index]
+			word &^= 1 << bit
+		}
+	}
+
+	return &EntityBitset{words: words, entities: entities}
+}
+
+// AndNot returns a new bitset with the entities present in b but not in other
+func (b *EntityBitset) AndNot(other *EntityBitset) *EntityBitset {
+	words := make([]uint64, len(b.words))
+	copy(words, b.words)
+	for i := 0; i < len(words) && i < len(other.words); i++ {
+		words[i] &^= other.words[i]
+	}
+	return &EntityBitset{words: words, entities: slices.Clone(b.entities)}
+}
+
+// Entities returns the entities in the bitset ordered by index
+func (b *EntityBitset) Entities() []Entity {
+	result := make([]Entity, 0, b.Count())
+	for i, word := range b.words {
+		for word != 0 {
+			bit := bits.TrailingZeros64(word)
+			result = append(result, b.entities[i*64+bit])
+			word &^= 1 << bit
+		}
+	}
+	return result
+}
+
+// ToBitset converts the query result into an entity bitset
+func (qr *QueryResult) ToBitset() *EntityBitset {
+	bitset := NewEntityBitset()
+	for _, entity := range qr.entities {
+		bitset.Add(entity)
+	}
+	return bitset
+}
@@ -0,0 +1,140 @@
+package ecs
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// sliceIntersect returns the entities of a that are also in b, ordered by index
+func sliceIntersect(a, b []Entity) []Entity {
+	result := make([]Entity, 0)
+	for _, entity := range a {
+		if slices.Contains(b, entity) {
+			result = append(result, entity)
+		}
+	}
+	return sortedByIndex(result)
+}
+
+// sliceDifference returns the entities of a that are not in b, ordered by index
+func sliceDifference(a, b []Entity) []Entity {
+	result := make([]Entity, 0)
+	for _, entity := range a {
+		if !slices.Contains(b, entity) {
+			result = append(result, entity)
+		}
+	}
+	return sortedByIndex(result)
+}
+
+func TestBitsetMatchesSliceIntersection(t *testing.T) {
+	w := NewWorld()
+	populateOverlap(w, 300, 25)
+
+	positions := With[testPosition](NewQuery(w)).Build()
+	velocities := With[testVelocity](NewQuery(w)).Build()
+	tagged := With[testTag](NewQuery(w)).Build()
+
+	and := positions.ToBitset().And(velocities.ToBitset()).Entities()
+	if want := sliceIntersect(positions.Entities(), velocities.Entities()); !slices.Equal(and, want) {
+		t.Fatalf("And = %d entities, want %d", len(and), len(want))
+	}
+
+	andNot := positions.ToBitset().AndNot(tagged.ToBitset()).Entities()
+	if want := sliceDifference(positions.Entities(), tagged.Entities()); !slices.Equal(andNot, want) {
+		t.Fatalf("AndNot = %d entities, want %d", len(andNot), len(want))
+	}
+}
+
+func TestBitsetIntersectsManyQueries(t *testing.T) {
+	w := NewWorld()
+	populateOverlap(w, 300, 60)
+
+	results := []*QueryResult{
+		With[testPosition](NewQuery(w)).Build(),
+		With[testVelocity](NewQuery(w)).Build(),
+		With[testHealth](NewQuery(w)).Build(),
+		With[testTag](NewQuery(w)).Build(),
+		Without[testTag](With[testPosition](NewQuery(w))).Build(),
+	}
+
+	combined := results[0].ToBitset()
+	want := sortedByIndex(results[0].Entities())
+	for _, result := range results[1:] {
+		combined = combined.And(result.ToBitset())
+		want = sliceIntersect(want, result.Entities())
+	}
+	if got := combined.Entities(); !slices.Equal(got, want) {
+		t.Fatalf("five-way And = %v, want %v", got, want)
+	}
+	if len(want) != 0 {
+		t.Fatalf("tagged and untagged queries should not overlap, got %d entities", len(want))
+	}
+
+	// Dropping the contradictory query leaves a non-empty intersection
+	combined = results[0].ToBitset()
+	want = sortedByIndex(results[0].Entities())
+	for _, result := range results[1:4] {
+		combined = combined.And(result.ToBitset())
+		want = sliceIntersect(want, result.Entities())
+	}
+	if got := combined.Entities(); len(want) == 0 || !slices.Equal(got, want) {
+		t.Fatalf("four-way And = %d entities, want %d", len(got), len(want))
+	}
+
+	or := results[1].ToBitset().Or(results[3].ToBitset()).Entities()
+	if len(or) != results[1].Size()+results[3].Size()-len(sliceIntersect(results[1].Entities(), results[3].Entities())) {
+		t.Fatalf("Or = %d entities, does not match the union size", len(or))
+	}
+}
+
+func TestBitsetResultsDoNotShareHandles(t *testing.T) {
+	a, b := NewEntityBitset(), NewEntityBitset()
+	a.Add(makeEntity(1, 0))
+	a.Add(makeEntity(2, 0))
+	b.Add(makeEntity(1, 0))
+
+	and := a.And(b)
+	andNot := a.AndNot(b)
+
+	// Reusing index 1 with a new generation in a must not leak into the results
+	a.Add(makeEntity(1, 1))
+	if !and.Contains(makeEntity(1, 0)) || and.Contains(makeEntity(1, 1)) {
+		t.Fatalf("And result changed after its source was modified")
+	}
+	a.Add(makeEntity(2, 1))
+	if !andNot.Contains(makeEntity(2, 0)) || andNot.Contains(makeEntity(2, 1)) {
+		t.Fatalf("AndNot result changed after its source was modified")
+	}
+}
+
+func BenchmarkBitsetAnd(b *testing.B) {
+	for _, size := range []int{1000, 100000} {
+		w := NewWorld()
+		populateOverlap(w, size, 50)
+		positions := With[testPosition](NewQuery(w)).Build()
+		velocities := With[testVelocity](NewQuery(w)).Build()
+
+		b.Run(fmt.Sprintf("bitset/size=%d", size), func(b *testing.B) {
+			left, right := positions.ToBitset(), velocities.ToBitset()
+			for i := 0; i < b.N; i++ {
+				left.And(right)
+			}
+		})
+		b.Run(fmt.Sprintf("slice/size=%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				members := make(map[Entity]struct{}, velocities.Size())
+				for _, entity := range velocities.Entities() {
+					members[entity] = struct{}{}
+				}
+				both := make([]Entity, 0, len(members))
+				for _, entity := range positions.Entities() {
+					if _, ok := members[entity]; ok {
+						both = append(both, entity)
+					}
+				}
+			}
+		})
+	}
+}
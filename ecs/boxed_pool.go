@@ -0,0 +1,80 @@
+package ecs
+
+// BoxedPool is a component pool that stores each component behind its own pointer
+// For large components, removal and reordering move a pointer instead of the value,
+// and GetPtr returns the stored pointer, which stays valid across other insertions
+// and removals unlike ComponentPool's pointers into its backing array
+// Aliasing: InsertPtr stores the caller's pointer as is, so writes through either
+// side are visible to both, and a pointer shared between entities is one component
+type BoxedPool[T any] struct {
+	pool *ComponentPool[*T]
+}
+
+// NewComponentPoolBoxed creates a pointer-backed component pool for type T
+func NewComponentPoolBoxed[T any]() *BoxedPool[T] {
+	return &BoxedPool[T]{
+		pool: NewComponentPool[*T](),
+	}
+}
+
+// Insert stores a copy of component for an entity, replacing its current one
+func (bp *BoxedPool[T]) Insert(entity Entity, component T) {
+	bp.pool.Insert(entity, &component)
+}
+
+// InsertPtr stores component itself for an entity without copying it
+func (bp *BoxedPool[T]) InsertPtr(entity Entity, component *T) {
+	if component == nil {
+		panic("ecs: BoxedPool.InsertPtr with nil component")
+	}
+	bp.pool.Insert(entity, component)
+}
+
+// Remove removes an entity's component
+func (bp *BoxedPool[T]) Remove(entity Entity) bool {
+	return bp.pool.Remove(entity)
+}
+
+// Get retrieves a copy of an entity's component
+func (bp *BoxedPool[T]) Get(entity Entity) (T, bool) {
+	if component := bp.GetPtr(entity); component != nil {
+		return *component, true
+	}
+	var zero T
+	return zero, false
+}
+
+// GetPtr returns the stored pointer to an entity's component, or nil
+func (bp *BoxedPool[T]) GetPtr(entity Entity) *T {
+	if boxed := bp.pool.GetPtr(entity); boxed != nil {
+		return *boxed
+	}
+	return nil
+}
+
+// Contains checks if an entity has this component
+func (bp *BoxedPool[T]) Contains(entity Entity) bool {
+	return bp.pool.Contains(entity)
+}
+
+// Size returns the number of entities with this component
+func (bp *BoxedPool[T]) Size() int {
+	return bp.pool.Size()
+}
+
+// Clear removes all components
+func (bp *BoxedPool[T]) Clear() {
+	bp.pool.Clear()
+}
+
+// Entities returns the sparse set of entities
+func (bp *BoxedPool[T]) Entities() *SparseSet {
+	return bp.pool.Entities()
+}
+
+// ForEach calls fn for every entity with its stored component pointer
+func (bp *BoxedPool[T]) ForEach(fn func(Entity, *T)) {
+	for i, entity := range bp.pool.entities.Data() {
+		fn(entity, bp.pool.components[i])
+	}
+}
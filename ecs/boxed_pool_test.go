@@ -0,0 +1,65 @@
+package ecs
+
+import "testing"
+
+// testLarge is a 1KB component for comparing value and boxed storage
+type testLarge struct {
+	Data [128]int64
+}
+
+func TestBoxedPoolPointersAreStable(t *testing.T) {
+	entities, _ := testPairs(100)
+	pool := NewComponentPoolBoxed[testLarge]()
+	for i, entity := range entities {
+		pool.Insert(entity, testLarge{Data: [128]int64{int64(i)}})
+	}
+	last := pool.GetPtr(entities[99])
+
+	// Swap-and-pop moves the last pointer, not the value it points to
+	pool.Remove(entities[0])
+	if pool.GetPtr(entities[99]) != last || last.Data[0] != 99 {
+		t.Fatalf("removal moved the last component's storage")
+	}
+
+	// InsertPtr aliases the caller's value
+	shared := &testLarge{}
+	pool.InsertPtr(entities[1], shared)
+	shared.Data[0] = 42
+	if c, _ := pool.Get(entities[1]); c.Data[0] != 42 || pool.GetPtr(entities[1]) != shared {
+		t.Fatalf("InsertPtr copied the component: %v", c.Data[0])
+	}
+	if pool.Contains(entities[0]) || pool.Size() != 99 {
+		t.Fatalf("pool holds %d components after one removal", pool.Size())
+	}
+}
+
+func BenchmarkValueVsBoxedPool(b *testing.B) {
+	entities, _ := testPairs(1000)
+	// Remove and reinsert from the front so every removal moves the last component
+	b.Run("value", func(b *testing.B) {
+		pool := NewComponentPool[testLarge]()
+		for _, entity := range entities {
+			pool.Insert(entity, testLarge{})
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			entity := entities[i%len(entities)]
+			pool.Remove(entity)
+			pool.Insert(entity, testLarge{})
+			pool.GetPtr(entities[(i*7)%len(entities)]).Data[0]++
+		}
+	})
+	b.Run("boxed", func(b *testing.B) {
+		pool := NewComponentPoolBoxed[testLarge]()
+		for _, entity := range entities {
+			pool.Insert(entity, testLarge{})
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			entity := entities[i%len(entities)]
+			pool.Remove(entity)
+			pool.Insert(entity, testLarge{})
+			pool.GetPtr(entities[(i*7)%len(entities)]).Data[0]++
+		}
+	})
+}
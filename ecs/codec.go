@@ -0,0 +1,446 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// componentCodec converts one component type to and from JSON
+type componentCodec struct {
+	name    string
+	version int // Current layout version, saves with older versions are migrated
+	decode  func(raw json.RawMessage) (any, error)
+	add     func(w *World, entity Entity, value any)
+	save    func(w *World) ([]Entity, []json.RawMessage, error)
+
+	// Binary counterparts of save and add, used by SaveBinary and LoadBinary
+	saveBinary func(w *World) ([]Entity, []byte, error)
+	loadBinary func(data []byte, count int) (func(w *World, entities []Entity), error)
+}
+
+// componentMigration upgrades a saved component value from one version to a later one
+type componentMigration struct {
+	toVersion int
+	migrate   func(raw json.RawMessage) (json.RawMessage, error)
+}
+
+// RegisterCodec registers component type T and makes it encodable to and decodable
+// from JSON by its type name, e.g. "main.Position" or the unqualified "Position"
+func RegisterCodec[T any](w *World) {
+	RegisterCodecVersion[T](w, 1)
+}
+
+// RegisterCodecVersion is like RegisterCodec but declares the current layout version
+// of T; LoadJSON upgrades older saved values with the registered migrations
+func RegisterCodecVersion[T any](w *World, version int) {
+	id := Register[T](w.componentRegistry)
+	name := w.componentRegistry.names[id]
+	w.codecs[name] = &componentCodec{
+		name:    name,
+		version: version,
+		decode: func(raw json.RawMessage) (any, error) {
+			var component T
+			if err := json.Unmarshal(raw, &component); err != nil {
+				return nil, err
+			}
+			return component, nil
+		},
+		add: func(w *World, entity Entity, value any) {
+			AddComponent(w, entity, value.(T))
+		},
+		save: func(w *World) ([]Entity, []json.RawMessage, error) {
+			pool, exists := GetStorage[T](w.componentRegistry)
+			if !exists {
+				return nil, nil, nil
+			}
+
+			entities, components := pool.DataWithEntities()
+			values := make([]json.RawMessage, len(components))
+			for i := range components {
+				raw, err := json.Marshal(components[i])
+				if err != nil {
+					return nil, nil, err
+				}
+				values[i] = raw
+			}
+			return append([]Entity(nil), entities...), values, nil
+		},
+		saveBinary: func(w *World) ([]Entity, []byte, error) {
+			pool, exists := GetStorage[T](w.componentRegistry)
+			if !exists {
+				return nil, nil, nil
+			}
+
+			entities, components := pool.DataWithEntities()
+			var data bytes.Buffer
+			if reflect.TypeFor[T]().Size() > 0 {
+				if err := gob.NewEncoder(&data).Encode(components); err != nil {
+					return nil, nil, err
+				}
+			}
+			return append([]Entity(nil), entities...), data.Bytes(), nil
+		},
+		loadBinary: func(data []byte, count int) (func(w *World, entities []Entity), error) {
+			// Zero-sized tags carry no data, and gob rejects structs without fields
+			components := make([]T, count)
+			if reflect.TypeFor[T]().Size() > 0 {
+				if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&components); err != nil {
+					return nil, err
+				}
+			}
+			if len(components) != count {
+				return nil, fmt.Errorf("%d values for %d entities", len(components), count)
+			}
+
+			return func(w *World, entities []Entity) {
+				Register[T](w.componentRegistry)
+				pool, _ := GetStorage[T](w.componentRegistry)
+				pool.InsertMany(entities, components)
+			}, nil
+		},
+	}
+}
+
+// RegisterMigration registers a function that upgrades saved values of a component
+// type from fromVersion to toVersion; LoadJSON chains migrations until the value
+// reaches the version declared with RegisterCodecVersion
+func (w *World) RegisterMigration(typeName string, fromVersion, toVersion int, fn func(raw json.RawMessage) (json.RawMessage, error)) {
+	if toVersion <= fromVersion {
+		panic("ecs: migration must upgrade to a later version")
+	}
+	if w.migrations[typeName] == nil {
+		w.migrations[typeName] = make(map[int]componentMigration)
+	}
+	w.migrations[typeName][fromVersion] = componentMigration{toVersion: toVersion, migrate: fn}
+}
+
+// migrate upgrades a saved value to the codec's current version
+func (w *World) migrate(codec *componentCodec, version int, raw json.RawMessage) (json.RawMessage, error) {
+	if version > codec.version {
+		return nil, fmt.Errorf("ecs: saved %s version %d is newer than current version %d", codec.name, version, codec.version)
+	}
+
+	// Each step may be registered under the full or the unqualified type name
+	full := w.migrations[codec.name]
+	short := w.migrations[codec.name[strings.LastIndex(codec.name, ".")+1:]]
+
+	for version < codec.version {
+		migration, exists := full[version]
+		if !exists {
+			migration, exists = short[version]
+		}
+		if !exists {
+			return nil, fmt.Errorf("ecs: no migration for %s from version %d", codec.name, version)
+		}
+
+		upgraded, err := migration.migrate(raw)
+		if err != nil {
+			return nil, fmt.Errorf("ecs: migrating %s from version %d: %w", codec.name, version, err)
+		}
+		raw, version = upgraded, migration.toVersion
+	}
+	return raw, nil
+}
+
+// codecByName returns the codec for a full or unambiguous unqualified type name
+func (w *World) codecByName(name string) (*componentCodec, bool) {
+	if codec, exists := w.codecs[name]; exists {
+		return codec, true
+	}
+
+	var match *componentCodec
+	matches := 0
+	for typeName, codec := range w.codecs {
+		if typeName[strings.LastIndex(typeName, ".")+1:] == name {
+			match = codec
+			matches++
+		}
+	}
+	return match, matches == 1
+}
+
+// SpawnFromMap creates an entity from JSON component values keyed by type name
+// Every value is decoded before the entity is created, so on error nothing is spawned
+func (w *World) SpawnFromMap(data map[string]json.RawMessage) (Entity, error) {
+	names := make([]string, 0, len(data))
+	for name := range data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	codecs := make([]*componentCodec, len(names))
+	values := make([]any, len(names))
+	for i, name := range names {
+		codec, exists := w.codecByName(name)
+		if !exists {
+			return NullEntity, fmt.Errorf("ecs: no codec registered for component %q", name)
+		}
+
+		value, err := codec.decode(data[name])
+		if err != nil {
+			return NullEntity, fmt.Errorf("ecs: decoding component %q: %w", name, err)
+		}
+		codecs[i] = codec
+		values[i] = value
+	}
+
+	entity := w.CreateEntity()
+	for i, codec := range codecs {
+		codec.add(w, entity, values[i])
+	}
+	return entity, nil
+}
+
+// jsonSave is the document written by SaveJSON
+type jsonSave struct {
+	Entities   []Entity                  `json:"entities"`
+	Components map[string]jsonComponents `json:"components"`
+}
+
+// jsonComponents holds the saved values of one component type
+type jsonComponents struct {
+	Version  int               `json:"version"`
+	Entities []Entity          `json:"entities"`
+	Values   []json.RawMessage `json:"values"`
+}
+
+// SaveJSON writes every live entity and its components that have a registered codec
+// Components without a codec are not saved
+func (w *World) SaveJSON(out io.Writer) error {
+	save := jsonSave{
+		Entities:   make([]Entity, 0, w.entityManager.LiveCount()),
+		Components: make(map[string]jsonComponents, len(w.codecs)),
+	}
+	w.entityManager.ForEachAlive(func(entity Entity) {
+		save.Entities = append(save.Entities, entity)
+	})
+
+	for name, codec := range w.codecs {
+		entities, values, err := codec.save(w)
+		if err != nil {
+			return fmt.Errorf("ecs: encoding component %q: %w", name, err)
+		}
+		if len(entities) > 0 {
+			save.Components[name] = jsonComponents{Version: codec.version, Entities: entities, Values: values}
+		}
+	}
+
+	return json.NewEncoder(out).Encode(save)
+}
+
+// LoadJSON creates the entities of a SaveJSON document in this world, migrating
+// component values saved with older codec versions, and returns the new handle for
+// every saved entity; on error nothing is created
+func (w *World) LoadJSON(in io.Reader) (map[Entity]Entity, error) {
+	var save jsonSave
+	if err := json.NewDecoder(in).Decode(&save); err != nil {
+		return nil, fmt.Errorf("ecs: reading save: %w", err)
+	}
+
+	saved := make(map[Entity]bool, len(save.Entities))
+	for _, entity := range save.Entities {
+		saved[entity] = true
+	}
+
+	names := make([]string, 0, len(save.Components))
+	for name := range save.Components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Decode everything up front so a bad value leaves the world untouched
+	type decoded struct {
+		codec    *componentCodec
+		entities []Entity
+		values   []any
+	}
+	pending := make([]decoded, 0, len(names))
+	for _, name := range names {
+		components := save.Components[name]
+		codec, exists := w.codecByName(name)
+		if !exists {
+			return nil, fmt.Errorf("ecs: no codec registered for component %q", name)
+		}
+		if len(components.Entities) != len(components.Values) {
+			return nil, fmt.Errorf("ecs: component %q has %d entities but %d values", name, len(components.Entities), len(components.Values))
+		}
+
+		values := make([]any, len(components.Values))
+		for i, raw := range components.Values {
+			if !saved[components.Entities[i]] {
+				return nil, fmt.Errorf("ecs: component %q refers to unsaved entity %s", name, components.Entities[i])
+			}
+
+			raw, err := w.migrate(codec, components.Version, raw)
+			if err != nil {
+				return nil, err
+			}
+			if values[i], err = codec.decode(raw); err != nil {
+				return nil, fmt.Errorf("ecs: decoding component %q: %w", name, err)
+			}
+		}
+		pending = append(pending, decoded{codec: codec, entities: components.Entities, values: values})
+	}
+
+	mapping := make(map[Entity]Entity, len(save.Entities))
+	for _, entity := range save.Entities {
+		mapping[entity] = w.CreateEntity()
+	}
+	for _, p := range pending {
+		for i, entity := range p.entities {
+			p.codec.add(w, mapping[entity], p.values[i])
+		}
+	}
+	return mapping, nil
+}
+
+// binarySave is the gob document written by SaveBinary
+// It holds the entity manager state as is, so loading reproduces every handle
+type binarySave struct {
+	Generations []uint32
+	Alive       []bool
+	Free        []uint32
+	Retired     int
+	Disabled    []Entity
+	Frame       uint64  // World.Frame at save time
+	Elapsed     float64 // World.Elapsed at save time
+	Components  []binaryComponents
+}
+
+// binaryComponents holds one pool: its entities in dense order and their gob-encoded values
+type binaryComponents struct {
+	Name     string
+	ID       ComponentID
+	Version  int
+	Entities []Entity
+	Values   []byte
+}
+
+// SaveBinary writes the world's entities and every component that has a registered
+// codec in a compact gob encoding; unlike SaveJSON it keeps entity handles, so
+// LoadBinary restores the exact same handles, including stale ones staying stale
+func (w *World) SaveBinary(out io.Writer) error {
+	em := w.entityManager
+	save := binarySave{
+		Generations: em.entities,
+		Alive:       em.alive,
+		Free:        em.free,
+		Retired:     em.retired,
+		Disabled:    w.disabled.Data(),
+		Frame:       w.frame,
+		Elapsed:     w.elapsed,
+		Components:  make([]binaryComponents, 0, len(w.codecs)),
+	}
+
+	for _, id := range w.componentRegistry.order {
+		codec, exists := w.codecs[w.componentRegistry.names[id]]
+		if !exists {
+			continue
+		}
+
+		entities, values, err := codec.saveBinary(w)
+		if err != nil {
+			return fmt.Errorf("ecs: encoding component %q: %w", codec.name, err)
+		}
+		if len(entities) > 0 {
+			save.Components = append(save.Components, binaryComponents{
+				Name:     codec.name,
+				ID:       id,
+				Version:  codec.version,
+				Entities: entities,
+				Values:   values,
+			})
+		}
+	}
+
+	return gob.NewEncoder(out).Encode(save)
+}
+
+// LoadBinary restores a SaveBinary document into this world, which must have no live
+// entities, e.g. a new or cleared world; codecs are matched by type name, so
+// component IDs may differ from the saving world. Migrations are JSON-only, so a
+// value saved with another codec version is an error. On error nothing changes
+// The frame count and elapsed time are restored along with the entities
+func (w *World) LoadBinary(in io.Reader) error {
+	if w.entityManager.LiveCount() > 0 {
+		return fmt.Errorf("ecs: LoadBinary needs a world without live entities, found %d", w.entityManager.LiveCount())
+	}
+
+	commit, err := w.decodeBinary(in)
+	if err != nil {
+		return err
+	}
+	commit()
+	return nil
+}
+
+// decodeBinary reads and validates a SaveBinary document, returning a function that
+// loads it into the world; the world is not touched until commit is called
+func (w *World) decodeBinary(in io.Reader) (func(), error) {
+	var save binarySave
+	if err := gob.NewDecoder(in).Decode(&save); err != nil {
+		return nil, fmt.Errorf("ecs: reading save: %w", err)
+	}
+	if err := checkRestore(save.Generations, save.Alive, save.Free); err != nil {
+		return nil, err
+	}
+
+	// The saved handle of an entity alive at save time
+	isSaved := func(entity Entity) bool {
+		index := entity.Index()
+		return entity.IsValid() && index < uint32(len(save.Alive)) && index < uint32(len(save.Generations)) &&
+			save.Alive[index] && save.Generations[index] == entity.Generation()
+	}
+
+	// Decode everything up front so a bad value leaves the world untouched
+	type decoded struct {
+		entities []Entity
+		insert   func(w *World, entities []Entity)
+	}
+	pending := make([]decoded, 0, len(save.Components))
+	for _, components := range save.Components {
+		codec, exists := w.codecByName(components.Name)
+		if !exists {
+			return nil, fmt.Errorf("ecs: no codec registered for component %q", components.Name)
+		}
+		if components.Version != codec.version {
+			return nil, fmt.Errorf("ecs: saved %s version %d differs from current version %d", codec.name, components.Version, codec.version)
+		}
+		for _, entity := range components.Entities {
+			if !isSaved(entity) {
+				return nil, fmt.Errorf("ecs: component %q refers to unsaved entity %s", components.Name, entity)
+			}
+		}
+
+		insert, err := codec.loadBinary(components.Values, len(components.Entities))
+		if err != nil {
+			return nil, fmt.Errorf("ecs: decoding component %q: %w", components.Name, err)
+		}
+		pending = append(pending, decoded{entities: components.Entities, insert: insert})
+	}
+	for _, entity := range save.Disabled {
+		if !isSaved(entity) {
+			return nil, fmt.Errorf("ecs: disabled entity %s was not saved", entity)
+		}
+	}
+
+	return func() {
+		// Validated above, so restore cannot fail
+		_ = w.entityManager.restore(save.Generations, save.Alive, save.Free, save.Retired)
+		w.entityManager.ForEachAlive(w.eventLogger.EntityCreated)
+		w.disabled.Clear()
+		for _, entity := range save.Disabled {
+			w.disabled.Insert(entity)
+		}
+		w.frame, w.elapsed = save.Frame, save.Elapsed
+		for _, p := range pending {
+			p.insert(w, p.entities)
+		}
+	}, nil
+}
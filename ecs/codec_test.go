@@ -0,0 +1,260 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSpawnFromMap(t *testing.T) {
+	w := NewWorld()
+	RegisterCodec[testPosition](w)
+	RegisterCodec[testHealth](w)
+
+	entity, err := w.SpawnFromMap(map[string]json.RawMessage{
+		"testPosition":   json.RawMessage(`{"X": 1, "Y": 2}`),
+		"ecs.testHealth": json.RawMessage(`{"HP": 7}`),
+	})
+	if err != nil {
+		t.Fatalf("SpawnFromMap: %v", err)
+	}
+	if pos, _ := GetComponent[testPosition](w, entity); pos != (testPosition{X: 1, Y: 2}) {
+		t.Fatalf("position = %v", pos)
+	}
+	if health, _ := GetComponent[testHealth](w, entity); health.HP != 7 {
+		t.Fatalf("health = %v", health)
+	}
+}
+
+func TestSpawnFromMapErrors(t *testing.T) {
+	w := NewWorld()
+	RegisterCodec[testPosition](w)
+	Register[testVelocity](w.componentRegistry) // Registered, but without a codec
+
+	for name, raw := range map[string]string{
+		"testVelocity": `{}`,
+		"Missing":      `{}`,
+		"testPosition": `{"X": "not a number"}`,
+	} {
+		_, err := w.SpawnFromMap(map[string]json.RawMessage{
+			"testPosition": json.RawMessage(`{}`),
+			name:           json.RawMessage(raw),
+		})
+		if err == nil {
+			t.Fatalf("SpawnFromMap with %s = %s succeeded, want an error", name, raw)
+		}
+	}
+	if live := w.entityManager.LiveCount(); live != 0 {
+		t.Fatalf("failed spawns left %d entities", live)
+	}
+}
+
+// testVitals is at layout version 3; version 1 saved {"HP"} and version 2 {"Health"}
+type testVitals struct {
+	Health int
+	Mana   int
+}
+
+func TestLoadJSONMigratesOldVersions(t *testing.T) {
+	w := NewWorld()
+	RegisterCodecVersion[testVitals](w, 3)
+	var applied []string
+	w.RegisterMigration("testVitals", 1, 2, func(raw json.RawMessage) (json.RawMessage, error) {
+		applied = append(applied, "1->2")
+		var v1 struct{ HP int }
+		if err := json.Unmarshal(raw, &v1); err != nil {
+			return nil, err
+		}
+		return json.Marshal(map[string]int{"Health": v1.HP})
+	})
+	w.RegisterMigration("ecs.testVitals", 2, 3, func(raw json.RawMessage) (json.RawMessage, error) {
+		applied = append(applied, "2->3")
+		var v2 map[string]any
+		if err := json.Unmarshal(raw, &v2); err != nil {
+			return nil, err
+		}
+		v2["Mana"] = 10
+		return json.Marshal(v2)
+	})
+
+	saved := makeEntity(3, 1)
+	blob := fmt.Sprintf(`{"entities": [%[1]d], "components": {"ecs.testVitals":
+		{"version": 1, "entities": [%[1]d], "values": [{"HP": 42}]}}}`, uint32(saved))
+	mapping, err := w.LoadJSON(strings.NewReader(blob))
+	if err != nil {
+		t.Fatalf("LoadJSON: %v", err)
+	}
+	if !slices.Equal(applied, []string{"1->2", "2->3"}) {
+		t.Fatalf("migrations applied %v, want 1->2 then 2->3", applied)
+	}
+	if vitals, ok := GetComponent[testVitals](w, mapping[saved]); !ok || vitals != (testVitals{Health: 42, Mana: 10}) {
+		t.Fatalf("loaded vitals = %v, %v, want upgraded to version 3", vitals, ok)
+	}
+
+	// Current-version values load without migrating
+	applied = nil
+	blob = fmt.Sprintf(`{"entities": [%[1]d], "components": {"testVitals":
+		{"version": 3, "entities": [%[1]d], "values": [{"Health": 1, "Mana": 2}]}}}`, uint32(saved))
+	if _, err := w.LoadJSON(strings.NewReader(blob)); err != nil || len(applied) != 0 {
+		t.Fatalf("loading a current save: %v, migrations %v", err, applied)
+	}
+}
+
+func TestLoadJSONMigrationErrors(t *testing.T) {
+	w := NewWorld()
+	RegisterCodecVersion[testVitals](w, 2)
+	entity := uint32(makeEntity(0, 1))
+	load := func(version int) error {
+		blob := fmt.Sprintf(`{"entities": [%[1]d], "components": {"testVitals":
+			{"version": %[2]d, "entities": [%[1]d], "values": [{"HP": 1}]}}}`, entity, version)
+		_, err := w.LoadJSON(strings.NewReader(blob))
+		return err
+	}
+
+	if err := load(1); err == nil || !strings.Contains(err.Error(), "no migration") {
+		t.Fatalf("loading without a migration: %v", err)
+	}
+	if err := load(3); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Fatalf("loading a newer version: %v", err)
+	}
+
+	w.RegisterMigration("testVitals", 1, 2, func(json.RawMessage) (json.RawMessage, error) {
+		return nil, fmt.Errorf("corrupt")
+	})
+	if err := load(1); err == nil || !strings.Contains(err.Error(), "corrupt") {
+		t.Fatalf("failing migration: %v", err)
+	}
+	if w.entityManager.LiveCount() != 0 {
+		t.Fatalf("failed loads created %d entities", w.entityManager.LiveCount())
+	}
+	expectPanic(t, "ecs: migration must upgrade to a later version", func() {
+		w.RegisterMigration("testVitals", 2, 2, nil)
+	})
+}
+
+// newSaveWorld returns a world with codecs for the test components, populated with n
+// entities, some destroyed, disabled or tagged
+func newSaveWorld(n int) (*World, []Entity) {
+	w := NewWorld()
+	RegisterCodec[testPosition](w)
+	RegisterCodec[testVelocity](w)
+	RegisterCodec[testTag](w)
+	entities := populateTestWorld(w, n)
+	for i, e := range entities {
+		switch i % 5 {
+		case 1:
+			w.DestroyEntity(e)
+		case 2:
+			AddComponent(w, e, testTag{})
+		case 3:
+			w.DisableEntity(e)
+		}
+	}
+	return w, entities
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	src, entities := newSaveWorld(50)
+	// Reusing a slot leaves the destroyed handle stale in the save
+	reused := src.CreateEntity()
+	AddComponent(src, reused, testPosition{X: -1, Y: 2})
+	src.Update(0.5)
+
+	var buf bytes.Buffer
+	if err := src.SaveBinary(&buf); err != nil {
+		t.Fatalf("SaveBinary: %v", err)
+	}
+	dst := NewWorld()
+	// Codecs register types in another order, so IDs differ from the saving world
+	RegisterCodec[testTag](dst)
+	RegisterCodec[testVelocity](dst)
+	RegisterCodec[testPosition](dst)
+	if err := dst.LoadBinary(&buf); err != nil {
+		t.Fatalf("LoadBinary: %v", err)
+	}
+
+	for _, e := range append(entities, reused) {
+		if src.IsValidEntity(e) != dst.IsValidEntity(e) || src.IsEntityEnabled(e) != dst.IsEntityEnabled(e) {
+			t.Fatalf("%s valid/enabled differs after loading", e)
+		}
+		srcPos, _ := GetComponent[testPosition](src, e)
+		dstPos, _ := GetComponent[testPosition](dst, e)
+		srcVel, hasVel := GetComponent[testVelocity](src, e)
+		dstVel, _ := GetComponent[testVelocity](dst, e)
+		if srcPos != dstPos || srcVel != dstVel || hasVel != HasComponent[testVelocity](dst, e) ||
+			HasComponent[testTag](src, e) != HasComponent[testTag](dst, e) {
+			t.Fatalf("components of %s differ after loading", e)
+		}
+	}
+	if dst.Frame() != src.Frame() || dst.Elapsed() != src.Elapsed() {
+		t.Fatalf("frame %d elapsed %v after loading, want %d and %v", dst.Frame(), dst.Elapsed(), src.Frame(), src.Elapsed())
+	}
+	if created := dst.CreateEntity(); created != src.CreateEntity() {
+		t.Fatalf("next entity after loading is %s, the saving world's would be the same slot", created)
+	}
+	if errs := dst.Validate(); errs != nil {
+		t.Fatalf("Validate after LoadBinary: %v", errs)
+	}
+}
+
+func TestLoadBinaryErrors(t *testing.T) {
+	src, _ := newSaveWorld(5)
+	var buf bytes.Buffer
+	if err := src.SaveBinary(&buf); err != nil {
+		t.Fatalf("SaveBinary: %v", err)
+	}
+	save := buf.Bytes()
+
+	if err := src.LoadBinary(bytes.NewReader(save)); err == nil {
+		t.Fatalf("LoadBinary into a populated world succeeded")
+	}
+	missing := NewWorld()
+	RegisterCodec[testPosition](missing)
+	if err := missing.LoadBinary(bytes.NewReader(save)); err == nil {
+		t.Fatalf("LoadBinary without every codec succeeded")
+	}
+	if missing.entityManager.Size() != 0 {
+		t.Fatalf("failed LoadBinary created %d entity slots", missing.entityManager.Size())
+	}
+	if err := NewWorld().LoadBinary(bytes.NewReader(save[:len(save)/2])); err == nil {
+		t.Fatalf("LoadBinary of a truncated save succeeded")
+	}
+}
+
+func BenchmarkSaveLoad(b *testing.B) {
+	src, _ := newSaveWorld(100000)
+	load := func() *World {
+		w := NewWorld()
+		RegisterCodec[testPosition](w)
+		RegisterCodec[testVelocity](w)
+		RegisterCodec[testTag](w)
+		return w
+	}
+
+	var binary, text bytes.Buffer
+	b.Run("binary", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			binary.Reset()
+			if err := src.SaveBinary(&binary); err != nil {
+				b.Fatal(err)
+			}
+			if err := load().LoadBinary(&binary); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("json", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			text.Reset()
+			if err := src.SaveJSON(&text); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := load().LoadJSON(&text); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package ecs
+
+// PendingEntity refers to an entity queued for creation in a CommandBuffer; its handle
+// is only known once the buffer is flushed
+type PendingEntity int
+
+// CommandBuffer queues structural changes (create/destroy entity, add/remove
+// component) to apply later in one go, e.g. from a worker goroutine that must not
+// touch the world while a parallel region runs
+// A buffer is not safe for concurrent use; give each goroutine its own
+type CommandBuffer struct {
+	commands []func(w *World, created []Entity)
+	pending  int // Entities queued for creation so far
+}
+
+// NewCommandBuffer creates an empty command buffer
+func NewCommandBuffer() *CommandBuffer {
+	return &CommandBuffer{
+		commands: make([]func(w *World, created []Entity), 0),
+	}
+}
+
+// Len returns the number of queued commands
+func (cb *CommandBuffer) Len() int {
+	return len(cb.commands)
+}
+
+// CreateEntity queues the creation of an entity
+func (cb *CommandBuffer) CreateEntity() PendingEntity {
+	pending := PendingEntity(cb.pending)
+	cb.pending++
+	cb.commands = append(cb.commands, func(w *World, created []Entity) {
+		created[pending] = w.CreateEntity()
+	})
+	return pending
+}
+
+// DestroyEntity queues the destruction of an entity
+func (cb *CommandBuffer) DestroyEntity(entity Entity) {
+	cb.commands = append(cb.commands, func(w *World, created []Entity) {
+		w.DestroyEntity(entity)
+	})
+}
+
+// BufferAddComponent queues adding a component to an existing entity
+func BufferAddComponent[T any](cb *CommandBuffer, entity Entity, component T) {
+	cb.commands = append(cb.commands, func(w *World, created []Entity) {
+		AddComponent(w, entity, component)
+	})
+}
+
+// BufferAddPending queues adding a component to an entity queued in the same buffer
+func BufferAddPending[T any](cb *CommandBuffer, pending PendingEntity, component T) {
+	cb.commands = append(cb.commands, func(w *World, created []Entity) {
+		AddComponent(w, created[pending], component)
+	})
+}
+
+// BufferRemoveComponent queues removing a component from an entity
+func BufferRemoveComponent[T any](cb *CommandBuffer, entity Entity) {
+	cb.commands = append(cb.commands, func(w *World, created []Entity) {
+		RemoveComponent[T](w, entity)
+	})
+}
+
+// Flush applies the queued commands to w in the order they were queued and empties
+// the buffer; returns the created entities, indexed by PendingEntity
+func (cb *CommandBuffer) Flush(w *World) []Entity {
+	created := make([]Entity, cb.pending)
+	for _, command := range cb.commands {
+		command(w, created)
+	}
+
+	clear(cb.commands)
+	cb.commands = cb.commands[:0]
+	cb.pending = 0
+	return created
+}
+
+// ParallelCommands returns one empty command buffer per worker, to be filled
+// concurrently and applied with MergeAndFlush after the parallel region
+func (w *World) ParallelCommands(workers int) []*CommandBuffer {
+	buffers := make([]*CommandBuffer, workers)
+	for i := range buffers {
+		buffers[i] = NewCommandBuffer()
+	}
+	return buffers
+}
+
+// MergeAndFlush applies the buffers one after another in slice order, so the result
+// doesn't depend on how the workers were scheduled; returns each buffer's created
+// entities, see CommandBuffer.Flush
+func (w *World) MergeAndFlush(buffers []*CommandBuffer) [][]Entity {
+	created := make([][]Entity, len(buffers))
+	for i, buffer := range buffers {
+		created[i] = buffer.Flush(w)
+	}
+	return created
+}
@@ -0,0 +1,98 @@
+package ecs
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// runParallelCommands has four workers queue changes concurrently, finishing in the
+// given order, then merges their buffers and returns each live entity's position
+func runParallelCommands(t *testing.T, finish []int) ([][]Entity, map[Entity]testPosition) {
+	t.Helper()
+	w := NewWorld()
+	existing := populateTestWorld(w, 8)
+	buffers := w.ParallelCommands(len(finish))
+
+	var wg sync.WaitGroup
+	for worker, buffer := range buffers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			time.Sleep(time.Duration(slices.Index(finish, worker)) * time.Millisecond)
+			for i := range 3 {
+				pending := buffer.CreateEntity()
+				BufferAddPending(buffer, pending, testPosition{X: float64(worker), Y: float64(i)})
+			}
+			buffer.DestroyEntity(existing[worker*2])
+			BufferRemoveComponent[testVelocity](buffer, existing[worker*2+1])
+		}()
+	}
+	wg.Wait()
+
+	created := w.MergeAndFlush(buffers)
+	positions := make(map[Entity]testPosition)
+	for entity, pos := range Range1[testPosition](w) {
+		positions[entity] = *pos
+	}
+	for _, buffer := range buffers {
+		if buffer.Len() != 0 {
+			t.Fatalf("MergeAndFlush left %d commands in a buffer", buffer.Len())
+		}
+	}
+	return created, positions
+}
+
+func TestMergeAndFlushIsOrderStable(t *testing.T) {
+	created, positions := runParallelCommands(t, []int{0, 1, 2, 3})
+	if len(created) != 4 || len(positions) != 4+12 {
+		t.Fatalf("merged %d buffers into %d positions, want 4 and 16", len(created), len(positions))
+	}
+	for worker, entities := range created {
+		for i, entity := range entities {
+			if positions[entity] != (testPosition{X: float64(worker), Y: float64(i)}) {
+				t.Fatalf("worker %d entity %d at %s has position %v", worker, i, entity, positions[entity])
+			}
+		}
+	}
+
+	// Workers finishing in another order produce the same handles and data
+	otherCreated, otherPositions := runParallelCommands(t, []int{3, 1, 0, 2})
+	for worker := range created {
+		if !slices.Equal(created[worker], otherCreated[worker]) {
+			t.Fatalf("worker %d created %v, then %v", worker, created[worker], otherCreated[worker])
+		}
+	}
+	for entity, pos := range positions {
+		if otherPositions[entity] != pos {
+			t.Fatalf("%s has position %v, then %v", entity, pos, otherPositions[entity])
+		}
+	}
+}
+
+func TestCommandBufferFlushOrder(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 2)
+	cb := NewCommandBuffer()
+	pending := cb.CreateEntity()
+	BufferAddPending(cb, pending, testHealth{HP: 1})
+	BufferAddComponent(cb, entities[0], testHealth{HP: 2})
+	// Commands apply in queue order, so the later add wins
+	BufferAddComponent(cb, entities[0], testHealth{HP: 3})
+	cb.DestroyEntity(entities[1])
+	if cb.Len() != 5 || HasComponent[testHealth](w, entities[0]) {
+		t.Fatalf("queued commands were applied early")
+	}
+
+	created := cb.Flush(w)
+	if h, _ := GetComponent[testHealth](w, created[pending]); h.HP != 1 {
+		t.Fatalf("pending entity health = %v", h)
+	}
+	if h, _ := GetComponent[testHealth](w, entities[0]); h.HP != 3 {
+		t.Fatalf("health = %v, want the last queued value", h)
+	}
+	if w.IsValidEntity(entities[1]) {
+		t.Fatalf("queued destroy was not applied")
+	}
+}
@@ -0,0 +1,155 @@
+package ecs
+
+import "bytes"
+
+// loggedCommand is one structural mutation recorded by a CommandLog
+type loggedCommand struct {
+	frame uint64
+	apply func(w *World, handles map[Entity]Entity)
+}
+
+// CommandLog applies structural mutations (create/destroy entity, add/remove component)
+// to a world while recording them with the current frame number, so they can be
+// re-applied after a rollback: Restore a Snapshot taken at frame N, then Replay the
+// commands of the following frames
+// Component values are recorded by value; slices, maps and pointers inside them are shared
+type CommandLog struct {
+	frame    uint64
+	commands []loggedCommand
+}
+
+// NewCommandLog creates an empty command log at frame 0
+func NewCommandLog() *CommandLog {
+	return &CommandLog{
+		commands: make([]loggedCommand, 0),
+	}
+}
+
+// SetFrame sets the frame number recorded with subsequent commands
+func (cl *CommandLog) SetFrame(frame uint64) {
+	cl.frame = frame
+}
+
+// Frame returns the frame number recorded with subsequent commands
+func (cl *CommandLog) Frame() uint64 {
+	return cl.frame
+}
+
+// Len returns the number of recorded commands
+func (cl *CommandLog) Len() int {
+	return len(cl.commands)
+}
+
+// record appends a command at the current frame
+func (cl *CommandLog) record(apply func(w *World, handles map[Entity]Entity)) {
+	cl.commands = append(cl.commands, loggedCommand{frame: cl.frame, apply: apply})
+}
+
+// resolve maps a recorded handle to the one created for it during replay
+func resolve(handles map[Entity]Entity, entity Entity) Entity {
+	if replayed, exists := handles[entity]; exists {
+		return replayed
+	}
+	return entity
+}
+
+// CreateEntity creates an entity in w and records the creation
+func (cl *CommandLog) CreateEntity(w *World) Entity {
+	entity := w.CreateEntity()
+	cl.record(func(w *World, handles map[Entity]Entity) {
+		handles[entity] = w.CreateEntity()
+	})
+	return entity
+}
+
+// DestroyEntity destroys an entity in w and records the destruction
+func (cl *CommandLog) DestroyEntity(w *World, entity Entity) bool {
+	if !w.DestroyEntity(entity) {
+		return false
+	}
+	cl.record(func(w *World, handles map[Entity]Entity) {
+		w.DestroyEntity(resolve(handles, entity))
+	})
+	return true
+}
+
+// LogAddComponent adds a component to an entity in w and records the addition
+func LogAddComponent[T any](cl *CommandLog, w *World, entity Entity, component T) {
+	if !w.entityManager.IsValid(entity) {
+		w.logf(LogLevelWarn, "LogAddComponent[%T] on invalid entity %s ignored", component, entity)
+		return
+	}
+
+	AddComponent(w, entity, component)
+	cl.record(func(w *World, handles map[Entity]Entity) {
+		AddComponent(w, resolve(handles, entity), component)
+	})
+}
+
+// LogRemoveComponent removes a component from an entity in w and records the removal
+func LogRemoveComponent[T any](cl *CommandLog, w *World, entity Entity) bool {
+	if !RemoveComponent[T](w, entity) {
+		return false
+	}
+	cl.record(func(w *World, handles map[Entity]Entity) {
+		RemoveComponent[T](w, resolve(handles, entity))
+	})
+	return true
+}
+
+// Replay re-applies the commands recorded for frames fromFrame through toFrame,
+// inclusive, in recording order, and returns the handle created during replay for
+// every entity the replayed commands created
+// Replaying onto the state the commands were recorded against, e.g. a restored
+// Snapshot, recreates the same handles
+func (cl *CommandLog) Replay(w *World, fromFrame, toFrame uint64) map[Entity]Entity {
+	handles := make(map[Entity]Entity)
+	for _, command := range cl.commands {
+		if command.frame >= fromFrame && command.frame <= toFrame {
+			command.apply(w, handles)
+		}
+	}
+	return handles
+}
+
+// Truncate drops the commands recorded after frame, e.g. before re-simulating
+// those frames following a rollback
+func (cl *CommandLog) Truncate(frame uint64) {
+	kept := cl.commands[:0]
+	for _, command := range cl.commands {
+		if command.frame <= frame {
+			kept = append(kept, command)
+		}
+	}
+	clear(cl.commands[len(kept):])
+	cl.commands = kept
+}
+
+// Snapshot captures the world's entities, codec-registered components, frame count
+// and elapsed time, see SaveBinary
+func (w *World) Snapshot() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := w.SaveBinary(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Restore replaces the world's entities and components with a Snapshot and rewinds
+// its frame count and elapsed time, keeping its systems, codecs and callbacks;
+// components without a codec and all relations are dropped
+// OnEntityDestroyed callbacks are not called for the entities being replaced
+func (w *World) Restore(snapshot []byte) error {
+	commit, err := w.decodeBinary(bytes.NewReader(snapshot))
+	if err != nil {
+		return err
+	}
+
+	w.entityManager.ForEachAlive(func(entity Entity) {
+		w.componentRegistry.RemoveAllComponents(entity)
+		w.eventLogger.EntityDestroyed(entity)
+	})
+	clear(w.relations)
+	commit()
+	return nil
+}
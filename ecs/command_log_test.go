@@ -0,0 +1,122 @@
+package ecs
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+type testLinked struct{}
+
+// testMovement moves every entity by its velocity each update
+type testMovement struct{}
+
+func (testMovement) Update(w *World, deltaTime float64) {
+	Iter2[testPosition, testVelocity](w).ForEach(func(_ Entity, pos *testPosition, vel *testVelocity) {
+		pos.X += vel.X * deltaTime
+		pos.Y += vel.Y * deltaTime
+	})
+}
+
+func (testMovement) GetName() string { return "testMovement" }
+
+// newReplayWorld creates a world with codecs for the test components and a movement system
+func newReplayWorld() *World {
+	w := NewWorld()
+	RegisterCodec[testPosition](w)
+	RegisterCodec[testVelocity](w)
+	w.AddSystem(testMovement{})
+	return w
+}
+
+// stepScripted applies one frame of scripted structural changes through log, then updates
+// Every frame spawns a moving entity; every other frame the lowest index is destroyed
+// and every third frame the newest entity stops moving
+func stepScripted(w *World, log *CommandLog) {
+	frame := w.Frame()
+	log.SetFrame(frame)
+
+	spawned := log.CreateEntity(w)
+	LogAddComponent(log, w, spawned, testPosition{X: float64(frame)})
+	LogAddComponent(log, w, spawned, testVelocity{X: 1, Y: float64(frame % 4)})
+
+	if frame%2 == 1 {
+		live := slices.SortedFunc(slices.Values(With[testPosition](NewQuery(w)).Build().Entities()),
+			func(a, b Entity) int { return int(a.Index()) - int(b.Index()) })
+		log.DestroyEntity(w, live[0])
+	}
+	if frame%3 == 2 {
+		LogRemoveComponent[testVelocity](log, w, spawned)
+	}
+
+	w.Update(0.1)
+}
+
+func TestRestoreThenReplayMatchesNormalRun(t *testing.T) {
+	const n = 5
+
+	// The reference world simply runs n+3 frames
+	reference := newReplayWorld()
+	for i := 0; i < n+3; i++ {
+		stepScripted(reference, NewCommandLog())
+	}
+
+	// The other world snapshots at frame n, runs 3 more frames, then rolls back
+	w := newReplayWorld()
+	log := NewCommandLog()
+	for i := 0; i < n; i++ {
+		stepScripted(w, log)
+	}
+	snapshot, err := w.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		stepScripted(w, log)
+	}
+	// Relations are not part of snapshots, so these must not survive the rollback
+	live := With[testPosition](NewQuery(w)).Build().Entities()
+	AddRelation[testLinked](w, live[0], live[1])
+
+	if err := w.Restore(snapshot); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if w.Frame() != n {
+		t.Fatalf("Frame after Restore = %d, want %d", w.Frame(), n)
+	}
+	if len(w.relations) != 0 {
+		t.Fatalf("relations survived Restore")
+	}
+
+	for frame := uint64(n); frame < n+3; frame++ {
+		log.Replay(w, frame, frame)
+		w.Update(0.1)
+	}
+
+	if w.Frame() != reference.Frame() || w.Elapsed() != reference.Elapsed() {
+		t.Fatalf("clock after replay = frame %d, elapsed %v, want frame %d, elapsed %v",
+			w.Frame(), w.Elapsed(), reference.Frame(), reference.Elapsed())
+	}
+	got, _ := w.Snapshot()
+	want, _ := reference.Snapshot()
+	if !bytes.Equal(got, want) {
+		t.Fatalf("world after restore and replay differs from the normally run world")
+	}
+}
+
+func TestCommandLogTruncate(t *testing.T) {
+	w := NewWorld()
+	log := NewCommandLog()
+	for frame := uint64(0); frame < 4; frame++ {
+		log.SetFrame(frame)
+		log.CreateEntity(w)
+	}
+
+	log.Truncate(1)
+	if log.Len() != 2 {
+		t.Fatalf("Len after Truncate = %d, want 2", log.Len())
+	}
+	if handles := log.Replay(NewWorld(), 0, 10); len(handles) != 2 {
+		t.Fatalf("Replay after Truncate created %d entities, want 2", len(handles))
+	}
+}
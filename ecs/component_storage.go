@@ -1,7 +1,12 @@
 package ecs
 
 import (
+	"fmt"
 	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 	"unsafe"
 )
 
@@ -9,6 +14,27 @@ import (
 type ComponentPool[T any] struct {
 	entities   *SparseSet // Tracks which entities have this component
 	components []T        // Component data aligned with entities dense array
+	modCount   uint64     // Incremented on every structural change (insert/remove/clear/reorder)
+
+	onInsert []func(Entity) // Called after an entity gains the component
+	onRemove []func(Entity) // Called before an entity loses the component
+
+	onReplace []func(entity Entity, old, new T) // Called after Insert overwrites an existing component
+	trackers  []*SparseSet                      // Receive every entity that gains or replaces the component
+
+	history      map[Entity][]T // Per-entity inserted values, oldest first (nil when disabled)
+	historyLimit int            // Maximum number of values kept per entity
+
+	changed *SparseSet // Entities whose component was marked changed (nil until first mark)
+
+	onAccess func() // Called on Get/GetPtr/Insert/Remove while access profiling is on
+
+	group any // Group that owns this pool's order, nil when not grouped
+
+	growthFactor float64 // Capacity multiplier when full, 0 leaves growth to append
+	stableOrder  bool    // Remove shifts later components down instead of swap-and-pop
+
+	less func(Entity, *T, Entity, *T) bool // Comparator behind Less, set with SetLess
 }
 
 // NewComponentPool creates a new component pool for type T
@@ -21,43 +47,180 @@ func NewComponentPool[T any]() *ComponentPool[T] {
 
 // Insert adds a component to an entity
 func (cp *ComponentPool[T]) Insert(entity Entity, component T) {
+	if cp.onAccess != nil {
+		cp.onAccess()
+	}
+	if cp.history != nil && entity.IsValid() {
+		cp.recordHistory(entity, component)
+	}
+
 	if cp.entities.Contains(entity) {
 		// Update existing component
 		index := cp.entities.Index(entity)
+		old := cp.components[index]
 		cp.components[index] = component
+		for _, hook := range cp.onReplace {
+			hook(entity, old, component)
+		}
+		cp.notifyTrackers(entity)
 		return
 	}
 
 	// Add new component
 	if cp.entities.Insert(entity) {
+		cp.modCount++
 		// Grow component array if needed
 		if len(cp.components) <= cp.entities.Size()-1 {
+			if cp.growthFactor > 0 && len(cp.components) == cap(cp.components) {
+				cp.Reserve(max(int(float64(cap(cp.components))*cp.growthFactor), cap(cp.components)+1))
+			}
 			cp.components = append(cp.components, component)
 		} else {
 			cp.components[cp.entities.Size()-1] = component
 		}
+
+		for _, hook := range cp.onInsert {
+			hook(entity)
+		}
+		cp.notifyTrackers(entity)
+	}
+}
+
+// InsertMany adds components to entities pairwise, e.g. when rebuilding a pool during
+// deserialization; storage grows once up front and each pair takes a single sparse
+// lookup, filling the dense, sparse and component arrays in one pass
+// Duplicates are allowed: an entity that already has the component, or appears more
+// than once, ends up with its last value, with replace hooks run as Insert would
+// Invalid entities are skipped
+func (cp *ComponentPool[T]) InsertMany(entities []Entity, components []T) {
+	if len(entities) != len(components) {
+		panic("ecs: InsertMany needs one component per entity")
+	}
+	if cp.onAccess != nil {
+		cp.onAccess()
+	}
+
+	cp.Reserve(cp.entities.Size() + len(entities))
+	for i, entity := range entities {
+		if !entity.IsValid() {
+			continue
+		}
+		component := components[i]
+		if cp.history != nil {
+			cp.recordHistory(entity, component)
+		}
+
+		index, inserted := cp.entities.insertOrIndex(entity)
+		if !inserted {
+			old := cp.components[index]
+			cp.components[index] = component
+			for _, hook := range cp.onReplace {
+				hook(entity, old, component)
+			}
+			cp.notifyTrackers(entity)
+			continue
+		}
+
+		cp.components = append(cp.components[:index], component)
+		cp.modCount++
+		for _, hook := range cp.onInsert {
+			hook(entity)
+		}
+		cp.notifyTrackers(entity)
+	}
+}
+
+// track makes the pool insert every entity that gains or replaces its component
+// into set; unlike hooks, a tracker can be detached again with untrack
+func (cp *ComponentPool[T]) track(set *SparseSet) {
+	cp.trackers = append(cp.trackers, set)
+}
+
+// untrack detaches a set attached with track
+func (cp *ComponentPool[T]) untrack(set *SparseSet) {
+	cp.trackers = slices.DeleteFunc(cp.trackers, func(tracker *SparseSet) bool {
+		return tracker == set
+	})
+}
+
+// notifyTrackers records an inserted or replaced entity in every tracker
+func (cp *ComponentPool[T]) notifyTrackers(entity Entity) {
+	for _, tracker := range cp.trackers {
+		tracker.Insert(entity)
 	}
 }
 
 // Remove removes a component from an entity
 func (cp *ComponentPool[T]) Remove(entity Entity) bool {
+	if cp.onAccess != nil {
+		cp.onAccess()
+	}
 	if !cp.entities.Contains(entity) {
 		return false
 	}
 
+	for _, hook := range cp.onRemove {
+		hook(entity)
+	}
+
+	if cp.history != nil {
+		delete(cp.history, entity)
+	}
+	if cp.changed != nil {
+		cp.changed.Remove(entity)
+	}
+
 	index := cp.entities.Index(entity)
 	lastIndex := cp.entities.Size() - 1
 
-	// Move last component to removed position before removing from sparse set
-	if index != lastIndex {
+	if cp.stableOrder {
+		// Shift later components down to keep insertion order
+		copy(cp.components[index:lastIndex], cp.components[index+1:lastIndex+1])
+	} else if index != lastIndex {
+		// Move last component to removed position before removing from sparse set
 		cp.components[index] = cp.components[lastIndex]
 	}
+	// Zero the vacated tail so pointers it holds don't keep memory alive
+	var zero T
+	cp.components[lastIndex] = zero
 
+	cp.modCount++
+	if cp.stableOrder {
+		return cp.entities.removeOrdered(entity)
+	}
 	return cp.entities.Remove(entity)
 }
 
+// SetStableOrder makes Remove keep the remaining components in insertion order by
+// shifting later ones down, instead of moving the last one into the gap
+// Iteration order then stays deterministic across removals, at the cost of O(n)
+// removal rather than O(1); Sort and Respect still reorder the pool
+// Panics when enabling it on a grouped pool, whose group reorders it on every change
+func (cp *ComponentPool[T]) SetStableOrder(enabled bool) {
+	if enabled && cp.group != nil {
+		panic("ecs: stable order on a grouped component pool")
+	}
+	cp.stableOrder = enabled
+}
+
+// RemoveAndGet removes a component from an entity and returns the removed value
+func (cp *ComponentPool[T]) RemoveAndGet(entity Entity) (T, bool) {
+	var zero T
+	if !cp.entities.Contains(entity) {
+		return zero, false
+	}
+
+	// Read before Remove moves the last component into this slot
+	component := cp.components[cp.entities.Index(entity)]
+	cp.Remove(entity)
+	return component, true
+}
+
 // Get retrieves a component for an entity
 func (cp *ComponentPool[T]) Get(entity Entity) (T, bool) {
+	if cp.onAccess != nil {
+		cp.onAccess()
+	}
 	var zero T
 	if !cp.entities.Contains(entity) {
 		return zero, false
@@ -69,6 +232,9 @@ func (cp *ComponentPool[T]) Get(entity Entity) (T, bool) {
 
 // GetPtr returns a pointer to the component for an entity
 func (cp *ComponentPool[T]) GetPtr(entity Entity) *T {
+	if cp.onAccess != nil {
+		cp.onAccess()
+	}
 	if !cp.entities.Contains(entity) {
 		return nil
 	}
@@ -82,6 +248,46 @@ func (cp *ComponentPool[T]) Contains(entity Entity) bool {
 	return cp.entities.Contains(entity)
 }
 
+// Reserve grows the pool's capacity to hold at least n components without reallocating
+func (cp *ComponentPool[T]) Reserve(n int) {
+	cp.entities.reserve(n)
+	if n > cap(cp.components) {
+		// Allocate exactly n, slices.Grow would round up like append and defeat SetGrowthFactor
+		components := make([]T, len(cp.components), n)
+		copy(components, cp.components)
+		cp.components = components
+	}
+}
+
+// SetGrowthFactor sets how much capacity is multiplied by when an insert finds the pool
+// full, e.g. 1.25 for gentler spikes than append's doubling; 0 restores append's policy
+func (cp *ComponentPool[T]) SetGrowthFactor(factor float64) {
+	if factor != 0 && factor <= 1 {
+		panic("ecs: growth factor must be greater than 1")
+	}
+	cp.growthFactor = factor
+}
+
+// Shrink releases memory left over from mass removals, see SparseSet.Shrink
+func (cp *ComponentPool[T]) Shrink() {
+	cp.entities.Shrink()
+	if size := cp.entities.Size(); cap(cp.components) > 2*size {
+		cp.components = slices.Clone(cp.components[:size])
+	}
+}
+
+// ShrinkToFit reallocates the components and the entity set to exactly the pool's
+// size, e.g. after loading with InsertMany into an over-reserved pool
+// Pointers obtained from the pool before the call are no longer the stored components
+func (cp *ComponentPool[T]) ShrinkToFit() {
+	cp.entities.ShrinkToFit()
+	if size := cp.entities.Size(); cap(cp.components) != size {
+		components := make([]T, size)
+		copy(components, cp.components[:size])
+		cp.components = components
+	}
+}
+
 // Size returns the number of entities with this component
 func (cp *ComponentPool[T]) Size() int {
 	return cp.entities.Size()
@@ -94,8 +300,95 @@ func (cp *ComponentPool[T]) Empty() bool {
 
 // Clear removes all components
 func (cp *ComponentPool[T]) Clear() {
+	entities := cp.entities.Data()
+	if cp.group != nil && len(entities) > 0 {
+		// Group hooks reorder the pool as entities leave
+		entities = slices.Clone(entities)
+	}
+	for _, hook := range cp.onRemove {
+		for _, entity := range entities {
+			hook(entity)
+		}
+	}
+
+	cp.modCount++
 	cp.entities.Clear()
+	clear(cp.components)
 	cp.components = cp.components[:0]
+	if cp.history != nil {
+		cp.history = make(map[Entity][]T)
+	}
+	if cp.changed != nil {
+		cp.changed.Clear()
+	}
+}
+
+// MarkChanged flags an entity's component as changed for change detection
+func (cp *ComponentPool[T]) MarkChanged(entity Entity) {
+	if !cp.entities.Contains(entity) {
+		return
+	}
+	if cp.changed == nil {
+		cp.changed = NewSparseSet()
+	}
+	cp.changed.Insert(entity)
+}
+
+// Changed returns the entities marked changed since the last ClearChanged
+func (cp *ComponentPool[T]) Changed() []Entity {
+	if cp.changed == nil {
+		return nil
+	}
+	return append([]Entity(nil), cp.changed.Data()...)
+}
+
+// ClearChanged resets the change flags of all entities
+func (cp *ComponentPool[T]) ClearChanged() {
+	if cp.changed != nil {
+		cp.changed.Clear()
+	}
+}
+
+// EnableHistory makes Insert keep an append-only list of the values written for
+// each entity, bounded to the last maxLength values; 0 disables history
+func (cp *ComponentPool[T]) EnableHistory(maxLength int) {
+	if maxLength <= 0 {
+		cp.history = nil
+		cp.historyLimit = 0
+		return
+	}
+
+	if cp.history == nil {
+		cp.history = make(map[Entity][]T)
+	}
+	cp.historyLimit = maxLength
+	for entity, values := range cp.history {
+		if len(values) > maxLength {
+			cp.history[entity] = values[len(values)-maxLength:]
+		}
+	}
+}
+
+// History returns the values inserted for an entity, oldest first
+// The last value is the current component; returns nil if history is disabled
+func (cp *ComponentPool[T]) History(entity Entity) []T {
+	values := cp.history[entity]
+	if len(values) == 0 {
+		return nil
+	}
+
+	result := make([]T, len(values))
+	copy(result, values)
+	return result
+}
+
+// recordHistory appends a value to an entity's history, dropping the oldest over the limit
+func (cp *ComponentPool[T]) recordHistory(entity Entity, component T) {
+	values := append(cp.history[entity], component)
+	if len(values) > cp.historyLimit {
+		values = values[len(values)-cp.historyLimit:]
+	}
+	cp.history[entity] = values
 }
 
 // Entities returns the sparse set of entities
@@ -108,24 +401,109 @@ func (cp *ComponentPool[T]) Data() []T {
 	return cp.components[:cp.entities.Size()]
 }
 
+// DataWithEntities returns the dense entity and component slices in lockstep, so
+// components[i] belongs to entities[i]; both are valid until the next insert or remove
+func (cp *ComponentPool[T]) DataWithEntities() ([]Entity, []T) {
+	return cp.entities.Data(), cp.components[:cp.entities.Size()]
+}
+
 // ForEach iterates over all entities and their components
+// Like Go maps, it panics if fn structurally modifies the pool (insert/remove/clear)
+// instead of silently skipping entities; use ForEachSafe when fn needs to do that
 func (cp *ComponentPool[T]) ForEach(fn func(Entity, *T)) {
+	modCount := cp.modCount
 	entities := cp.entities.Data()
 	for i, entity := range entities {
 		fn(entity, &cp.components[i])
+		if cp.modCount != modCount {
+			panic("ecs: pool modified during iteration")
+		}
+	}
+}
+
+// Batches yields the dense entities and component data in aligned chunks of up to
+// size elements (e.g. for instanced draw calls), the last chunk holding the remainder
+// The slices alias pool storage and must not be retained or resized
+func (cp *ComponentPool[T]) Batches(size int, fn func(entities []Entity, data []T)) {
+	entities := cp.entities.Data()
+	if size <= 0 {
+		size = len(entities)
+	}
+
+	modCount := cp.modCount
+	for start := 0; start < len(entities); start += size {
+		end := min(start+size, len(entities))
+		fn(entities[start:end], cp.components[start:end])
+		if cp.modCount != modCount {
+			panic("ecs: pool modified during iteration")
+		}
+	}
+}
+
+// ForEachSafe iterates over a snapshot of the entities so fn may modify the pool
+// Entities removed before they are reached are skipped, new entities are not visited
+func (cp *ComponentPool[T]) ForEachSafe(fn func(Entity, *T)) {
+	snapshot := make([]Entity, cp.entities.Size())
+	copy(snapshot, cp.entities.Data())
+	for _, entity := range snapshot {
+		if ptr := cp.GetPtr(entity); ptr != nil {
+			fn(entity, ptr)
+		}
+	}
+}
+
+// ModCount returns the number of structural changes made to the pool
+func (cp *ComponentPool[T]) ModCount() uint64 {
+	return cp.modCount
+}
+
+// swap exchanges two dense positions, keeping entities and components aligned
+func (cp *ComponentPool[T]) swap(i, j int) {
+	if i == j {
+		return
 	}
+	cp.entities.Swap(i, j)
+	cp.components[i], cp.components[j] = cp.components[j], cp.components[i]
+	cp.modCount++
 }
 
-// Sort sorts components by the given comparison function
+// Sort sorts components by the given comparison function; components that compare
+// equal keep their relative order
 func (cp *ComponentPool[T]) Sort(less func(Entity, *T, Entity, *T) bool) {
-	cp.entities.Sort(func(a, b Entity) bool {
-		indexA := cp.entities.Index(a)
-		indexB := cp.entities.Index(b)
-		return less(a, &cp.components[indexA], b, &cp.components[indexB])
-	})
+	previous := cp.less
+	cp.less = less
+	sort.Stable(cp)
+	cp.less = previous
+	cp.modCount++
+}
+
+// SetLess sets the comparator behind Less, so the pool can be passed to the sort
+// package directly, e.g. pool.SetLess(byDepth); sort.Stable(pool)
+func (cp *ComponentPool[T]) SetLess(less func(Entity, *T, Entity, *T) bool) {
+	cp.less = less
+}
+
+// Len returns the number of components, for sort.Interface
+func (cp *ComponentPool[T]) Len() int {
+	return cp.entities.Size()
 }
 
-// Respect reorders this pool to match another sparse set's order
+// Less compares the components at two dense positions with the comparator set by
+// SetLess, for sort.Interface
+func (cp *ComponentPool[T]) Less(i, j int) bool {
+	if cp.less == nil {
+		panic("ecs: ComponentPool.Less without a comparator, see SetLess")
+	}
+	return cp.less(cp.entities.At(i), &cp.components[i], cp.entities.At(j), &cp.components[j])
+}
+
+// Swap exchanges the components at two dense positions, keeping the entities'
+// sparse indices consistent, for sort.Interface
+func (cp *ComponentPool[T]) Swap(i, j int) {
+	cp.swap(i, j)
+}
+
+// Respect reorders this pool to match another sparse set's order in O(n+m)
 func (cp *ComponentPool[T]) Respect(other *SparseSet) {
 	if other.Size() == 0 {
 		return
@@ -143,22 +521,16 @@ func (cp *ComponentPool[T]) Respect(other *SparseSet) {
 		}
 	}
 
-	// Then add remaining components
+	// Then add remaining components, other is itself an O(1) membership test
 	entities := cp.entities.Data()
 	for i, entity := range entities {
-		found := false
-		for j := 0; j < other.Size(); j++ {
-			if other.At(j) == entity {
-				found = true
-				break
-			}
-		}
-		if !found {
+		if !other.Contains(entity) {
 			newComponents = append(newComponents, cp.components[i])
 		}
 	}
 
 	// Update entities order and components
+	cp.modCount++
 	cp.entities.Respect(other)
 	copy(cp.components[:len(newComponents)], newComponents)
 }
@@ -171,6 +543,19 @@ type IComponentStorage interface {
 	Clear()
 	Entities() *SparseSet
 	TypeName() string
+	CloneComponent(src, dst Entity) bool
+	swapComponents(a, b Entity)
+	GetAny(entity Entity) (any, bool)
+	SetAny(entity Entity, value any) bool
+	ClearChanged()
+	Shrink()
+	ShrinkToFit()
+	Reserve(n int)
+	setAccessHook(fn func())
+	registerInto(cr *ComponentRegistry) IComponentStorage
+	memoryStats() PoolMemoryStats
+	validate() []error
+	pointer(entity Entity) unsafe.Pointer
 }
 
 // TypedStorage wraps ComponentPool to implement IComponentStorage
@@ -224,6 +609,124 @@ func (ts *TypedStorage[T]) TypeName() string {
 	return ts.typeName
 }
 
+// Shrink releases memory left over from mass removals
+func (ts *TypedStorage[T]) Shrink() {
+	ts.pool.Shrink()
+}
+
+// ShrinkToFit reallocates the pool to exactly its size
+func (ts *TypedStorage[T]) ShrinkToFit() {
+	ts.pool.ShrinkToFit()
+}
+
+// Reserve grows the storage's capacity to hold at least n components
+func (ts *TypedStorage[T]) Reserve(n int) {
+	ts.pool.Reserve(n)
+}
+
+// ClearChanged resets the change flags of all entities
+func (ts *TypedStorage[T]) ClearChanged() {
+	ts.pool.ClearChanged()
+}
+
+// setAccessHook installs the access profiling callback, nil removes it
+func (ts *TypedStorage[T]) setAccessHook(fn func()) {
+	ts.pool.onAccess = fn
+}
+
+// GetAny returns a copy of the entity's component as an untyped value
+func (ts *TypedStorage[T]) GetAny(entity Entity) (any, bool) {
+	component, exists := ts.pool.Get(entity)
+	if !exists {
+		return nil, false
+	}
+	return component, true
+}
+
+// SetAny adds or overwrites an entity's component from an untyped value
+// Returns false if the value is not of the storage's component type
+func (ts *TypedStorage[T]) SetAny(entity Entity, value any) bool {
+	component, ok := value.(T)
+	if !ok {
+		return false
+	}
+
+	ts.pool.Insert(entity, component)
+	return true
+}
+
+// registerInto registers this storage's component type in another registry and
+// returns the storage there
+func (ts *TypedStorage[T]) registerInto(cr *ComponentRegistry) IComponentStorage {
+	id := Register[T](cr)
+	storage, _ := cr.GetStorageByID(id)
+	return storage
+}
+
+// pointer returns an untyped pointer to an entity's component, or nil
+func (ts *TypedStorage[T]) pointer(entity Entity) unsafe.Pointer {
+	return unsafe.Pointer(ts.pool.GetPtr(entity))
+}
+
+// validate checks the pool's sparse set and that every entity has a component slot
+func (ts *TypedStorage[T]) validate() []error {
+	errs := ts.pool.entities.validate()
+	if size := ts.pool.entities.Size(); len(ts.pool.components) < size {
+		errs = append(errs, fmt.Errorf("%d components for %d entities", len(ts.pool.components), size))
+	}
+	return errs
+}
+
+// memoryStats reports the storage's lengths, capacities and footprint
+func (ts *TypedStorage[T]) memoryStats() PoolMemoryStats {
+	var zero T
+	pool := ts.pool
+	stats := PoolMemoryStats{
+		Name:      ts.typeName,
+		Len:       pool.Size(),
+		Cap:       cap(pool.components),
+		SparseLen: len(pool.entities.sparse) * sparsePageSize,
+		DenseCap:  cap(pool.entities.dense),
+		ElemBytes: int(unsafe.Sizeof(zero)),
+	}
+
+	stats.Bytes = stats.Cap*stats.ElemBytes + stats.DenseCap*int(unsafe.Sizeof(Entity(0)))
+	stats.Bytes += len(pool.entities.sparse) * int(unsafe.Sizeof([]int32(nil)))
+	for _, page := range pool.entities.sparse {
+		stats.Bytes += cap(page) * int(unsafe.Sizeof(int32(0)))
+	}
+	return stats
+}
+
+// CloneComponent copies src's component value to dst, adding or overwriting it
+// The copy is shallow: slices, maps and pointers inside the component are shared
+func (ts *TypedStorage[T]) CloneComponent(src, dst Entity) bool {
+	component, exists := ts.pool.Get(src)
+	if !exists {
+		return false
+	}
+
+	ts.pool.Insert(dst, component)
+	return true
+}
+
+// swapComponents exchanges two entities' components, moving it over when only one has it
+func (ts *TypedStorage[T]) swapComponents(a, b Entity) {
+	componentA, hasA := ts.pool.Get(a)
+	componentB, hasB := ts.pool.Get(b)
+	switch {
+	case hasA && hasB:
+		ts.pool.Insert(a, componentB)
+		ts.pool.Insert(b, componentA)
+	case hasA:
+		ts.pool.Remove(a)
+		ts.pool.Insert(b, componentA)
+	case hasB:
+		ts.pool.Remove(b)
+		ts.pool.Insert(a, componentB)
+	}
+}
+
 // ComponentID represents a unique identifier for a component type
 type ComponentID uint32
 
@@ -234,6 +737,21 @@ type ComponentRegistry struct {
 	idToType map[ComponentID]reflect.Type
 	storages map[ComponentID]IComponentStorage
 	names    map[ComponentID]string
+	order    []ComponentID // Registered IDs in registration order
+
+	signatures *signatureTable      // Per-entity component bitsets, kept in sync by pool hooks
+	persistent map[ComponentID]bool // Types whose data survives World.Clear
+	queryOnly  map[ComponentID]bool // Types registered by query criteria and never registered since
+	version    uint64               // Incremented on registration and pool membership changes
+
+	onAccess func(ComponentID) // Access profiling callback installed on every pool, nil when off
+	logger   Logger            // Told of every component addition and removal, nil when off
+
+	concurrent bool         // Guard registration and type lookups with mu
+	mu         sync.RWMutex // Held by Register, GetComponentID and GetStorage when concurrent
+
+	recycleIDs bool          // Let Register reuse IDs freed by Unregister
+	freeIDs    []ComponentID // IDs freed by Unregister, reused lowest first when recycling
 }
 
 // NewComponentRegistry creates a new component registry
@@ -244,37 +762,136 @@ func NewComponentRegistry() *ComponentRegistry {
 		idToType: make(map[ComponentID]reflect.Type),
 		storages: make(map[ComponentID]IComponentStorage),
 		names:    make(map[ComponentID]string),
+		order:    make([]ComponentID, 0),
+
+		signatures: newSignatureTable(),
+		persistent: make(map[ComponentID]bool),
+		queryOnly:  make(map[ComponentID]bool),
 	}
 }
 
+// SetConcurrent makes Register, GetComponentID and GetStorage safe to call from
+// several goroutines at once, e.g. parallel systems lazily registering types
+// Left off, registration takes no locks
+func (cr *ComponentRegistry) SetConcurrent(enabled bool) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	cr.concurrent = enabled
+}
+
+// inheritSettings copies another registry's configuration but none of its types or
+// data, e.g. when World.Clear replaces the registry
+func (cr *ComponentRegistry) inheritSettings(from *ComponentRegistry) {
+	cr.concurrent = from.concurrent
+	cr.recycleIDs = from.recycleIDs
+	cr.onAccess = from.onAccess
+	cr.logger = from.logger
+}
+
 // Register registers a component type and returns its ID
 func Register[T any](cr *ComponentRegistry) ComponentID {
+	return register[T](cr, false)
+}
+
+// register registers a component type on behalf of query criteria when queried is
+// set, remembering it as query-only, otherwise explicitly, ending that status
+// Both happen under one lock so a concurrent explicit registration can't be lost
+func register[T any](cr *ComponentRegistry, queried bool) ComponentID {
 	var zero T
 	componentType := reflect.TypeOf(zero)
 
+	if cr.concurrent {
+		cr.mu.Lock()
+		defer cr.mu.Unlock()
+	}
+
 	// Check if already registered
 	if id, exists := cr.typeToID[componentType]; exists {
+		if !queried && len(cr.queryOnly) > 0 {
+			delete(cr.queryOnly, id)
+		}
 		return id
 	}
 
-	// Register new component type
+	id := cr.newID()
+	addStorage[T](cr, id, componentType)
+	if queried {
+		cr.queryOnly[id] = true
+	}
+	return id
+}
+
+// newID picks the ID for a new component type: a freed one when recycling, else the
+// next unused one, skipping IDs claimed with RegisterAs
+func (cr *ComponentRegistry) newID() ComponentID {
+	if cr.recycleIDs {
+		for len(cr.freeIDs) > 0 {
+			id := cr.freeIDs[0]
+			cr.freeIDs = cr.freeIDs[1:]
+			if cr.idToType[id] == nil {
+				return id
+			}
+		}
+	}
+
 	id := cr.nextID
-	cr.nextID++
+	for cr.idToType[id] != nil {
+		id++
+	}
+	cr.nextID = id + 1
+	return id
+}
+
+// registerQueried registers T on behalf of query criteria; a type nothing else has
+// registered is remembered as query-only until it is, see Query.BuildStrict
+func registerQueried[T any](cr *ComponentRegistry) ComponentID {
+	if id, exists := GetComponentID[T](cr); exists {
+		return id
+	}
 
+	return register[T](cr, true)
+}
+
+// addStorage creates and registers the storage for a new component type
+func addStorage[T any](cr *ComponentRegistry, id ComponentID, componentType reflect.Type) {
 	storage := NewTypedStorage[T]()
 
+	// Keep entity signatures in sync with pool membership
+	cr.signatures.ensureType(id)
+	storage.pool.onInsert = append(storage.pool.onInsert, func(entity Entity) {
+		cr.signatures.set(entity, id)
+		cr.version++
+		if cr.logger != nil {
+			cr.logger.ComponentAdded(entity, id)
+		}
+	})
+	storage.pool.onRemove = append(storage.pool.onRemove, func(entity Entity) {
+		cr.signatures.unset(entity, id)
+		cr.version++
+		if cr.logger != nil {
+			cr.logger.ComponentRemoved(entity, id)
+		}
+	})
+	cr.version++
+	if cr.onAccess != nil {
+		storage.setAccessHook(cr.accessHookFor(id))
+	}
+
 	cr.typeToID[componentType] = id
 	cr.idToType[id] = componentType
 	cr.storages[id] = storage
 	cr.names[id] = componentType.String()
-
-	return id
+	cr.order = append(cr.order, id)
 }
 
 // GetComponentID returns the component ID for a given type
 func GetComponentID[T any](cr *ComponentRegistry) (ComponentID, bool) {
 	var zero T
 	componentType := reflect.TypeOf(zero)
+	if cr.concurrent {
+		cr.mu.RLock()
+		defer cr.mu.RUnlock()
+	}
 	id, exists := cr.typeToID[componentType]
 	return id, exists
 }
@@ -286,7 +903,13 @@ func GetStorage[T any](cr *ComponentRegistry) (*ComponentPool[T], bool) {
 		return nil, false
 	}
 
+	if cr.concurrent {
+		cr.mu.RLock()
+	}
 	storage, exists := cr.storages[id]
+	if cr.concurrent {
+		cr.mu.RUnlock()
+	}
 	if !exists {
 		return nil, false
 	}
@@ -299,6 +922,91 @@ func GetStorage[T any](cr *ComponentRegistry) (*ComponentPool[T], bool) {
 	return typedStorage.Pool(), true
 }
 
+// setAccessHook installs an access profiling callback on every pool, nil removes it
+func (cr *ComponentRegistry) setAccessHook(fn func(ComponentID)) {
+	cr.onAccess = fn
+	for id, storage := range cr.storages {
+		if fn == nil {
+			storage.setAccessHook(nil)
+		} else {
+			storage.setAccessHook(cr.accessHookFor(id))
+		}
+	}
+}
+
+// accessHookFor binds the registry's access callback to one component ID
+func (cr *ComponentRegistry) accessHookFor(id ComponentID) func() {
+	return func() {
+		if cr.onAccess != nil {
+			cr.onAccess(id)
+		}
+	}
+}
+
+// Unregister drops component type T entirely: every entity holding it loses it,
+// as if removed (removal hooks and observers fire), and the type is forgotten
+// Observers, groups and other helpers created for T keep the dropped pool and must
+// be recreated if T is registered again. Returns false if T is not registered
+func Unregister[T any](cr *ComponentRegistry) bool {
+	id, exists := GetComponentID[T](cr)
+	return exists && cr.UnregisterByID(id)
+}
+
+// UnregisterByID is like Unregister but takes a component ID
+func (cr *ComponentRegistry) UnregisterByID(id ComponentID) bool {
+	if cr.concurrent {
+		cr.mu.RLock()
+	}
+	storage, exists := cr.storages[id]
+	if cr.concurrent {
+		cr.mu.RUnlock()
+	}
+	if !exists {
+		return false
+	}
+
+	// Emptying the pool runs removal hooks and observers, which may look component
+	// types up, so it happens before taking the write lock
+	storage.Clear()
+
+	if cr.concurrent {
+		cr.mu.Lock()
+		defer cr.mu.Unlock()
+	}
+	if cr.storages[id] != storage {
+		return false // Unregistered by someone else meanwhile
+	}
+	cr.forget(id)
+	cr.freeIDs = append(cr.freeIDs, id)
+	slices.Sort(cr.freeIDs)
+	return true
+}
+
+// SetRecycleIDs makes Register hand out IDs freed by Unregister, lowest first, before
+// new ones; off by default so an unregistered ID never comes back as another type
+func (cr *ComponentRegistry) SetRecycleIDs(enabled bool) {
+	cr.recycleIDs = enabled
+}
+
+// forget removes a component type from the registry, leaving its storage as is
+func (cr *ComponentRegistry) forget(id ComponentID) {
+	delete(cr.typeToID, cr.idToType[id])
+	delete(cr.idToType, id)
+	delete(cr.storages, id)
+	delete(cr.names, id)
+	delete(cr.persistent, id)
+	delete(cr.queryOnly, id)
+	cr.order = slices.DeleteFunc(cr.order, func(registered ComponentID) bool {
+		return registered == id
+	})
+	cr.version++
+}
+
+// IsPersistent checks if a component type survives World.Clear
+func (cr *ComponentRegistry) IsPersistent(id ComponentID) bool {
+	return cr.persistent[id]
+}
+
 // GetStorageByID returns the type-erased storage for a component ID
 func (cr *ComponentRegistry) GetStorageByID(id ComponentID) (IComponentStorage, bool) {
 	storage, exists := cr.storages[id]
@@ -320,6 +1028,23 @@ func (cr *ComponentRegistry) GetComponentName(id ComponentID) string {
 	return "Unknown"
 }
 
+// GetComponentIDByName looks up a component ID by its full type name (e.g. "main.Position")
+// or by its unqualified name ("Position") when that is unambiguous
+func (cr *ComponentRegistry) GetComponentIDByName(name string) (ComponentID, bool) {
+	var match ComponentID
+	matches := 0
+	for id, typeName := range cr.names {
+		if typeName == name {
+			return id, true
+		}
+		if typeName[strings.LastIndex(typeName, ".")+1:] == name {
+			match = id
+			matches++
+		}
+	}
+	return match, matches == 1
+}
+
 // GetRegisteredTypes returns all registered component types
 func (cr *ComponentRegistry) GetRegisteredTypes() map[ComponentID]string {
 	result := make(map[ComponentID]string)
@@ -329,6 +1054,14 @@ func (cr *ComponentRegistry) GetRegisteredTypes() map[ComponentID]string {
 	return result
 }
 
+// ForEachStorage calls fn for every registered component type in registration order
+// fn must not register or unregister component types
+func (cr *ComponentRegistry) ForEachStorage(fn func(id ComponentID, name string, storage IComponentStorage)) {
+	for _, id := range cr.order {
+		fn(id, cr.names[id], cr.storages[id])
+	}
+}
+
 // UnsafePointer returns an unsafe pointer to component data for entity
 // This is used for advanced operations and should be used with caution
 func UnsafeComponentPointer[T any](cr *ComponentRegistry, entity Entity) unsafe.Pointer {
@@ -0,0 +1,696 @@
+package ecs
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testPairs returns n entities with widely spread indices and a distinct value each
+func testPairs(n int) ([]Entity, []testPosition) {
+	entities := make([]Entity, n)
+	components := make([]testPosition, n)
+	for i := range entities {
+		entities[i] = makeEntity(uint32(i*7+1), uint32(i%3))
+		components[i] = testPosition{X: float64(i)}
+	}
+	return entities, components
+}
+
+func TestInsertManyTenThousandPairs(t *testing.T) {
+	entities, components := testPairs(10000)
+	pool := NewComponentPool[testPosition]()
+	pool.InsertMany(entities, components)
+
+	if pool.Size() != len(entities) {
+		t.Fatalf("Size = %d, want %d", pool.Size(), len(entities))
+	}
+	for i, entity := range entities {
+		if got, ok := pool.Get(entity); !ok || got != components[i] {
+			t.Fatalf("Get(%v) = %v, %v, want %v", entity, got, ok, components[i])
+		}
+		if pool.Entities().At(i) != entity {
+			t.Fatalf("dense order differs at %d", i)
+		}
+	}
+	if errs := pool.entities.validate(); len(errs) > 0 {
+		t.Fatalf("sparse set inconsistent: %v", errs)
+	}
+
+	// The result must match a pool built with Insert
+	expected := NewComponentPool[testPosition]()
+	for i, entity := range entities {
+		expected.Insert(entity, components[i])
+	}
+	for i := range entities {
+		if pool.entities.At(i) != expected.entities.At(i) || pool.components[i] != expected.components[i] {
+			t.Fatalf("InsertMany and Insert differ at dense index %d", i)
+		}
+	}
+}
+
+func TestInsertManyDuplicates(t *testing.T) {
+	a, b := makeEntity(1, 0), makeEntity(2, 0)
+	pool := NewComponentPool[testPosition]()
+	pool.Insert(a, testPosition{X: 1})
+
+	replaced := 0
+	pool.onReplace = append(pool.onReplace, func(Entity, testPosition, testPosition) { replaced++ })
+	pool.InsertMany(
+		[]Entity{b, a, b, NullEntity},
+		[]testPosition{{X: 2}, {X: 10}, {X: 20}, {X: 99}},
+	)
+
+	if pool.Size() != 2 {
+		t.Fatalf("Size = %d, want 2", pool.Size())
+	}
+	if got, _ := pool.Get(a); got.X != 10 {
+		t.Fatalf("existing entity = %v, want the new value", got)
+	}
+	if got, _ := pool.Get(b); got.X != 20 {
+		t.Fatalf("repeated entity = %v, want the last value", got)
+	}
+	if replaced != 2 {
+		t.Fatalf("replace hooks ran %d times, want 2", replaced)
+	}
+}
+
+func TestInsertManyAfterRemovals(t *testing.T) {
+	entities, components := testPairs(100)
+	pool := NewComponentPool[testPosition]()
+	pool.InsertMany(entities[:50], components[:50])
+	for _, entity := range entities[10:40] {
+		pool.Remove(entity)
+	}
+	pool.InsertMany(entities[50:], components[50:])
+
+	if pool.Size() != 70 {
+		t.Fatalf("Size = %d, want 70", pool.Size())
+	}
+	for i, entity := range entities {
+		removed := i >= 10 && i < 40
+		if got, ok := pool.Get(entity); ok == removed || (ok && got != components[i]) {
+			t.Fatalf("Get(%v) = %v, %v after removals", entity, got, ok)
+		}
+	}
+	if errs := pool.entities.validate(); len(errs) > 0 {
+		t.Fatalf("sparse set inconsistent: %v", errs)
+	}
+}
+
+func BenchmarkInsertMany(b *testing.B) {
+	entities, components := testPairs(10000)
+	b.Run("InsertMany", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			pool := NewComponentPool[testPosition]()
+			pool.InsertMany(entities, components)
+		}
+	})
+	b.Run("Insert", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			pool := NewComponentPool[testPosition]()
+			for j, entity := range entities {
+				pool.Insert(entity, components[j])
+			}
+		}
+	})
+}
+
+// byX orders positions by X only, so positions sharing X compare equal
+func byX(_ Entity, a *testPosition, _ Entity, b *testPosition) bool {
+	return a.X < b.X
+}
+
+// stablePool returns a pool whose positions repeat X values, with Y recording
+// insertion order so stability can be checked
+func stablePool(n int) *ComponentPool[testPosition] {
+	pool := NewComponentPool[testPosition]()
+	for i := 0; i < n; i++ {
+		pool.Insert(makeEntity(uint32(n-i), 0), testPosition{X: float64(i % 5), Y: float64(i)})
+	}
+	return pool
+}
+
+// checkSortedStable fails unless pool is ordered by X, ties in insertion order, and
+// every entity still maps to its component
+func checkSortedStable(t *testing.T, pool *ComponentPool[testPosition]) {
+	t.Helper()
+	for i := 1; i < pool.Len(); i++ {
+		prev, cur := pool.components[i-1], pool.components[i]
+		if prev.X > cur.X || (prev.X == cur.X && prev.Y > cur.Y) {
+			t.Fatalf("components %d and %d out of stable order: %v, %v", i-1, i, prev, cur)
+		}
+	}
+	for i, entity := range pool.Entities().Data() {
+		if pool.Entities().Index(entity) != i {
+			t.Fatalf("sparse index of %v = %d, want %d", entity, pool.Entities().Index(entity), i)
+		}
+		if got, _ := pool.Get(entity); got != pool.components[i] {
+			t.Fatalf("Get(%v) = %v, want %v", entity, got, pool.components[i])
+		}
+	}
+	if errs := pool.entities.validate(); len(errs) > 0 {
+		t.Fatalf("sparse set inconsistent: %v", errs)
+	}
+}
+
+func TestPoolSortStable(t *testing.T) {
+	pool := stablePool(200)
+	pool.SetLess(byX)
+	sort.Stable(pool)
+	checkSortedStable(t, pool)
+}
+
+func TestPoolSortKeepsEqualOrder(t *testing.T) {
+	pool := stablePool(200)
+	pool.Sort(byX)
+	checkSortedStable(t, pool)
+	if pool.less != nil {
+		t.Fatalf("Sort left its comparator installed")
+	}
+}
+
+func TestPoolLessWithoutComparatorPanics(t *testing.T) {
+	pool := stablePool(2)
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("Less without a comparator did not panic")
+		}
+	}()
+	pool.Less(0, 1)
+}
+
+// expectPanic fails the test unless fn panics with message
+func expectPanic(t *testing.T, message string, fn func()) {
+	t.Helper()
+	defer func() {
+		t.Helper()
+		if recovered := recover(); recovered != message {
+			t.Fatalf("panic = %v, want %q", recovered, message)
+		}
+	}()
+	fn()
+}
+
+func TestPoolForEachPanicsWhenModified(t *testing.T) {
+	entities, components := testPairs(10)
+	pool := NewComponentPool[testPosition]()
+	for i, entity := range entities {
+		pool.Insert(entity, components[i])
+	}
+
+	expectPanic(t, "ecs: pool modified during iteration", func() {
+		pool.ForEach(func(entity Entity, _ *testPosition) {
+			pool.Remove(entity)
+		})
+	})
+	expectPanic(t, "ecs: pool modified during iteration", func() {
+		pool.ForEach(func(Entity, *testPosition) {
+			pool.Insert(makeEntity(5000, 0), testPosition{})
+		})
+	})
+}
+
+func TestPoolForEachUnmodified(t *testing.T) {
+	entities, components := testPairs(10)
+	pool := NewComponentPool[testPosition]()
+	for i, entity := range entities {
+		pool.Insert(entity, components[i])
+	}
+
+	// Writing through the pointer is not a structural change
+	visited := 0
+	pool.ForEach(func(entity Entity, pos *testPosition) {
+		pos.Y = 1
+		visited++
+	})
+	if visited != 10 {
+		t.Fatalf("ForEach visited %d entities, want 10", visited)
+	}
+	for _, entity := range entities {
+		if pos, _ := pool.Get(entity); pos.Y != 1 {
+			t.Fatalf("write through ForEach pointer lost for %v", entity)
+		}
+	}
+}
+
+func TestPoolForEachSafeAllowsRemoval(t *testing.T) {
+	entities, components := testPairs(10)
+	pool := NewComponentPool[testPosition]()
+	for i, entity := range entities {
+		pool.Insert(entity, components[i])
+	}
+
+	visited := 0
+	pool.ForEachSafe(func(entity Entity, _ *testPosition) {
+		visited++
+		pool.Remove(entity)
+	})
+	if visited != 10 || pool.Size() != 0 {
+		t.Fatalf("ForEachSafe visited %d and left %d, want 10 and 0", visited, pool.Size())
+	}
+}
+
+func TestPoolBatchesCoverAllEntities(t *testing.T) {
+	entities, components := testPairs(23)
+	pool := NewComponentPool[testPosition]()
+	for i, entity := range entities {
+		pool.Insert(entity, components[i])
+	}
+
+	var sizes []int
+	seen := 0
+	pool.Batches(10, func(batch []Entity, data []testPosition) {
+		sizes = append(sizes, len(batch))
+		if len(batch) != len(data) {
+			t.Fatalf("batch has %d entities and %d components", len(batch), len(data))
+		}
+		for i, entity := range batch {
+			if got, _ := pool.Get(entity); got != data[i] {
+				t.Fatalf("data misaligned at %v: %v, want %v", entity, data[i], got)
+			}
+		}
+		seen += len(batch)
+	})
+	if !slices.Equal(sizes, []int{10, 10, 3}) || seen != len(entities) {
+		t.Fatalf("batch sizes = %v, want [10 10 3]", sizes)
+	}
+
+	// A non-positive size yields everything at once
+	sizes = nil
+	pool.Batches(0, func(batch []Entity, _ []testPosition) { sizes = append(sizes, len(batch)) })
+	if !slices.Equal(sizes, []int{23}) {
+		t.Fatalf("batch sizes with size 0 = %v, want [23]", sizes)
+	}
+
+	expectPanic(t, "ecs: pool modified during iteration", func() {
+		pool.Batches(10, func(batch []Entity, _ []testPosition) { pool.Remove(batch[0]) })
+	})
+}
+
+func TestPoolRemoveAndGet(t *testing.T) {
+	entities, components := testPairs(5)
+	pool := NewComponentPool[testPosition]()
+	for i, entity := range entities {
+		pool.Insert(entity, components[i])
+	}
+
+	// Removing from the middle moves the last component into the gap
+	got, ok := pool.RemoveAndGet(entities[1])
+	if !ok || got != components[1] {
+		t.Fatalf("RemoveAndGet = %v, %v, want %v", got, ok, components[1])
+	}
+	if pool.Size() != 4 || pool.Contains(entities[1]) {
+		t.Fatalf("pool not shrunk: size %d, contains %v", pool.Size(), pool.Contains(entities[1]))
+	}
+	if moved, _ := pool.Get(entities[4]); moved != components[4] {
+		t.Fatalf("moved component = %v, want %v", moved, components[4])
+	}
+
+	if _, ok := pool.RemoveAndGet(entities[1]); ok {
+		t.Fatalf("RemoveAndGet of a missing component succeeded")
+	}
+}
+
+// testKind yields a distinct component type per type argument
+type testKind[N any] struct{ Value N }
+
+// testKindRegistrations registers 16 distinct component types, one per function
+var testKindRegistrations = []func(cr *ComponentRegistry) ComponentID{
+	func(cr *ComponentRegistry) ComponentID { return Register[testKind[[1]byte]](cr) },
+	func(cr *ComponentRegistry) ComponentID { return Register[testKind[[2]byte]](cr) },
+	func(cr *ComponentRegistry) ComponentID { return Register[testKind[[3]byte]](cr) },
+	func(cr *ComponentRegistry) ComponentID { return Register[testKind[[4]byte]](cr) },
+	func(cr *ComponentRegistry) ComponentID { return Register[testKind[[5]byte]](cr) },
+	func(cr *ComponentRegistry) ComponentID { return Register[testKind[[6]byte]](cr) },
+	func(cr *ComponentRegistry) ComponentID { return Register[testKind[[7]byte]](cr) },
+	func(cr *ComponentRegistry) ComponentID { return Register[testKind[[8]byte]](cr) },
+	func(cr *ComponentRegistry) ComponentID { return Register[testKind[[9]byte]](cr) },
+	func(cr *ComponentRegistry) ComponentID { return Register[testKind[[10]byte]](cr) },
+	func(cr *ComponentRegistry) ComponentID { return Register[testKind[[11]byte]](cr) },
+	func(cr *ComponentRegistry) ComponentID { return Register[testKind[[12]byte]](cr) },
+	func(cr *ComponentRegistry) ComponentID { return Register[testKind[[13]byte]](cr) },
+	func(cr *ComponentRegistry) ComponentID { return Register[testKind[[14]byte]](cr) },
+	func(cr *ComponentRegistry) ComponentID { return Register[testKind[[15]byte]](cr) },
+	func(cr *ComponentRegistry) ComponentID { return Register[testKind[[16]byte]](cr) },
+}
+
+func TestConcurrentRegistration(t *testing.T) {
+	w := NewWorld()
+	w.SetConcurrentRegistration(true)
+	registry := w.componentRegistry
+
+	// Every goroutine registers every type, in a different order, racing on each
+	const goroutines = 8
+	ids := make([][]ComponentID, goroutines)
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ids[g] = make([]ComponentID, len(testKindRegistrations))
+			for i := range testKindRegistrations {
+				index := (i + g*3) % len(testKindRegistrations)
+				ids[g][index] = testKindRegistrations[index](registry)
+				GetStorage[testPosition](registry)
+			}
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[ComponentID]bool)
+	for i, id := range ids[0] {
+		for g := 1; g < goroutines; g++ {
+			if ids[g][i] != id {
+				t.Fatalf("type %d got ID %d in one goroutine and %d in another", i, id, ids[g][i])
+			}
+		}
+		if seen[id] {
+			t.Fatalf("ID %d assigned to two types", id)
+		}
+		seen[id] = true
+		if _, exists := registry.GetStorageByID(id); !exists {
+			t.Fatalf("no storage for ID %d", id)
+		}
+	}
+	if len(registry.order) != len(testKindRegistrations) {
+		t.Fatalf("%d types registered, want %d", len(registry.order), len(testKindRegistrations))
+	}
+}
+
+type testBlob struct{ Data []byte }
+
+func TestPoolRemoveReleasesTailReference(t *testing.T) {
+	pool := NewComponentPool[testBlob]()
+	a, b := makeEntity(1, 0), makeEntity(2, 0)
+	pool.Insert(a, testBlob{Data: make([]byte, 1<<20)})
+	pool.Insert(b, testBlob{Data: make([]byte, 1<<20)})
+
+	// b moves into a's slot; the vacated tail must not keep b's data alive
+	pool.Remove(a)
+	if tail := pool.components[:cap(pool.components)][1]; tail.Data != nil {
+		t.Fatalf("removed tail slot still references %d bytes", len(tail.Data))
+	}
+	pool.Remove(b)
+	if slot := pool.components[:cap(pool.components)][0]; slot.Data != nil {
+		t.Fatalf("last removed slot still references %d bytes", len(slot.Data))
+	}
+}
+
+func TestPoolRemoveLetsGCReclaimData(t *testing.T) {
+	pool := NewComponentPool[*testBlob]()
+	collected := make(chan struct{})
+	blob := &testBlob{Data: make([]byte, 1<<20)}
+	runtime.SetFinalizer(blob, func(*testBlob) { close(collected) })
+	pool.Insert(makeEntity(1, 0), blob)
+	pool.Insert(makeEntity(2, 0), &testBlob{})
+	blob = nil
+
+	pool.Remove(makeEntity(1, 0))
+	for i := 0; i < 10; i++ {
+		runtime.GC()
+		select {
+		case <-collected:
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	t.Fatalf("removed component was not garbage collected")
+}
+
+func TestPoolReserveAndGrowthFactor(t *testing.T) {
+	entities, components := testPairs(100)
+	pool := NewComponentPool[testPosition]()
+	pool.Reserve(100)
+	if cap(pool.components) < 100 || cap(pool.entities.dense) < 100 {
+		t.Fatalf("Reserve(100) gave capacity %d, %d", cap(pool.components), cap(pool.entities.dense))
+	}
+	pool.Reserve(10) // Never shrinks
+	if cap(pool.components) < 100 {
+		t.Fatalf("Reserve below the current capacity shrank the pool")
+	}
+	first := &pool.components[:1][0]
+	for i := range entities {
+		pool.Insert(entities[i], components[i])
+	}
+	if &pool.components[0] != first {
+		t.Fatalf("inserting within the reserved capacity reallocated")
+	}
+
+	// Each reallocation multiplies capacity by the growth factor
+	grown := NewComponentPool[testPosition]()
+	grown.SetGrowthFactor(1.5)
+	grown.Reserve(10)
+	var caps []int
+	for i := range entities {
+		grown.Insert(entities[i], components[i])
+		if c := cap(grown.components); len(caps) == 0 || caps[len(caps)-1] != c {
+			caps = append(caps, c)
+		}
+	}
+	for i := 1; i < len(caps); i++ {
+		if caps[i] > caps[i-1]*3/2+1 {
+			t.Fatalf("capacity grew from %d to %d with factor 1.5", caps[i-1], caps[i])
+		}
+	}
+	expectPanic(t, "ecs: growth factor must be greater than 1", func() { grown.SetGrowthFactor(1) })
+}
+
+func BenchmarkPoolInsertBurst(b *testing.B) {
+	entities, components := testPairs(100000)
+	for _, reserve := range []bool{false, true} {
+		b.Run(fmt.Sprintf("reserve=%v", reserve), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				pool := NewComponentPool[testPosition]()
+				if reserve {
+					pool.Reserve(len(entities))
+				}
+				for j := range entities {
+					pool.Insert(entities[j], components[j])
+				}
+			}
+		})
+	}
+}
+
+func TestUnregisterDropsComponentType(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 6)
+	velocityID, _ := GetComponentID[testVelocity](w.componentRegistry)
+	observer := Observe[testVelocity](w)
+
+	if !Unregister[testVelocity](w.componentRegistry) || Unregister[testVelocity](w.componentRegistry) {
+		t.Fatalf("Unregister should succeed once for a registered type")
+	}
+	if _, exists := GetComponentID[testVelocity](w.componentRegistry); exists {
+		t.Fatalf("type still registered after Unregister")
+	}
+	if _, err := w.QueryString("testVelocity"); err == nil {
+		t.Fatalf("query string resolved an unregistered type")
+	}
+	if got := sortedByIndex(observer.Removed()); !slices.Equal(got, []Entity{entities[0], entities[2], entities[4]}) {
+		t.Fatalf("observer saw %v removed, want the moving entities", got)
+	}
+	if HasComponent[testVelocity](w, entities[0]) {
+		t.Fatalf("entity still holds an unregistered component")
+	}
+	if got := With[testVelocity](NewQuery(w)).Count(); got != 0 {
+		t.Fatalf("query found %d entities with an unregistered type", got)
+	}
+	if got := With[testPosition](NewQuery(w)).Count(); got != 6 {
+		t.Fatalf("other components affected: %d positions", got)
+	}
+	if errs := w.Validate(); errs != nil {
+		t.Fatalf("Validate after Unregister: %v", errs)
+	}
+
+	// Freed IDs are only handed out again when recycling is on
+	AddComponent(w, entities[1], testVelocity{X: 2})
+	if id, _ := GetComponentID[testVelocity](w.componentRegistry); id == velocityID {
+		t.Fatalf("ID %d reused without SetRecycleIDs", id)
+	}
+	if v, _ := GetComponent[testVelocity](w, entities[1]); v.X != 2 || HasComponent[testVelocity](w, entities[0]) {
+		t.Fatalf("re-registered type holds stale data")
+	}
+
+	w.componentRegistry.SetRecycleIDs(true)
+	healthID := Register[testHealth](w.componentRegistry)
+	if healthID != velocityID {
+		t.Fatalf("recycling registered testHealth as %d, want freed ID %d", healthID, velocityID)
+	}
+}
+
+func TestUnregisterHooksMayLookUpTypes(t *testing.T) {
+	w := NewWorld()
+	w.SetConcurrentRegistration(true)
+	registry := w.componentRegistry
+	entities := populateTestWorld(w, 20)
+	observer := Observe[testVelocity](w)
+
+	// Removal hooks run while Unregister empties the pool and look types up, which
+	// deadlocks if Unregister holds the registry's write lock around them
+	velocities, _ := GetStorage[testVelocity](registry)
+	looked := 0
+	velocities.onRemove = append(velocities.onRemove, func(Entity) {
+		if _, exists := GetComponentID[testPosition](registry); exists {
+			looked++
+		}
+		GetStorage[testVelocity](registry)
+	})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range testKindRegistrations {
+			testKindRegistrations[i](registry)
+			GetStorage[testPosition](registry)
+		}
+	}()
+	done := make(chan bool)
+	go func() { done <- Unregister[testVelocity](registry) }()
+	select {
+	case ok := <-done:
+		if !ok {
+			t.Fatalf("Unregister failed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Unregister deadlocked running removal hooks")
+	}
+	wg.Wait()
+
+	if looked != 10 || len(observer.Removed()) != 10 {
+		t.Fatalf("hooks ran for %d entities, observer saw %d removed, want 10", looked, len(observer.Removed()))
+	}
+	if HasComponent[testVelocity](w, entities[0]) {
+		t.Fatalf("entity kept an unregistered component")
+	}
+}
+
+func TestPoolStableOrderOnRemove(t *testing.T) {
+	entities, components := testPairs(10)
+	removed := []int{0, 4, 5, 9}
+
+	stable, swapped := NewComponentPool[testPosition](), NewComponentPool[testPosition]()
+	stable.SetStableOrder(true)
+	for _, pool := range []*ComponentPool[testPosition]{stable, swapped} {
+		pool.InsertMany(entities, components)
+		for _, i := range removed {
+			pool.Remove(entities[i])
+		}
+	}
+
+	var want []Entity
+	for i, entity := range entities {
+		if !slices.Contains(removed, i) {
+			want = append(want, entity)
+		}
+	}
+	if got := stable.Entities().Data(); !slices.Equal(got, want) {
+		t.Fatalf("stable order = %v, want insertion order %v", got, want)
+	}
+	if got := swapped.Entities().Data(); slices.Equal(got, want) {
+		t.Fatalf("swap-and-pop kept insertion order, the test no longer compares anything")
+	}
+	for i, entity := range stable.Entities().Data() {
+		if stable.Data()[i] != components[slices.Index(entities, entity)] {
+			t.Fatalf("stable pool component %d doesn't belong to %s", i, entity)
+		}
+	}
+	if errs := stable.entities.validate(); errs != nil {
+		t.Fatalf("stable pool inconsistent: %v", errs)
+	}
+}
+
+// respectNested is the original O(n·m) Respect ordering, scanning other linearly
+// for every entity left over
+func respectNested(entities []Entity, other []Entity) []Entity {
+	var ordered []Entity
+	for _, entity := range other {
+		if slices.Contains(entities, entity) {
+			ordered = append(ordered, entity)
+		}
+	}
+	for _, entity := range entities {
+		if !slices.Contains(other, entity) {
+			ordered = append(ordered, entity)
+		}
+	}
+	return ordered
+}
+
+// newRespectPools builds a pool of n entities and a set holding every third of
+// them in reverse, plus entities the pool lacks
+func newRespectPools(n int) (*ComponentPool[testPosition], *SparseSet) {
+	entities, components := testPairs(n)
+	pool := NewComponentPool[testPosition]()
+	pool.InsertMany(entities, components)
+	other := NewSparseSet()
+	for i := n - 1; i >= 0; i -= 3 {
+		other.Insert(entities[i])
+		other.Insert(makeEntity(uint32(i*7+2), 0))
+	}
+	return pool, other
+}
+
+func TestRespectMatchesNestedLoop(t *testing.T) {
+	pool, other := newRespectPools(1000)
+	want := respectNested(slices.Clone(pool.Entities().Data()), other.Data())
+	positions := make(map[Entity]testPosition, pool.Size())
+	for i, entity := range pool.Entities().Data() {
+		positions[entity] = pool.Data()[i]
+	}
+
+	pool.Respect(other)
+	if got := pool.Entities().Data(); !slices.Equal(got, want) {
+		t.Fatalf("Respect order differs from the nested loop")
+	}
+	for i, entity := range pool.Entities().Data() {
+		if pool.Data()[i] != positions[entity] {
+			t.Fatalf("%s lost its component: %v, want %v", entity, pool.Data()[i], positions[entity])
+		}
+	}
+	if errs := pool.entities.validate(); errs != nil {
+		t.Fatalf("pool inconsistent after Respect: %v", errs)
+	}
+}
+
+func BenchmarkRespect(b *testing.B) {
+	pool, other := newRespectPools(10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pool.Respect(other)
+	}
+}
+
+func TestShrinkToFitKeepsMembership(t *testing.T) {
+	entities, components := testPairs(1000)
+	pool := NewComponentPool[testPosition]()
+	pool.Reserve(5000)
+	pool.InsertMany(entities, components)
+	for _, entity := range entities[:400] {
+		pool.Remove(entity)
+	}
+
+	pool.ShrinkToFit()
+	if cap(pool.components) != 600 || cap(pool.entities.dense) != 600 || pool.Size() != 600 {
+		t.Fatalf("after ShrinkToFit: %d components, %d dense capacity, size %d",
+			cap(pool.components), cap(pool.entities.dense), pool.Size())
+	}
+	for i, entity := range entities {
+		if pool.Contains(entity) != (i >= 400) {
+			t.Fatalf("%s membership changed", entity)
+		}
+		if i >= 400 && pool.GetPtr(entity).X != float64(i) {
+			t.Fatalf("%s component = %v", entity, pool.GetPtr(entity))
+		}
+	}
+	if errs := pool.entities.validate(); errs != nil {
+		t.Fatalf("pool inconsistent after ShrinkToFit: %v", errs)
+	}
+}
@@ -1,6 +1,10 @@
 package ecs
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
 
 // Entity represents a unique identifier for an entity in the ECS
 // Uses generational index pattern: high bits for generation, low bits for index
@@ -19,6 +23,9 @@ const (
 	NullEntity Entity = 0xFFFFFFFF
 )
 
+// ErrInvalidEntity is returned when an operation targets a null, destroyed or stale entity
+var ErrInvalidEntity = errors.New("ecs: invalid entity")
+
 // Index returns the index part of the entity
 func (e Entity) Index() uint32 {
 	return uint32(e) & EntityIndexMask
@@ -51,52 +58,94 @@ func makeEntity(index, generation uint32) Entity {
 type EntityManager struct {
 	// entities stores generation for each entity index
 	entities []uint32
-	// freeHead points to the first free entity index, or -1 if none
-	freeHead int32
+	// free holds destroyed indices available for reuse, most recently freed last
+	free []uint32
+	// alive marks indices holding a live entity
+	alive []bool
+	// live is the number of entities currently alive
+	live int
+	// retired counts indices whose generation is exhausted and are never reused
+	retired int
+	// version is incremented whenever an entity is created or destroyed
+	version uint64
 }
 
 // NewEntityManager creates a new entity manager
 func NewEntityManager() *EntityManager {
 	return &EntityManager{
 		entities: make([]uint32, 0),
-		freeHead: -1,
+		free:     make([]uint32, 0),
+		alive:    make([]bool, 0),
 	}
 }
 
 // Create creates a new entity with proper ID recycling
 func (em *EntityManager) Create() Entity {
 	var index uint32
-	var generation uint32
-
-	if em.freeHead >= 0 {
-		// Reuse a freed entity index
-		index = uint32(em.freeHead)
-
-		// The stored value is either the next free index or generation
-		stored := em.entities[index]
-		if stored == uint32(em.freeHead) {
-			// This was the last free entity, no more in the chain
-			em.freeHead = -1
-			generation = 0 // Reset generation for reused entity
-		} else {
-			// Point to next free entity in the chain
-			em.freeHead = int32(stored)
-			generation = 0 // Reset generation for reused entity
-		}
 
-		// Store the new generation
-		em.entities[index] = generation
+	if len(em.free) > 0 {
+		// Reuse a freed entity index, its generation was bumped on destroy
+		index = em.free[len(em.free)-1]
+		em.free = em.free[:len(em.free)-1]
+		em.alive[index] = true
 	} else {
 		// Create a new entity index
 		index = uint32(len(em.entities))
-		generation = 0
-		em.entities = append(em.entities, generation)
+		em.entities = append(em.entities, 0)
+		em.alive = append(em.alive, true)
 	}
 
-	return makeEntity(index, generation)
+	em.live++
+	em.version++
+	return makeEntity(index, em.entities[index])
+}
+
+// CreateWithID creates the entity with the given index and generation, e.g. to
+// recreate saved handles so references between them still resolve
+// Slots below index that don't exist yet are created free; errors if the slot is live,
+// retired, or expects a later generation, as the handle would then be one that was
+// already destroyed and stale references to it would come back to life
+func (em *EntityManager) CreateWithID(index, generation uint32) (Entity, error) {
+	if index > EntityIndexMask || generation > EntityGenerationMask {
+		return NullEntity, fmt.Errorf("ecs: entity index %d or generation %d out of range", index, generation)
+	}
+	entity := makeEntity(index, generation)
+	if entity == NullEntity {
+		return NullEntity, fmt.Errorf("ecs: entity index %d generation %d is the null entity", index, generation)
+	}
+
+	if index < uint32(len(em.entities)) {
+		if em.alive[index] {
+			return NullEntity, fmt.Errorf("ecs: entity slot %d is already live as %s", index, makeEntity(index, em.entities[index]))
+		}
+		free := slices.Index(em.free, index)
+		if free < 0 {
+			return NullEntity, fmt.Errorf("ecs: entity slot %d is retired", index)
+		}
+		if generation < em.entities[index] {
+			return NullEntity, fmt.Errorf("ecs: %s was already destroyed, slot %d is at generation %d", entity, index, em.entities[index])
+		}
+		em.free = slices.Delete(em.free, free, free+1)
+	} else {
+		for next := uint32(len(em.entities)); next < index; next++ {
+			em.entities = append(em.entities, 0)
+			em.alive = append(em.alive, false)
+			em.free = append(em.free, next)
+		}
+		em.entities = append(em.entities, 0)
+		em.alive = append(em.alive, false)
+	}
+
+	em.entities[index] = generation
+	em.alive[index] = true
+	em.live++
+	em.version++
+	return entity, nil
 }
 
 // Destroy marks an entity for reuse and increments its generation
+// An index whose generation would wrap is retired instead of reused, so a stale
+// handle can never match a newer entity; this costs one index per 4096 reuses
 func (em *EntityManager) Destroy(entity Entity) bool {
 	if !entity.IsValid() {
 		return false
@@ -111,22 +160,30 @@ func (em *EntityManager) Destroy(entity Entity) bool {
 	expectedGen := entity.Generation()
 
 	// Check if this is the current generation of the entity
-	if currentGen != expectedGen {
+	if !em.alive[index] || currentGen != expectedGen {
 		return false // Entity is stale
 	}
+	em.alive[index] = false
+	em.live--
+	em.version++
 
-	// Add to free list - store the previous free head
-	if em.freeHead >= 0 {
-		em.entities[index] = uint32(em.freeHead)
-	} else {
-		em.entities[index] = index // Point to itself if no free list
+	// The last generation of the last index would encode NullEntity
+	next := currentGen + 1
+	if next > EntityGenerationMask || makeEntity(index, next) == NullEntity {
+		em.retired++
+		return true
 	}
 
-	em.freeHead = int32(index)
-
+	em.entities[index] = next
+	em.free = append(em.free, index)
 	return true
 }
 
+// Retired returns the number of indices taken out of use after exhausting their generations
+func (em *EntityManager) Retired() int {
+	return em.retired
+}
+
 // IsValid checks if an entity is valid and current
 func (em *EntityManager) IsValid(entity Entity) bool {
 	if !entity.IsValid() {
@@ -138,16 +195,74 @@ func (em *EntityManager) IsValid(entity Entity) bool {
 		return false
 	}
 
-	return em.entities[index] == entity.Generation()
+	return em.alive[index] && em.entities[index] == entity.Generation()
 }
 
-// Size returns the number of entities that have been created
+// ForEachAlive calls fn for every entity that has been created and not destroyed,
+// in index order; fn may destroy entities
+func (em *EntityManager) ForEachAlive(fn func(Entity)) {
+	for index := range em.entities {
+		if !em.alive[index] {
+			continue
+		}
+		if entity := makeEntity(uint32(index), em.entities[index]); entity != NullEntity {
+			fn(entity)
+		}
+	}
+}
+
+// Size returns the number of entity slots ever created, including destroyed ones
 func (em *EntityManager) Size() int {
 	return len(em.entities)
 }
 
+// LiveCount returns the number of entities that are currently alive
+func (em *EntityManager) LiveCount() int {
+	return em.live
+}
+
+// restore replaces the manager's state with saved generations, liveness and free
+// list, so saved entity handles stay valid and destroyed ones stay stale
+func (em *EntityManager) restore(generations []uint32, alive []bool, free []uint32, retired int) error {
+	if err := checkRestore(generations, alive, free); err != nil {
+		return err
+	}
+
+	live := 0
+	for _, isAlive := range alive {
+		if isAlive {
+			live++
+		}
+	}
+
+	em.entities = append(em.entities[:0], generations...)
+	em.alive = append(em.alive[:0], alive...)
+	em.free = append(em.free[:0], free...)
+	em.live = live
+	em.retired = retired
+	em.version++
+	return nil
+}
+
+// checkRestore validates saved entity manager state before it is restored
+func checkRestore(generations []uint32, alive []bool, free []uint32) error {
+	if len(generations) != len(alive) {
+		return fmt.Errorf("ecs: %d entity generations but %d liveness flags", len(generations), len(alive))
+	}
+	for _, index := range free {
+		if index >= uint32(len(generations)) || alive[index] {
+			return fmt.Errorf("ecs: free entity index %d is out of range or alive", index)
+		}
+	}
+	return nil
+}
+
 // Clear removes all entities
 func (em *EntityManager) Clear() {
 	em.entities = em.entities[:0]
-	em.freeHead = -1
+	em.alive = em.alive[:0]
+	em.free = em.free[:0]
+	em.live = 0
+	em.retired = 0
+	em.version++
 }
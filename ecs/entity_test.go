@@ -0,0 +1,218 @@
+package ecs
+
+import (
+	"slices"
+	"testing"
+)
+
+// aliveEntities collects the entities ForEachAlive visits
+func aliveEntities(em *EntityManager) []Entity {
+	var visited []Entity
+	em.ForEachAlive(func(e Entity) { visited = append(visited, e) })
+	return visited
+}
+
+func TestForEachAliveVisitsLiveEntities(t *testing.T) {
+	em := NewEntityManager()
+	entities := make([]Entity, 10)
+	for i := range entities {
+		entities[i] = em.Create()
+	}
+	var want []Entity
+	for i, e := range entities {
+		if i%3 == 0 {
+			em.Destroy(e)
+		} else {
+			want = append(want, e)
+		}
+	}
+	if got := aliveEntities(em); !slices.Equal(got, want) {
+		t.Fatalf("ForEachAlive visited %v, want %v", got, want)
+	}
+
+	// Recycled slots are visited with their new generation, never the stale handle
+	reused := em.Create()
+	if reused.Index() != entities[9].Index() || reused == entities[9] {
+		t.Fatalf("Create reused %s, want index %d with a new generation", reused, entities[9].Index())
+	}
+	want = append(want, reused)
+	slices.SortFunc(want, func(a, b Entity) int { return int(a.Index()) - int(b.Index()) })
+	if got := aliveEntities(em); !slices.Equal(got, want) {
+		t.Fatalf("ForEachAlive after reuse visited %v, want %v", got, want)
+	}
+	if len(want) != em.LiveCount() {
+		t.Fatalf("LiveCount = %d, want %d", em.LiveCount(), len(want))
+	}
+}
+
+func TestForEachAliveAllowsDestroy(t *testing.T) {
+	em := NewEntityManager()
+	for range 5 {
+		em.Create()
+	}
+	visited := 0
+	em.ForEachAlive(func(e Entity) {
+		visited++
+		em.Destroy(e)
+	})
+	if visited != 5 || em.LiveCount() != 0 {
+		t.Fatalf("destroying while iterating visited %d, left %d alive", visited, em.LiveCount())
+	}
+	if got := aliveEntities(em); len(got) != 0 {
+		t.Fatalf("ForEachAlive after destroying all visited %v", got)
+	}
+}
+
+func TestWorldForEachEntity(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 6)
+	w.DestroyEntity(entities[1])
+	w.DestroyEntity(entities[4])
+
+	var visited []Entity
+	w.ForEachEntity(func(e Entity) { visited = append(visited, e) })
+	want := []Entity{entities[0], entities[2], entities[3], entities[5]}
+	if !slices.Equal(visited, want) {
+		t.Fatalf("ForEachEntity visited %v, want %v", visited, want)
+	}
+}
+
+func TestGenerationWrapRetiresIndex(t *testing.T) {
+	em := NewEntityManager()
+	first := em.Create()
+	seen := map[Entity]bool{first: true}
+	stale := []Entity{first}
+
+	entity := first
+	for range EntityGenerationMask + 10 {
+		em.Destroy(entity)
+		entity = em.Create()
+		if seen[entity] {
+			t.Fatalf("Create returned %s again after %d reuses", entity, len(seen))
+		}
+		seen[entity] = true
+		stale = append(stale, entity)
+	}
+
+	// Every handle but the current one is stale, including the original
+	for _, old := range stale[:len(stale)-1] {
+		if em.IsValid(old) {
+			t.Fatalf("stale handle %s is valid", old)
+		}
+	}
+	if !em.IsValid(entity) {
+		t.Fatalf("current handle %s is not valid", entity)
+	}
+	if em.Retired() != 1 {
+		t.Fatalf("Retired = %d, want 1", em.Retired())
+	}
+	if entity.Index() == first.Index() {
+		t.Fatalf("exhausted index %d was reused", first.Index())
+	}
+	if errs := em.validate(); errs != nil {
+		t.Fatalf("validate: %v", errs)
+	}
+}
+
+// testTarget references another entity, like a saved parent or target field
+type testTarget struct{ Entity Entity }
+
+func TestCreateWithIDRestoresCrossReferences(t *testing.T) {
+	src := NewWorld()
+	entities := populateTestWorld(src, 6)
+	src.DestroyEntity(entities[1])
+	src.DestroyEntity(entities[4])
+	recycled := src.CreateEntity() // Slot 4 with generation 1
+	AddComponent(src, recycled, testTarget{Entity: entities[5]})
+	AddComponent(src, entities[5], testTarget{Entity: recycled})
+	AddComponent(src, entities[0], testTarget{Entity: entities[3]})
+
+	// Recreate the saved handles out of order, as a deserializer might
+	dst := NewWorld()
+	saved := []Entity{entities[5], recycled, entities[0], entities[3], entities[2]}
+	for _, e := range saved {
+		created, err := dst.CreateEntityWithID(e.Index(), e.Generation())
+		if err != nil || created != e {
+			t.Fatalf("CreateEntityWithID(%d, %d) = %s, %v", e.Index(), e.Generation(), created, err)
+		}
+		if target, ok := GetComponent[testTarget](src, e); ok {
+			AddComponent(dst, e, target)
+		}
+	}
+
+	for _, e := range saved {
+		target, ok := GetComponent[testTarget](dst, e)
+		if ok && !dst.IsValidEntity(target.Entity) {
+			t.Fatalf("%s refers to %s, which doesn't resolve", e, target.Entity)
+		}
+	}
+	if back, _ := GetComponent[testTarget](dst, entities[5]); back.Entity != recycled {
+		t.Fatalf("cross-reference from %s = %s, want %s", entities[5], back.Entity, recycled)
+	}
+	if dst.IsValidEntity(entities[4]) || dst.IsValidEntity(entities[1]) {
+		t.Fatalf("handles that were never restored are valid")
+	}
+
+	// The gap at index 1 is free for the next CreateEntity
+	if next := dst.CreateEntity(); next.Index() != 1 {
+		t.Fatalf("CreateEntity after restoring used index %d, want the free slot 1", next.Index())
+	}
+	if errs := dst.Validate(); errs != nil {
+		t.Fatalf("Validate: %v", errs)
+	}
+}
+
+func TestCreateWithIDErrors(t *testing.T) {
+	em := NewEntityManager()
+	live := em.Create()
+	if _, err := em.CreateWithID(live.Index(), 3); err == nil {
+		t.Fatalf("CreateWithID over a live slot succeeded")
+	}
+	if _, err := em.CreateWithID(EntityIndexMask+1, 0); err == nil {
+		t.Fatalf("CreateWithID with an out of range index succeeded")
+	}
+	if _, err := em.CreateWithID(0, EntityGenerationMask+1); err == nil {
+		t.Fatalf("CreateWithID with an out of range generation succeeded")
+	}
+	if _, err := em.CreateWithID(NullEntity.Index(), NullEntity.Generation()); err == nil {
+		t.Fatalf("CreateWithID of the null entity succeeded")
+	}
+	if em.LiveCount() != 1 || em.validate() != nil {
+		t.Fatalf("failed CreateWithID calls changed the manager: %v", em.validate())
+	}
+}
+
+func TestCreateWithIDKeepsStaleHandlesInvalid(t *testing.T) {
+	em := NewEntityManager()
+	first := em.Create()
+	em.Destroy(first)
+
+	// The slot now expects generation 1, so the destroyed handle can't come back
+	if _, err := em.CreateWithID(first.Index(), first.Generation()); err == nil {
+		t.Fatalf("CreateWithID recreated the destroyed handle %s", first)
+	}
+	if em.IsValid(first) {
+		t.Fatalf("stale handle %s became valid", first)
+	}
+	later, err := em.CreateWithID(first.Index(), 5)
+	if err != nil || em.IsValid(first) || !em.IsValid(later) {
+		t.Fatalf("CreateWithID with a later generation = %s, %v", later, err)
+	}
+
+	// A retired slot stays retired
+	em.Destroy(later)
+	last, err := em.CreateWithID(first.Index(), EntityGenerationMask)
+	if err != nil {
+		t.Fatal(err)
+	}
+	em.Destroy(last)
+	if em.Retired() != 1 {
+		t.Fatalf("slot wasn't retired, %d retired", em.Retired())
+	}
+	if _, err := em.CreateWithID(last.Index(), last.Generation()); err == nil || em.IsValid(last) {
+		t.Fatalf("CreateWithID revived a retired slot")
+	}
+	if errs := em.validate(); errs != nil {
+		t.Fatalf("manager inconsistent: %v", errs)
+	}
+}
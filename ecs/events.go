@@ -0,0 +1,107 @@
+package ecs
+
+import "reflect"
+
+// eventQueue is the type-erased interface of a per-type event buffer
+type eventQueue interface {
+	// flush delivers pending events to subscribers and drops unretained events
+	flush()
+	// discard drops every queued event without delivering it
+	discard()
+}
+
+// eventBuffer holds the events of a single type
+type eventBuffer[T any] struct {
+	events      []T // Events waiting to be drained by polling consumers
+	pending     []T // Events not yet delivered to subscribers
+	subscribers []func(T)
+	retain      bool // Keep undrained events across frames
+}
+
+func (eb *eventBuffer[T]) flush() {
+	// Subscribers may publish while being notified; those events go out next frame
+	pending := eb.pending
+	eb.pending = nil
+	for _, evt := range pending {
+		for _, fn := range eb.subscribers {
+			fn(evt)
+		}
+	}
+
+	if !eb.retain {
+		eb.events = eb.events[:0]
+	}
+}
+
+func (eb *eventBuffer[T]) discard() {
+	clear(eb.events)
+	eb.events = eb.events[:0]
+	eb.pending = nil
+}
+
+// eventBus routes events between systems without direct references
+type eventBus struct {
+	queues map[reflect.Type]eventQueue
+	order  []eventQueue // Queues in creation order so flushing is deterministic
+}
+
+// newEventBus creates an empty event bus
+func newEventBus() *eventBus {
+	return &eventBus{
+		queues: make(map[reflect.Type]eventQueue),
+		order:  make([]eventQueue, 0),
+	}
+}
+
+// flush ends the frame for every event type
+func (bus *eventBus) flush() {
+	for _, queue := range bus.order {
+		queue.flush()
+	}
+}
+
+// eventBufferFor returns the buffer for event type T, creating it if needed
+func eventBufferFor[T any](w *World) *eventBuffer[T] {
+	eventType := reflect.TypeOf((*T)(nil)).Elem()
+	if queue, exists := w.events.queues[eventType]; exists {
+		return queue.(*eventBuffer[T])
+	}
+
+	buffer := &eventBuffer[T]{}
+	w.events.queues[eventType] = buffer
+	w.events.order = append(w.events.order, buffer)
+	return buffer
+}
+
+// PublishEvent buffers an event for polling consumers and subscribers
+func PublishEvent[T any](w *World, evt T) {
+	buffer := eventBufferFor[T](w)
+	buffer.events = append(buffer.events, evt)
+	buffer.pending = append(buffer.pending, evt)
+}
+
+// DrainEvents returns the buffered events of type T in publish order and removes them
+func DrainEvents[T any](w *World) []T {
+	buffer := eventBufferFor[T](w)
+	if len(buffer.events) == 0 {
+		return nil
+	}
+
+	events := make([]T, len(buffer.events))
+	copy(events, buffer.events)
+	buffer.events = buffer.events[:0]
+	return events
+}
+
+// SubscribeEvent registers a callback that receives every event of type T
+// Callbacks are invoked at the end of each world Update, after all systems ran
+func SubscribeEvent[T any](w *World, fn func(T)) {
+	buffer := eventBufferFor[T](w)
+	buffer.subscribers = append(buffer.subscribers, fn)
+}
+
+// RetainEvents controls whether undrained events of type T survive the end of a frame
+// By default events are dropped once the frame's Update completes
+func RetainEvents[T any](w *World, retain bool) {
+	eventBufferFor[T](w).retain = retain
+}
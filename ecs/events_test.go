@@ -0,0 +1,81 @@
+package ecs
+
+import (
+	"slices"
+	"testing"
+)
+
+type testDamage struct {
+	Target Entity
+	Amount int
+}
+
+func TestEventsDrainInPublishOrder(t *testing.T) {
+	w := NewWorld()
+	for i := 1; i <= 3; i++ {
+		PublishEvent(w, testDamage{Amount: i})
+	}
+
+	want := []testDamage{{Amount: 1}, {Amount: 2}, {Amount: 3}}
+	if got := DrainEvents[testDamage](w); !slices.Equal(got, want) {
+		t.Fatalf("DrainEvents = %v, want %v", got, want)
+	}
+	if got := DrainEvents[testDamage](w); got != nil {
+		t.Fatalf("second DrainEvents = %v, want nil", got)
+	}
+}
+
+func TestSubscribersRunAtEndOfUpdate(t *testing.T) {
+	w := NewWorld()
+	var sound, ui []int
+	SubscribeEvent(w, func(evt testDamage) { sound = append(sound, evt.Amount) })
+	SubscribeEvent(w, func(evt testDamage) { ui = append(ui, evt.Amount) })
+
+	PublishEvent(w, testDamage{Amount: 5})
+	PublishEvent(w, testDamage{Amount: 7})
+	if len(sound) != 0 {
+		t.Fatalf("subscriber called before Update")
+	}
+
+	w.Update(0)
+	if !slices.Equal(sound, []int{5, 7}) || !slices.Equal(ui, []int{5, 7}) {
+		t.Fatalf("subscribers received %v and %v, want [5 7] each", sound, ui)
+	}
+
+	w.Update(0)
+	if len(sound) != 2 {
+		t.Fatalf("events delivered twice: %v", sound)
+	}
+}
+
+func TestEventsClearedEachFrameUnlessRetained(t *testing.T) {
+	w := NewWorld()
+	RetainEvents[int](w, true)
+	PublishEvent(w, testDamage{Amount: 1})
+	PublishEvent(w, 42)
+
+	w.Update(0)
+	if got := DrainEvents[testDamage](w); got != nil {
+		t.Fatalf("unretained events survived the frame: %v", got)
+	}
+	if got := DrainEvents[int](w); !slices.Equal(got, []int{42}) {
+		t.Fatalf("retained events = %v, want [42]", got)
+	}
+}
+
+func TestEventsDoNotLeakAcrossClear(t *testing.T) {
+	w := NewWorld()
+	received := 0
+	SubscribeEvent(w, func(testDamage) { received++ })
+	PublishEvent(w, testDamage{Amount: 1})
+
+	w.Clear()
+	w.Update(0)
+
+	if received != 0 {
+		t.Fatalf("subscriber from before Clear received %d events", received)
+	}
+	if got := DrainEvents[testDamage](w); got != nil {
+		t.Fatalf("events from before Clear = %v, want nil", got)
+	}
+}
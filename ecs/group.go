@@ -0,0 +1,104 @@
+package ecs
+
+// Group keeps the entities holding both A and B packed at the front of both pools,
+// in the same order, so iterating them is a linear walk over two arrays with no
+// per-entity lookups
+// A grouped pool must not be sorted or reordered with Respect or AlignPools
+// Groups and SetStableOrder exclude each other: the group swaps entities in and out
+// of its prefix, so removals could not keep insertion order anyway
+type Group[A, B any] struct {
+	poolA  *ComponentPool[A]
+	poolB  *ComponentPool[B]
+	length int // Number of grouped entities, the shared prefix of both pools
+}
+
+// NewGroup creates the group for components A and B, or returns the existing one
+// Each pool can belong to one group only; grouping an already owned pool, or one
+// with stable order, panics
+func NewGroup[A, B any](w *World) *Group[A, B] {
+	Register[A](w.componentRegistry)
+	Register[B](w.componentRegistry)
+	poolA, _ := GetStorage[A](w.componentRegistry)
+	poolB, _ := GetStorage[B](w.componentRegistry)
+
+	if existing, ok := poolA.group.(*Group[A, B]); ok && existing.poolB == poolB {
+		return existing
+	}
+	if poolA.group != nil || poolB.group != nil || any(poolA) == any(poolB) {
+		panic("ecs: component pool already owned by a group")
+	}
+	if poolA.stableOrder || poolB.stableOrder {
+		panic("ecs: grouping a component pool with stable order")
+	}
+
+	g := &Group[A, B]{poolA: poolA, poolB: poolB}
+	poolA.group = g
+	poolB.group = g
+
+	poolA.onInsert = append(poolA.onInsert, g.onInsert)
+	poolB.onInsert = append(poolB.onInsert, g.onInsert)
+	poolA.onRemove = append(poolA.onRemove, g.onRemove)
+	poolB.onRemove = append(poolB.onRemove, g.onRemove)
+
+	// Pull in entities that already hold both components
+	smaller := poolA.Entities()
+	if poolB.Size() < poolA.Size() {
+		smaller = poolB.Entities()
+	}
+	for _, entity := range append([]Entity(nil), smaller.Data()...) {
+		g.onInsert(entity)
+	}
+	return g
+}
+
+// onInsert moves an entity that now holds both components to the end of the prefix
+func (g *Group[A, B]) onInsert(entity Entity) {
+	if !g.poolA.Contains(entity) || !g.poolB.Contains(entity) {
+		return
+	}
+	if g.poolA.entities.Index(entity) < g.length {
+		return
+	}
+
+	g.poolA.swap(g.poolA.entities.Index(entity), g.length)
+	g.poolB.swap(g.poolB.entities.Index(entity), g.length)
+	g.length++
+}
+
+// onRemove moves an entity about to lose a component out of the prefix
+func (g *Group[A, B]) onRemove(entity Entity) {
+	if !g.poolA.Contains(entity) || !g.poolB.Contains(entity) {
+		return
+	}
+	index := g.poolA.entities.Index(entity)
+	if index >= g.length {
+		return
+	}
+
+	g.length--
+	g.poolA.swap(index, g.length)
+	g.poolB.swap(index, g.length)
+}
+
+// Size returns the number of entities in the group
+func (g *Group[A, B]) Size() int {
+	return g.length
+}
+
+// Entities returns the grouped entities; the slice aliases pool storage
+func (g *Group[A, B]) Entities() []Entity {
+	return g.poolA.entities.Data()[:g.length]
+}
+
+// Each calls fn for every grouped entity with pointers to both components
+// Panics if fn adds or removes A or B, like ComponentPool.ForEach
+func (g *Group[A, B]) Each(fn func(Entity, *A, *B)) {
+	modA, modB := g.poolA.modCount, g.poolB.modCount
+	entities := g.poolA.entities.Data()
+	for i := 0; i < g.length; i++ {
+		fn(entities[i], &g.poolA.components[i], &g.poolB.components[i])
+		if g.poolA.modCount != modA || g.poolB.modCount != modB {
+			panic("ecs: pool modified during iteration")
+		}
+	}
+}
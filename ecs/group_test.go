@@ -0,0 +1,144 @@
+package ecs
+
+import (
+	"fmt"
+	"testing"
+)
+
+// checkGroup verifies that exactly the entities holding both components form the
+// shared prefix of both pools
+func checkGroup(t *testing.T, w *World, g *Group[testPosition, testVelocity]) {
+	t.Helper()
+	positions, velocities := g.poolA, g.poolB
+	want := 0
+	positions.ForEach(func(entity Entity, _ *testPosition) {
+		if velocities.Contains(entity) {
+			want++
+		}
+	})
+	if g.Size() != want {
+		t.Fatalf("group size = %d, want %d", g.Size(), want)
+	}
+
+	positionEntities, velocityEntities := positions.Entities().Data(), velocities.Entities().Data()
+	for i, entity := range g.Entities() {
+		if positionEntities[i] != entity || velocityEntities[i] != entity {
+			t.Fatalf("index %d: group %s, positions %s, velocities %s", i, entity, positionEntities[i], velocityEntities[i])
+		}
+		if p, _ := GetComponent[testPosition](w, entity); p != positions.Data()[i] {
+			t.Fatalf("position of %s is not at its dense index", entity)
+		}
+	}
+	if errs := w.Validate(); errs != nil {
+		t.Fatalf("Validate: %v", errs)
+	}
+}
+
+func TestGroupStaysConsistent(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 30)
+	g := NewGroup[testPosition, testVelocity](w)
+	checkGroup(t, w, g)
+	if g.Size() != 15 {
+		t.Fatalf("group of existing entities has %d, want 15", g.Size())
+	}
+
+	for i, entity := range entities {
+		switch i % 4 {
+		case 0:
+			RemoveComponent[testVelocity](w, entity)
+		case 1:
+			AddComponent(w, entity, testVelocity{X: 2})
+		case 2:
+			RemoveComponent[testPosition](w, entity)
+		case 3:
+			w.DestroyEntity(entity)
+		}
+		checkGroup(t, w, g)
+	}
+
+	fresh := w.CreateEntity()
+	AddComponent(w, fresh, testVelocity{})
+	AddComponent(w, fresh, testPosition{})
+	checkGroup(t, w, g)
+}
+
+func TestGroupEach(t *testing.T) {
+	w := NewWorld()
+	populateTestWorld(w, 10)
+	g := NewGroup[testPosition, testVelocity](w)
+	if NewGroup[testPosition, testVelocity](w) != g {
+		t.Fatalf("NewGroup did not return the existing group")
+	}
+
+	visited := 0
+	g.Each(func(entity Entity, p *testPosition, v *testVelocity) {
+		p.X += v.X
+		visited++
+	})
+	if visited != 5 {
+		t.Fatalf("Each visited %d entities, want 5", visited)
+	}
+	for _, entity := range g.Entities() {
+		if p, _ := GetComponent[testPosition](w, entity); int(p.X)%2 != 1 {
+			t.Fatalf("%s position %v was not moved by Each", entity, p)
+		}
+	}
+
+	expectPanic(t, "ecs: pool modified during iteration", func() {
+		g.Each(func(entity Entity, _ *testPosition, _ *testVelocity) {
+			RemoveComponent[testVelocity](w, entity)
+		})
+	})
+}
+
+func TestGroupOwnedPoolPanics(t *testing.T) {
+	w := NewWorld()
+	NewGroup[testPosition, testVelocity](w)
+	expectPanic(t, "ecs: component pool already owned by a group", func() {
+		NewGroup[testPosition, testHealth](w)
+	})
+}
+
+func TestGroupRejectsStableOrder(t *testing.T) {
+	w := NewWorld()
+	Register[testHealth](w.componentRegistry)
+	health, _ := GetStorage[testHealth](w.componentRegistry)
+	health.SetStableOrder(true)
+	expectPanic(t, "ecs: grouping a component pool with stable order", func() {
+		NewGroup[testPosition, testHealth](w)
+	})
+
+	g := NewGroup[testPosition, testVelocity](w)
+	position, _ := GetStorage[testPosition](w.componentRegistry)
+	expectPanic(t, "ecs: stable order on a grouped component pool", func() {
+		position.SetStableOrder(true)
+	})
+	position.SetStableOrder(false)
+	if position.stableOrder || g.Size() != 0 {
+		t.Fatalf("rejected SetStableOrder changed the pool")
+	}
+}
+
+func BenchmarkGroupVsIter2(b *testing.B) {
+	for _, size := range []int{1000, 100000} {
+		w := NewWorld()
+		populateOverlap(w, size, 50)
+		b.Run(fmt.Sprintf("Iter2/size=%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				Iter2[testPosition, testVelocity](w).ForEach(func(_ Entity, p *testPosition, v *testVelocity) {
+					p.X += v.X
+				})
+			}
+		})
+
+		g := NewGroup[testPosition, testVelocity](w)
+		b.Run(fmt.Sprintf("Group/size=%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				g.Each(func(_ Entity, p *testPosition, v *testVelocity) {
+					p.X += v.X
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package ecs
+
+// LazyQuery defers building a query until its result is needed, then caches the
+// result until the world changes structurally (see World.Version)
+type LazyQuery struct {
+	world   *World
+	spec    func(*Query)
+	result  *QueryResult
+	version uint64
+	builds  int
+}
+
+// LazyQuery creates a lazily built, cached query; spec adds the query criteria,
+// e.g. func(q *Query) { With[Position](q); Without[Static](q) }
+func (w *World) LazyQuery(spec func(*Query)) *LazyQuery {
+	return &LazyQuery{
+		world: w,
+		spec:  spec,
+	}
+}
+
+// Result returns the query result, rebuilding it only if the world changed
+func (lq *LazyQuery) Result() *QueryResult {
+	if lq.result != nil && lq.version == lq.world.Version() {
+		return lq.result
+	}
+
+	query := lq.world.Query()
+	lq.spec(query)
+	lq.result = query.Build()
+	// Building may register component types, so read the version afterwards
+	lq.version = lq.world.Version()
+	lq.builds++
+	return lq.result
+}
+
+// Entities returns the entities that match the query
+func (lq *LazyQuery) Entities() []Entity {
+	return lq.Result().Entities()
+}
+
+// ForEach iterates over all entities that match the query
+func (lq *LazyQuery) ForEach(fn func(Entity)) {
+	lq.Result().ForEach(fn)
+}
+
+// Invalidate drops the cached result so the next access rebuilds it
+func (lq *LazyQuery) Invalidate() {
+	lq.result = nil
+}
+
+// Builds returns how many times the query has been built
+func (lq *LazyQuery) Builds() int {
+	return lq.builds
+}
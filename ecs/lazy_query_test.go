@@ -0,0 +1,39 @@
+package ecs
+
+import "testing"
+
+func TestLazyQueryBuildsOnDemand(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 4)
+	lazy := w.LazyQuery(func(q *Query) { With[testVelocity](q) })
+
+	if lazy.Builds() != 0 {
+		t.Fatalf("query built before first use")
+	}
+	if got := len(lazy.Entities()); got != 2 {
+		t.Fatalf("Entities = %d, want 2", got)
+	}
+	count := 0
+	lazy.ForEach(func(Entity) { count++ })
+	if count != 2 || lazy.Builds() != 1 {
+		t.Fatalf("second access visited %d with %d builds, want 2 and 1 build", count, lazy.Builds())
+	}
+
+	// Value changes are not structural and reuse the result
+	GetComponentPtr[testPosition](w, entities[0]).X = 5
+	lazy.Entities()
+	if lazy.Builds() != 1 {
+		t.Fatalf("rebuilt after a value change")
+	}
+
+	AddComponent(w, entities[1], testVelocity{})
+	if got := len(lazy.Entities()); got != 3 || lazy.Builds() != 2 {
+		t.Fatalf("after a structural change got %d entities with %d builds, want 3 and 2", got, lazy.Builds())
+	}
+
+	lazy.Invalidate()
+	lazy.Entities()
+	if lazy.Builds() != 3 {
+		t.Fatalf("Invalidate did not force a rebuild")
+	}
+}
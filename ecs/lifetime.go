@@ -0,0 +1,32 @@
+package ecs
+
+// Lifetime makes LifetimeSystem destroy an entity once Remaining seconds have passed
+type Lifetime struct {
+	Remaining float64
+}
+
+// LifetimeSystem counts down every Lifetime by deltaTime and destroys the entities
+// whose Remaining reaches zero; disabled entities don't age
+type LifetimeSystem struct {
+	*BaseSystem
+	commands *CommandBuffer // Destructions queued while iterating
+}
+
+// NewLifetimeSystem creates a lifetime system
+func NewLifetimeSystem() *LifetimeSystem {
+	return &LifetimeSystem{
+		BaseSystem: NewBaseSystem("LifetimeSystem"),
+		commands:   NewCommandBuffer(),
+	}
+}
+
+// Update ages every Lifetime and destroys the expired entities
+func (s *LifetimeSystem) Update(world *World, deltaTime float64) {
+	Iter1[Lifetime](world).ForEach(func(entity Entity, lifetime *Lifetime) {
+		lifetime.Remaining -= deltaTime
+		if lifetime.Remaining <= 0 {
+			s.commands.DestroyEntity(entity)
+		}
+	})
+	s.commands.Flush(world)
+}
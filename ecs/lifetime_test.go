@@ -0,0 +1,36 @@
+package ecs
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestLifetimeSystemExpiresEntities(t *testing.T) {
+	w := NewWorld()
+	w.AddSystem(NewLifetimeSystem())
+	short, long, frozen, immortal := w.CreateEntity(), w.CreateEntity(), w.CreateEntity(), w.CreateEntity()
+	AddComponent(w, short, Lifetime{Remaining: 0.5})
+	AddComponent(w, long, Lifetime{Remaining: 1})
+	AddComponent(w, frozen, Lifetime{Remaining: 0.25})
+	AddComponent(w, immortal, testPosition{})
+	w.DisableEntity(frozen)
+
+	// Steps of 0.25 are exact, so lifetimes reach zero on known frames
+	alive := func() []bool {
+		return []bool{w.IsValidEntity(short), w.IsValidEntity(long), w.IsValidEntity(frozen), w.IsValidEntity(immortal)}
+	}
+	for frame, want := range [][]bool{
+		{true, true, true, true},
+		{false, true, true, true},
+		{false, true, true, true},
+		{false, false, true, true},
+	} {
+		w.Update(0.25)
+		if got := alive(); !slices.Equal(got, want) {
+			t.Fatalf("after frame %d alive = %v, want %v", frame, got, want)
+		}
+	}
+	if l, _ := GetComponent[Lifetime](w, frozen); l.Remaining != 0.25 {
+		t.Fatalf("disabled entity aged to %v", l.Remaining)
+	}
+}
@@ -0,0 +1,60 @@
+package ecs
+
+import "fmt"
+
+// Log levels passed to the world's logger
+const (
+	LogLevelDebug = "debug"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+)
+
+// Logger receives a trace of the world's structural changes, in the order they happen
+// Bulk operations (World.Clear, Reset, LoadBinary, Restore) report every entity they
+// destroy or create and every component they drop, like the single-entity calls
+// Embed NopLogger to implement only the methods of interest
+type Logger interface {
+	// EntityCreated is called after an entity is created
+	EntityCreated(entity Entity)
+	// EntityDestroyed is called after an entity is destroyed, following the
+	// ComponentRemoved calls for its components
+	EntityDestroyed(entity Entity)
+	// ComponentAdded is called when an entity gains a component; replacing an
+	// existing component is not an addition
+	ComponentAdded(entity Entity, id ComponentID)
+	// ComponentRemoved is called when an entity loses a component
+	ComponentRemoved(entity Entity, id ComponentID)
+}
+
+// NopLogger is a Logger that ignores everything, the world's default
+type NopLogger struct{}
+
+func (NopLogger) EntityCreated(entity Entity)                    {}
+func (NopLogger) EntityDestroyed(entity Entity)                  {}
+func (NopLogger) ComponentAdded(entity Entity, id ComponentID)   {}
+func (NopLogger) ComponentRemoved(entity Entity, id ComponentID) {}
+
+// SetLogger routes the world's internal diagnostics (misuse warnings, recovered
+// failures) to a user-controlled sink; nil restores the default no-op logger
+func (w *World) SetLogger(logger func(level, msg string)) {
+	w.logger = logger
+}
+
+// SetEventLogger routes a trace of entity and component changes to logger, e.g. for
+// debugging a simulation; nil restores the default no-op logger
+// It is separate from SetLogger, which receives free-form diagnostics
+func (w *World) SetEventLogger(logger Logger) {
+	if logger == nil {
+		logger = NopLogger{}
+	}
+	w.eventLogger = logger
+	w.componentRegistry.logger = logger
+}
+
+// logf formats and emits a diagnostic if a logger is set
+func (w *World) logf(level, format string, args ...any) {
+	if w.logger == nil {
+		return
+	}
+	w.logger(level, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,184 @@
+package ecs
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// testEventLogger records the structural changes it receives as strings
+type testEventLogger struct {
+	events []string
+}
+
+func (l *testEventLogger) EntityCreated(entity Entity) {
+	l.events = append(l.events, fmt.Sprintf("created %d", entity.Index()))
+}
+
+func (l *testEventLogger) EntityDestroyed(entity Entity) {
+	l.events = append(l.events, fmt.Sprintf("destroyed %d", entity.Index()))
+}
+
+func (l *testEventLogger) ComponentAdded(entity Entity, id ComponentID) {
+	l.events = append(l.events, fmt.Sprintf("added %d %d", entity.Index(), id))
+}
+
+func (l *testEventLogger) ComponentRemoved(entity Entity, id ComponentID) {
+	l.events = append(l.events, fmt.Sprintf("removed %d %d", entity.Index(), id))
+}
+
+func TestEventLoggerReceivesChangesInOrder(t *testing.T) {
+	w := NewWorld()
+	logger := &testEventLogger{}
+	w.SetEventLogger(logger)
+
+	entity := w.CreateEntity()
+	AddComponent(w, entity, testPosition{})
+	AddComponent(w, entity, testPosition{X: 1}) // Replacing is not an addition
+	AddComponent(w, entity, testVelocity{})
+	RemoveComponent[testVelocity](w, entity)
+	w.DestroyEntity(entity)
+
+	position, _ := GetComponentID[testPosition](w.componentRegistry)
+	velocity, _ := GetComponentID[testVelocity](w.componentRegistry)
+	want := []string{
+		"created 0",
+		fmt.Sprintf("added 0 %d", position),
+		fmt.Sprintf("added 0 %d", velocity),
+		fmt.Sprintf("removed 0 %d", velocity),
+		fmt.Sprintf("removed 0 %d", position),
+		"destroyed 0",
+	}
+	if !slices.Equal(logger.events, want) {
+		t.Fatalf("events = %v, want %v", logger.events, want)
+	}
+
+	logger.events = nil
+	w.SetEventLogger(nil)
+	w.CreateEntity()
+	if len(logger.events) != 0 {
+		t.Fatalf("logger still received %v after being unset", logger.events)
+	}
+}
+
+func TestEventLoggerSeesReset(t *testing.T) {
+	w := NewWorld()
+	entity := w.CreateEntity()
+	AddComponent(w, entity, testPosition{})
+	logger := &testEventLogger{}
+	w.SetEventLogger(logger)
+
+	w.Reset()
+
+	position, _ := GetComponentID[testPosition](w.componentRegistry)
+	want := []string{fmt.Sprintf("removed 0 %d", position), "destroyed 0"}
+	if !slices.Equal(logger.events, want) {
+		t.Fatalf("events = %v, want %v", logger.events, want)
+	}
+}
+
+func TestSetLoggerReceivesDiagnostics(t *testing.T) {
+	w := NewWorld()
+	var messages []string
+	w.SetLogger(func(level, msg string) {
+		messages = append(messages, level+": "+msg)
+	})
+	w.SetEventLogger(&testEventLogger{})
+
+	w.DestroyEntity(NullEntity)
+	if len(messages) != 1 || !strings.HasPrefix(messages[0], LogLevelWarn) {
+		t.Fatalf("diagnostics = %v, want one warning", messages)
+	}
+
+	w.SetLogger(nil)
+	w.DestroyEntity(NullEntity)
+	if len(messages) != 1 {
+		t.Fatalf("diagnostics still delivered after SetLogger(nil)")
+	}
+}
+
+func TestLoggerReceivesOperationDiagnostics(t *testing.T) {
+	w := NewWorld()
+	var messages []string
+	w.SetLogger(func(level, msg string) {
+		messages = append(messages, level+": "+msg)
+	})
+
+	stale := w.CreateEntity()
+	w.DestroyEntity(stale)
+	AddComponent(w, stale, testPosition{})
+	NewQuery(w).Build()
+
+	want := []string{
+		"warn: AddComponent[ecs.testPosition] on invalid entity " + stale.String() + " ignored",
+		"debug: query has no With or WithAny criteria, returning empty result",
+	}
+	if !slices.Equal(messages, want) {
+		t.Fatalf("messages = %q, want %q", messages, want)
+	}
+}
+
+func TestWorldWithoutLoggerIsSilent(t *testing.T) {
+	w := NewWorld()
+	// Diagnostics with no logger set must not panic
+	w.DestroyEntity(NullEntity)
+	NewQuery(w).Build()
+}
+
+func TestEventLoggerSeesBulkOperations(t *testing.T) {
+	w := NewWorld()
+	RegisterCodec[testPosition](w)
+	entity := w.CreateEntity()
+	AddComponent(w, entity, testPosition{})
+	position, _ := GetComponentID[testPosition](w.componentRegistry)
+	snapshot, err := w.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := &testEventLogger{}
+	w.SetEventLogger(logger)
+	replaced := []string{fmt.Sprintf("removed 0 %d", position), "destroyed 0"}
+	restored := []string{"created 0", fmt.Sprintf("added 0 %d", position)}
+
+	if err := w.Restore(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if want := slices.Concat(replaced, restored); !slices.Equal(logger.events, want) {
+		t.Fatalf("Restore events = %v, want %v", logger.events, want)
+	}
+
+	logger.events = nil
+	w.Clear()
+	if !slices.Equal(logger.events, replaced) {
+		t.Fatalf("Clear events = %v, want %v", logger.events, replaced)
+	}
+
+	// Persistent types keep their entities; the others are destroyed
+	RegisterCodec[testPosition](w)
+	position, _ = GetComponentID[testPosition](w.componentRegistry)
+	RegisterPersistent[testTag](w)
+	kept := w.CreateEntity()
+	AddComponent(w, kept, testTag{})
+	dropped := w.CreateEntity()
+	AddComponent(w, dropped, testPosition{})
+	logger.events = nil
+	w.Clear()
+	want := []string{fmt.Sprintf("removed %d %d", dropped.Index(), position), fmt.Sprintf("destroyed %d", dropped.Index())}
+	if !slices.Equal(logger.events, want) {
+		t.Fatalf("Clear with persistent types events = %v, want %v", logger.events, want)
+	}
+
+	w.DestroyEntity(kept)
+	RegisterCodec[testPosition](w)
+	logger.events = nil
+	if err := w.LoadBinary(bytes.NewReader(snapshot)); err != nil {
+		t.Fatal(err)
+	}
+	position, _ = GetComponentID[testPosition](w.componentRegistry)
+	restored = []string{"created 0", fmt.Sprintf("added 0 %d", position)}
+	if !slices.Equal(logger.events, restored) {
+		t.Fatalf("LoadBinary events = %v, want %v", logger.events, restored)
+	}
+}
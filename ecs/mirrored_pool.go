@@ -0,0 +1,116 @@
+package ecs
+
+// MirroredPool is a component pool that keeps a flat []float32 projection of its
+// components, e.g. for direct GPU upload or a physics engine's own layout
+// The mirror holds Stride floats per component, in the pool's dense order
+// Mutate components through Insert or Update so the mirror stays in sync
+type MirroredPool[T any] struct {
+	pool    *ComponentPool[T]
+	project func(*T) []float32
+	stride  int // Floats per component, fixed by the first projection
+	mirror  []float32
+}
+
+// NewMirroredPool creates a mirrored pool; project must always return the same number of floats
+func NewMirroredPool[T any](project func(*T) []float32) *MirroredPool[T] {
+	return &MirroredPool[T]{
+		pool:    NewComponentPool[T](),
+		project: project,
+		stride:  -1,
+		mirror:  make([]float32, 0),
+	}
+}
+
+// Insert adds or replaces an entity's component and updates its mirror entry
+func (mp *MirroredPool[T]) Insert(entity Entity, component T) {
+	mp.pool.Insert(entity, component)
+	if mp.pool.Contains(entity) {
+		mp.reproject(entity)
+	}
+}
+
+// Update mutates an entity's component in place and updates its mirror entry
+// Returns false if the entity doesn't have the component
+func (mp *MirroredPool[T]) Update(entity Entity, fn func(*T)) bool {
+	ptr := mp.pool.GetPtr(entity)
+	if ptr == nil {
+		return false
+	}
+
+	fn(ptr)
+	mp.reproject(entity)
+	return true
+}
+
+// Remove removes an entity's component and its mirror entry
+func (mp *MirroredPool[T]) Remove(entity Entity) bool {
+	if !mp.pool.Contains(entity) {
+		return false
+	}
+
+	// Mirror the pool's swap-and-pop
+	index := mp.pool.entities.Index(entity)
+	lastIndex := mp.pool.Size() - 1
+	if index != lastIndex {
+		copy(mp.mirror[index*mp.stride:(index+1)*mp.stride], mp.mirror[lastIndex*mp.stride:])
+	}
+	mp.mirror = mp.mirror[:lastIndex*mp.stride]
+
+	return mp.pool.Remove(entity)
+}
+
+// Get retrieves a component for an entity
+func (mp *MirroredPool[T]) Get(entity Entity) (T, bool) {
+	return mp.pool.Get(entity)
+}
+
+// Contains checks if an entity has this component
+func (mp *MirroredPool[T]) Contains(entity Entity) bool {
+	return mp.pool.Contains(entity)
+}
+
+// Size returns the number of entities with this component
+func (mp *MirroredPool[T]) Size() int {
+	return mp.pool.Size()
+}
+
+// Clear removes all components and empties the mirror
+func (mp *MirroredPool[T]) Clear() {
+	mp.pool.Clear()
+	mp.mirror = mp.mirror[:0]
+}
+
+// Entities returns the entities in dense order, matching the mirror layout
+func (mp *MirroredPool[T]) Entities() []Entity {
+	return mp.pool.entities.Data()
+}
+
+// Stride returns the number of floats per component in the mirror, or 0 before the first insert
+func (mp *MirroredPool[T]) Stride() int {
+	return max(mp.stride, 0)
+}
+
+// Mirror returns the projected buffer; entry i belongs to Entities()[i]
+// The slice is only valid until the next mutation of the pool
+func (mp *MirroredPool[T]) Mirror() []float32 {
+	return mp.mirror
+}
+
+// reproject writes the projection of an entity's component into its mirror entry
+func (mp *MirroredPool[T]) reproject(entity Entity) {
+	index := mp.pool.entities.Index(entity)
+	projected := mp.project(&mp.pool.components[index])
+	if mp.stride < 0 {
+		mp.stride = len(projected)
+	}
+	if len(projected) != mp.stride {
+		panic("ecs: mirrored pool projection changed length")
+	}
+
+	start := index * mp.stride
+	if start == len(mp.mirror) {
+		mp.mirror = append(mp.mirror, projected...)
+		return
+	}
+	copy(mp.mirror[start:start+mp.stride], projected)
+}
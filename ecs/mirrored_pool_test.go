@@ -0,0 +1,70 @@
+package ecs
+
+import (
+	"slices"
+	"testing"
+)
+
+// projectPosition flattens a position into two floats
+func projectPosition(pos *testPosition) []float32 {
+	return []float32{float32(pos.X), float32(pos.Y)}
+}
+
+// checkMirror fails unless the mirror holds the projection of every component in dense order
+func checkMirror(t *testing.T, mp *MirroredPool[testPosition]) {
+	t.Helper()
+	want := make([]float32, 0)
+	for _, entity := range mp.Entities() {
+		pos, _ := mp.Get(entity)
+		want = append(want, projectPosition(&pos)...)
+	}
+	if !slices.Equal(mp.Mirror(), want) {
+		t.Fatalf("mirror = %v, want %v", mp.Mirror(), want)
+	}
+}
+
+func TestMirroredPoolFollowsWrites(t *testing.T) {
+	mp := NewMirroredPool(projectPosition)
+	a, b, c := makeEntity(1, 0), makeEntity(2, 0), makeEntity(3, 0)
+	if mp.Stride() != 0 {
+		t.Fatalf("Stride before the first insert = %d, want 0", mp.Stride())
+	}
+
+	mp.Insert(a, testPosition{X: 1, Y: 2})
+	mp.Insert(b, testPosition{X: 3, Y: 4})
+	mp.Insert(c, testPosition{X: 5, Y: 6})
+	checkMirror(t, mp)
+	if mp.Stride() != 2 {
+		t.Fatalf("Stride = %d, want 2", mp.Stride())
+	}
+
+	mp.Insert(b, testPosition{X: 30, Y: 40})
+	checkMirror(t, mp)
+	mp.Update(c, func(pos *testPosition) { pos.Y = 60 })
+	checkMirror(t, mp)
+	if mp.Update(makeEntity(9, 0), func(*testPosition) {}) {
+		t.Fatalf("Update of a missing entity succeeded")
+	}
+
+	// Removal mirrors the pool's swap-and-pop
+	mp.Remove(a)
+	checkMirror(t, mp)
+	if len(mp.Mirror()) != 4 {
+		t.Fatalf("mirror holds %d floats after removal, want 4", len(mp.Mirror()))
+	}
+
+	mp.Clear()
+	if len(mp.Mirror()) != 0 || mp.Size() != 0 {
+		t.Fatalf("Clear left %d floats and %d components", len(mp.Mirror()), mp.Size())
+	}
+}
+
+func TestMirroredPoolProjectionLengthMustNotChange(t *testing.T) {
+	mp := NewMirroredPool(func(pos *testPosition) []float32 {
+		return make([]float32, int(pos.X))
+	})
+	mp.Insert(makeEntity(1, 0), testPosition{X: 2})
+	expectPanic(t, "ecs: mirrored pool projection changed length", func() {
+		mp.Insert(makeEntity(2, 0), testPosition{X: 3})
+	})
+}
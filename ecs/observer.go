@@ -0,0 +1,62 @@
+package ecs
+
+// Observer records which entities gained or lost component T since it was last cleared
+// Each observer keeps its own record, so several systems can observe the same type
+type Observer[T any] struct {
+	added   *SparseSet
+	removed *SparseSet
+}
+
+// Observe creates an observer for component T that sees changes from now on
+func Observe[T any](w *World) *Observer[T] {
+	Register[T](w.componentRegistry)
+	pool, _ := GetStorage[T](w.componentRegistry)
+
+	obs := &Observer[T]{
+		added:   NewSparseSet(),
+		removed: NewSparseSet(),
+	}
+	pool.onInsert = append(pool.onInsert, func(entity Entity) {
+		obs.added.Insert(entity)
+	})
+	pool.onRemove = append(pool.onRemove, func(entity Entity) {
+		// Gaining and losing the component in the same window cancels out
+		if !obs.added.Remove(entity) {
+			obs.removed.Insert(entity)
+		}
+	})
+	return obs
+}
+
+// Added returns the entities that gained the component since the last Clear
+func (o *Observer[T]) Added() []Entity {
+	return append([]Entity(nil), o.added.Data()...)
+}
+
+// Removed returns the entities that lost the component since the last Clear
+// Handles may be dead if the component was removed by destroying the entity
+func (o *Observer[T]) Removed() []Entity {
+	return append([]Entity(nil), o.removed.Data()...)
+}
+
+// Clear forgets the recorded changes
+func (o *Observer[T]) Clear() {
+	o.added.Clear()
+	o.removed.Clear()
+}
+
+// OnAdd registers a callback run after an entity gains component T
+// Overwriting a component the entity already has calls OnReplace callbacks instead
+func OnAdd[T any](w *World, fn func(Entity)) {
+	Register[T](w.componentRegistry)
+	pool, _ := GetStorage[T](w.componentRegistry)
+	pool.onInsert = append(pool.onInsert, fn)
+}
+
+// OnReplace registers a callback run after adding component T to an entity that
+// already has it overwrites the value, with the old and new values
+func OnReplace[T any](w *World, fn func(entity Entity, old, new T)) {
+	Register[T](w.componentRegistry)
+	pool, _ := GetStorage[T](w.componentRegistry)
+	pool.onReplace = append(pool.onReplace, fn)
+}
@@ -0,0 +1,92 @@
+package ecs
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestObserverReportsAddedAndRemoved(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 4)
+	first := Observe[testVelocity](w)
+
+	AddComponent(w, entities[1], testVelocity{})
+	RemoveComponent[testVelocity](w, entities[0])
+	w.DestroyEntity(entities[2])
+
+	if got := first.Added(); !slices.Equal(got, []Entity{entities[1]}) {
+		t.Fatalf("Added = %v, want %v", got, entities[1:2])
+	}
+	if got := sortedByIndex(first.Removed()); !slices.Equal(got, []Entity{entities[0], entities[2]}) {
+		t.Fatalf("Removed = %v, want %v and %v", got, entities[0], entities[2])
+	}
+
+	// Gaining and then losing the component within one window cancels out
+	AddComponent(w, entities[3], testVelocity{})
+	RemoveComponent[testVelocity](w, entities[3])
+	if slices.Contains(first.Added(), entities[3]) || slices.Contains(first.Removed(), entities[3]) {
+		t.Fatalf("add then remove was reported: added %v, removed %v", first.Added(), first.Removed())
+	}
+
+	first.Clear()
+	if len(first.Added()) != 0 || len(first.Removed()) != 0 {
+		t.Fatalf("Clear left added %v, removed %v", first.Added(), first.Removed())
+	}
+}
+
+func TestObserversAreIndependent(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 2)
+	first := Observe[testHealth](w)
+	AddComponent(w, entities[0], testHealth{})
+
+	// An observer only sees changes made after it was created
+	second := Observe[testHealth](w)
+	AddComponent(w, entities[1], testHealth{})
+
+	if got := first.Added(); !slices.Equal(got, entities) {
+		t.Fatalf("first observer Added = %v, want %v", got, entities)
+	}
+	if got := second.Added(); !slices.Equal(got, entities[1:]) {
+		t.Fatalf("second observer Added = %v, want %v", got, entities[1:])
+	}
+
+	first.Clear()
+	RemoveComponent[testHealth](w, entities[0])
+	if got := first.Removed(); !slices.Equal(got, entities[:1]) {
+		t.Fatalf("first observer Removed = %v, want %v", got, entities[:1])
+	}
+	if got := second.Added(); !slices.Equal(got, entities[1:]) {
+		t.Fatalf("clearing one observer changed another: %v", got)
+	}
+	if got := second.Removed(); !slices.Equal(got, entities[:1]) {
+		t.Fatalf("second observer Removed = %v, want %v", got, entities[:1])
+	}
+}
+
+func TestOnReplaceDistinctFromOnAdd(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 2)
+	var added []Entity
+	var replaced [][2]testHealth
+	OnAdd[testHealth](w, func(e Entity) { added = append(added, e) })
+	OnReplace(w, func(e Entity, old, new testHealth) {
+		if e != entities[0] {
+			t.Fatalf("OnReplace got %s, want %s", e, entities[0])
+		}
+		replaced = append(replaced, [2]testHealth{old, new})
+	})
+
+	AddComponent(w, entities[0], testHealth{HP: 1})
+	if !slices.Equal(added, entities[:1]) || len(replaced) != 0 {
+		t.Fatalf("first insert: added %v, replaced %v", added, replaced)
+	}
+	AddComponent(w, entities[0], testHealth{HP: 2})
+	if len(added) != 1 || !slices.Equal(replaced, [][2]testHealth{{{HP: 1}, {HP: 2}}}) {
+		t.Fatalf("second insert: added %v, replaced %v", added, replaced)
+	}
+	AddComponent(w, entities[1], testHealth{HP: 3})
+	if len(added) != 2 || len(replaced) != 1 {
+		t.Fatalf("insert on another entity: added %v, replaced %v", added, replaced)
+	}
+}
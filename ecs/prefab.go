@@ -0,0 +1,84 @@
+package ecs
+
+import "reflect"
+
+// prefabComponent is a recorded component value of a prefab
+type prefabComponent struct {
+	componentType reflect.Type
+	value         any
+	add           func(w *World, entity Entity, value any)
+}
+
+// PrefabBuilder records the component values of a reusable entity blueprint
+type PrefabBuilder struct {
+	components []prefabComponent
+}
+
+// AddPrefabComponent records a component value, replacing any earlier value of the same type
+func AddPrefabComponent[T any](b *PrefabBuilder, component T) {
+	componentType := reflect.TypeOf((*T)(nil)).Elem()
+	recorded := prefabComponent{
+		componentType: componentType,
+		value:         component,
+		add: func(w *World, entity Entity, value any) {
+			AddComponent(w, entity, value.(T))
+		},
+	}
+
+	for i := range b.components {
+		if b.components[i].componentType == componentType {
+			b.components[i] = recorded
+			return
+		}
+	}
+	b.components = append(b.components, recorded)
+}
+
+// ModifyPrefabComponent changes fields of a recorded component value, e.g. to override
+// one field at instantiate time; returns false if the prefab has no component T
+func ModifyPrefabComponent[T any](b *PrefabBuilder, fn func(*T)) bool {
+	componentType := reflect.TypeOf((*T)(nil)).Elem()
+	for i := range b.components {
+		if b.components[i].componentType == componentType {
+			component := b.components[i].value.(T)
+			fn(&component)
+			b.components[i].value = component
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterPrefab records a named entity blueprint built by build
+// Registering an existing name replaces the previous prefab
+func (w *World) RegisterPrefab(name string, build func(b *PrefabBuilder)) {
+	builder := &PrefabBuilder{}
+	build(builder)
+	w.prefabs[name] = builder
+}
+
+// Instantiate creates an entity with the components recorded for a prefab
+// Overrides are applied to a copy of the prefab, so they only affect this entity
+// Returns NullEntity if no prefab has that name
+func (w *World) Instantiate(name string, overrides ...func(b *PrefabBuilder)) Entity {
+	prefab, exists := w.prefabs[name]
+	if !exists {
+		w.logf(LogLevelWarn, "Instantiate of unknown prefab %q ignored", name)
+		return NullEntity
+	}
+
+	if len(overrides) > 0 {
+		prefab = &PrefabBuilder{
+			components: append([]prefabComponent(nil), prefab.components...),
+		}
+		for _, override := range overrides {
+			override(prefab)
+		}
+	}
+
+	entity := w.CreateEntity()
+	for _, component := range prefab.components {
+		component.add(w, entity, component.value)
+	}
+	return entity
+}
@@ -0,0 +1,46 @@
+package ecs
+
+import "testing"
+
+func TestInstantiatePrefabs(t *testing.T) {
+	w := NewWorld()
+	w.RegisterPrefab("goblin", func(b *PrefabBuilder) {
+		AddPrefabComponent(b, testHealth{HP: 10})
+		AddPrefabComponent(b, testPosition{X: 1, Y: 1})
+	})
+
+	goblins := []Entity{w.Instantiate("goblin"), w.Instantiate("goblin"), w.Instantiate("goblin")}
+	for _, goblin := range goblins {
+		if health, _ := GetComponent[testHealth](w, goblin); health.HP != 10 {
+			t.Fatalf("goblin health = %v, want 10", health)
+		}
+		if pos, _ := GetComponent[testPosition](w, goblin); pos != (testPosition{X: 1, Y: 1}) {
+			t.Fatalf("goblin position = %v", pos)
+		}
+	}
+	// The blueprint itself occupies no entity slot
+	if live := w.entityManager.LiveCount(); live != 3 {
+		t.Fatalf("%d live entities, want 3", live)
+	}
+
+	boss := w.Instantiate("goblin", func(b *PrefabBuilder) {
+		ModifyPrefabComponent(b, func(pos *testPosition) { pos.X = 9 })
+		AddPrefabComponent(b, testTag{})
+	})
+	if pos, _ := GetComponent[testPosition](w, boss); pos != (testPosition{X: 9, Y: 1}) {
+		t.Fatalf("overridden position = %v, want X 9 and Y kept", pos)
+	}
+	if !HasComponent[testTag](w, boss) {
+		t.Fatalf("override did not add a component")
+	}
+
+	// Overrides must not leak into the registered prefab
+	plain := w.Instantiate("goblin")
+	if pos, _ := GetComponent[testPosition](w, plain); pos.X != 1 || HasComponent[testTag](w, plain) {
+		t.Fatalf("override changed the prefab: position %v, tagged %v", pos, HasComponent[testTag](w, plain))
+	}
+
+	if w.Instantiate("dragon") != NullEntity {
+		t.Fatalf("instantiating an unknown prefab should return NullEntity")
+	}
+}
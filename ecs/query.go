@@ -1,9 +1,18 @@
 package ecs
 
+import (
+	"cmp"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+)
+
 // QueryResult represents the result of a query operation
 type QueryResult struct {
 	entities []Entity
 	world    *World
+	driver   *SparseSet // Set whose dense order the entities follow, nil if unordered
 }
 
 // NewQueryResult creates a new query result
@@ -36,13 +45,78 @@ func (qr *QueryResult) ForEach(fn func(Entity)) {
 	}
 }
 
+// Filter returns a new result with the entities for which pred returns true, in the
+// same order; the original result and the storages are left untouched
+func (qr *QueryResult) Filter(pred func(Entity) bool) *QueryResult {
+	entities := make([]Entity, 0, len(qr.entities))
+	for _, entity := range qr.entities {
+		if pred(entity) {
+			entities = append(entities, entity)
+		}
+	}
+
+	result := NewQueryResult(entities, qr.world)
+	result.driver = qr.driver
+	return result
+}
+
+// Collect returns a copy of component T for every entity in the result that has it,
+// in result order
+func Collect[T any](result *QueryResult) []T {
+	pool, exists := GetStorage[T](result.world.componentRegistry)
+	if !exists {
+		return nil
+	}
+
+	values := make([]T, 0, len(result.entities))
+	for _, entity := range result.entities {
+		if component := pool.GetPtr(entity); component != nil {
+			values = append(values, *component)
+		}
+	}
+	return values
+}
+
+// EntityValue pairs an entity with one of its component values
+type EntityValue[T any] struct {
+	Entity Entity
+	Value  T
+}
+
+// CollectPairs is like Collect but keeps each value with its entity
+func CollectPairs[T any](result *QueryResult) []EntityValue[T] {
+	pool, exists := GetStorage[T](result.world.componentRegistry)
+	if !exists {
+		return nil
+	}
+
+	pairs := make([]EntityValue[T], 0, len(result.entities))
+	for _, entity := range result.entities {
+		if component := pool.GetPtr(entity); component != nil {
+			pairs = append(pairs, EntityValue[T]{Entity: entity, Value: *component})
+		}
+	}
+	return pairs
+}
+
 // Query provides a fluent interface for querying entities
+// A query keeps scratch state between builds, so one query must not be built from
+// several goroutines at once
 type Query struct {
 	world      *World
 	include    []ComponentID
 	exclude    []ComponentID
 	includeAny []ComponentID
 	excludeAny []ComponentID
+	predicates []func(Entity) bool // Extra conditions that can't be expressed as ID sets
+	within     []Entity            // Candidate set from an earlier result, nil to gather from pools
+
+	includeDisabled bool // Also match entities disabled with World.DisableEntity
+	exact           bool // Reject entities holding components not listed in With or WithAny
+	readOnly        bool // Look component types up without registering them, see ReadOnlyWorld
+
+	scratch  queryMatcher        // Reused by matcher so repeated builds don't allocate
+	storages []IComponentStorage // Reused by buildInto to order the included pools
 }
 
 // NewQuery creates a new query for the world
@@ -58,107 +132,463 @@ func NewQuery(world *World) *Query {
 
 // With adds component types that entities must have (AND operation)
 func With[T any](q *Query) *Query {
-	id := Register[T](q.world.componentRegistry)
+	id := queriedID[T](q)
 	q.include = append(q.include, id)
 	return q
 }
 
 // Without adds component types that entities must not have (NOT operation)
 func Without[T any](q *Query) *Query {
-	id := Register[T](q.world.componentRegistry)
+	id := queriedID[T](q)
 	q.exclude = append(q.exclude, id)
 	return q
 }
 
 // WithAny adds component types where entities must have at least one (OR operation)
 func WithAny[T any](q *Query) *Query {
-	id := Register[T](q.world.componentRegistry)
+	id := queriedID[T](q)
 	q.includeAny = append(q.includeAny, id)
 	return q
 }
 
 // WithoutAny adds component types where entities must not have any (NOR operation)
 func WithoutAny[T any](q *Query) *Query {
-	id := Register[T](q.world.componentRegistry)
+	id := queriedID[T](q)
 	q.excludeAny = append(q.excludeAny, id)
 	return q
 }
 
+// unregisteredID stands in for a type a read-only query found unregistered; it has
+// no storage, so criteria on it behave as for a type no entity holds
+const unregisteredID = ^ComponentID(0)
+
+// queriedID returns T's ID for query criteria, registering T if needed; read-only
+// queries must not change the registry, so they get unregisteredID instead
+func queriedID[T any](q *Query) ComponentID {
+	if q.readOnly {
+		if id, exists := GetComponentID[T](q.world.componentRegistry); exists {
+			return id
+		}
+		return unregisteredID
+	}
+	return registerQueried[T](q.world.componentRegistry)
+}
+
+// WithAll is an alias of With: entities must have every type added this way
+func WithAll[T any](q *Query) *Query {
+	return With[T](q)
+}
+
+// WithoutAll is an alias of Without: entities must have none of the types added this way
+func WithoutAll[T any](q *Query) *Query {
+	return Without[T](q)
+}
+
+// QueryOption adds extra criteria to the query behind an iterator or system
+type QueryOption func(*Query)
+
+// Excluding skips entities that have component T
+func Excluding[T any]() QueryOption {
+	return func(q *Query) {
+		Without[T](q)
+	}
+}
+
+// IncludeDisabled makes the query also match disabled entities, which are skipped by default
+func (q *Query) IncludeDisabled() *Query {
+	q.includeDisabled = true
+	return q
+}
+
+// IncludeDisabled is a QueryOption that makes an iterator or system see disabled entities
+func IncludeDisabled() QueryOption {
+	return func(q *Query) {
+		q.IncludeDisabled()
+	}
+}
+
+// Exact restricts the query to entities holding no components besides those listed
+// with With or WithAny, e.g. to find entities with exactly {A, B} and not {A, B, C}
+func (q *Query) Exact() *Query {
+	q.exact = true
+	return q
+}
+
 // Build executes the query and returns the results
+// With the world's query cache enabled, the result may be shared with other callers
 func (q *Query) Build() *QueryResult {
+	if q.world.queryCache != nil && q.cacheable() {
+		return q.world.cachedQuery(q)
+	}
+	return q.build()
+}
+
+// BuildStrict is like Build but fails if the query references a component type that
+// nothing but queries has registered, which usually means a typo'd or never-added type
+func (q *Query) BuildStrict() (*QueryResult, error) {
+	registry := q.world.componentRegistry
+	var unknown []string
+	for _, ids := range [][]ComponentID{q.include, q.exclude, q.includeAny, q.excludeAny} {
+		for _, id := range ids {
+			if registry.queryOnly[id] {
+				unknown = append(unknown, registry.GetComponentName(id))
+			}
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("ecs: query references unregistered component types %s", strings.Join(unknown, ", "))
+	}
+	return q.Build(), nil
+}
+
+// Count returns the number of matching entities; disabled entities are not counted
+// unless IncludeDisabled was set
+func (q *Query) Count() int {
+	return q.Build().Size()
+}
+
+// BuildInto executes the query into result, reusing its entity slice and growing it
+// only when needed, so a query run every frame doesn't allocate a new result each time
+// A nil result is taken from the world's result pool, see World.ReleaseResult
+// The result's previous entities are overwritten: slices obtained from it earlier
+// are invalid once it is reused. The query cache is bypassed
+func (q *Query) BuildInto(result *QueryResult) *QueryResult {
+	if result == nil {
+		result = q.world.acquireResult()
+	}
+	result.world = q.world
+	return q.buildInto(result)
+}
+
+// acquireResult takes a result from the world's result pool, or creates one
+func (w *World) acquireResult() *QueryResult {
+	if result, ok := w.resultPool.Get().(*QueryResult); ok {
+		return result
+	}
+	return NewQueryResult(make([]Entity, 0), w)
+}
+
+// ReleaseResult returns a result filled by Query.BuildInto to the world's result pool
+// for later BuildInto calls; neither result nor its entities may be used afterwards
+// Results from Build must not be released, as they may be shared through the query cache
+func (w *World) ReleaseResult(result *QueryResult) {
+	if result == nil {
+		return
+	}
+	result.entities = result.entities[:0]
+	result.driver = nil
+	w.resultPool.Put(result)
+}
+
+// build executes the query without consulting the cache
+func (q *Query) build() *QueryResult {
+	return q.buildInto(NewQueryResult([]Entity{}, q.world))
+}
+
+// buildInto executes the query without consulting the cache, writing into result
+func (q *Query) buildInto(result *QueryResult) *QueryResult {
+	entities := result.entities[:0]
+	if q.within != nil {
+		return q.buildWithin(result, entities)
+	}
+
 	if len(q.include) == 0 && len(q.includeAny) == 0 {
 		// No inclusion criteria, return empty result
-		return NewQueryResult([]Entity{}, q.world)
+		q.world.logf(LogLevelDebug, "query has no With or WithAny criteria, returning empty result")
+		return q.finish(result, entities, nil)
 	}
 
-	var candidates []Entity
+	var driver *SparseSet
+	matcher := q.matcher()
+	matcher.include, matcher.require = matcher.include[:0], 0 // Satisfied by how candidates are gathered
 
-	// Start with the smallest required component set
 	if len(q.include) > 0 {
-		// Find the smallest component pool to start with
-		smallestSize := int(^uint(0) >> 1) // Max int
-		var smallestStorage IComponentStorage
-
+		storages := q.storages[:0]
 		for _, id := range q.include {
-			if storage, exists := q.world.componentRegistry.GetStorageByID(id); exists {
-				if storage.Size() < smallestSize {
-					smallestSize = storage.Size()
-					smallestStorage = storage
-				}
+			storage, exists := q.world.componentRegistry.GetStorageByID(id)
+			if !exists {
+				return q.finish(result, entities, nil) // Component type not registered
 			}
+			storages = append(storages, storage)
 		}
+		q.storages = storages
+
+		// Intersect smallest first so every step probes as few entities as possible
+		slices.SortStableFunc(storages, func(a, b IComponentStorage) int {
+			return cmp.Compare(a.Size(), b.Size())
+		})
 
-		if smallestStorage != nil {
-			candidates = smallestStorage.Entities().Data()
+		// Intersection keeps the smallest set's dense order
+		driver = storages[0].Entities()
+		if len(storages) == 1 {
+			entities = append(entities, driver.Data()...)
 		} else {
-			return NewQueryResult([]Entity{}, q.world)
+			entities = driver.intersectInto(entities, storages[1].Entities())
+			for _, storage := range storages[2:] {
+				entities = filterContains(entities, storage.Entities())
+			}
 		}
 	} else if len(q.includeAny) > 0 {
-		// Collect entities from any of the includeAny components
-		entitySet := make(map[Entity]bool)
-		for _, id := range q.includeAny {
+		// Collect entities from any of the includeAny components, visiting storages in
+		// ascending ID order and each in dense order so the result is reproducible
+		ids := slices.Clone(q.includeAny)
+		slices.Sort(ids)
+		storages := q.storages[:0]
+		for _, id := range slices.Compact(ids) {
 			if storage, exists := q.world.componentRegistry.GetStorageByID(id); exists {
-				entities := storage.Entities().Data()
-				for _, entity := range entities {
-					entitySet[entity] = true
+				storages = append(storages, storage)
+			}
+		}
+		q.storages = storages
+
+		for i, storage := range storages {
+			for _, entity := range storage.Entities().Data() {
+				// Entities held by an earlier storage were already collected from it
+				seen := slices.ContainsFunc(storages[:i], func(earlier IComponentStorage) bool {
+					return earlier.Contains(entity)
+				})
+				if !seen {
+					entities = append(entities, entity)
 				}
 			}
 		}
+	}
+
+	// Apply the remaining criteria (exclude, disabled, exact, predicates) last
+	if !matcher.acceptsAll() {
+		entities = filterMatches(entities, matcher)
+	}
+	return q.finish(result, entities, driver)
+}
+
+// buildWithin filters the query's candidate set, dropping entities destroyed since
+func (q *Query) buildWithin(result *QueryResult, entities []Entity) *QueryResult {
+	matcher := q.matcher()
+	entities = slices.Grow(entities, len(q.within))
+	for _, entity := range q.within {
+		if q.world.entityManager.IsValid(entity) && matcher.matches(entity) {
+			entities = append(entities, entity)
+		}
+	}
+	return q.finish(result, entities, nil)
+}
+
+// finish stores matched entities in result, sorted by index in deterministic mode
+// driver is the set whose dense order the entities follow, if any
+func (q *Query) finish(result *QueryResult, entities []Entity, driver *SparseSet) *QueryResult {
+	if q.world.deterministic {
+		slices.SortFunc(entities, func(a, b Entity) int {
+			return cmp.Compare(a.Index(), b.Index())
+		})
+		driver = nil
+	}
+
+	result.entities = entities
+	result.driver = driver
+	return result
+}
+
+// poolModCount returns a pool's modCount, or 0 for a pool that doesn't exist
+func poolModCount[T any](pool *ComponentPool[T]) uint64 {
+	if pool == nil {
+		return 0
+	}
+	return pool.modCount
+}
+
+// denseCursor walks the first pool of an iterator in step with a query result that
+// follows that pool's dense order, so its components are read by position rather
+// than through a sparse lookup per entity
+// It falls back to lookups once the pool changes structurally or is reordered
+type denseCursor struct {
+	active   bool
+	modCount uint64 // Pool modCount the result was built against
+	next     int    // Dense position to resume scanning from
+}
+
+// newDenseCursor enables the fast path if result was driven by pool
+func newDenseCursor[T any](result *QueryResult, pool *ComponentPool[T], modCount uint64) denseCursor {
+	return denseCursor{
+		active:   pool != nil && result.driver == pool.entities,
+		modCount: modCount,
+	}
+}
 
-		candidates = make([]Entity, 0, len(entitySet))
-		for entity := range entitySet {
-			candidates = append(candidates, entity)
+// lookupDense returns entity's component, by position while the cursor is active
+func lookupDense[T any](pool *ComponentPool[T], cursor *denseCursor, entity Entity) *T {
+	if cursor.active && pool.modCount == cursor.modCount {
+		if pool.onAccess != nil {
+			pool.onAccess()
+		}
+		dense := pool.entities.Data()
+		for ; cursor.next < len(dense); cursor.next++ {
+			if dense[cursor.next] == entity {
+				cursor.next++
+				return &pool.components[cursor.next-1]
+			}
 		}
+		cursor.active = false
 	}
+	return pool.GetPtr(entity)
+}
 
-	// Filter candidates
-	result := make([]Entity, 0, len(candidates))
+// filterContains keeps the entities that are also in set, reusing the slice
+func filterContains(entities []Entity, set *SparseSet) []Entity {
+	kept := entities[:0]
+	for _, entity := range entities {
+		if set.Contains(entity) {
+			kept = append(kept, entity)
+		}
+	}
+	return kept
+}
 
-	for _, entity := range candidates {
-		if q.matchesEntity(entity) {
-			result = append(result, entity)
+// filterMatches keeps the entities the matcher accepts, reusing the slice
+func filterMatches(entities []Entity, matcher *queryMatcher) []Entity {
+	kept := entities[:0]
+	for _, entity := range entities {
+		if matcher.matches(entity) {
+			kept = append(kept, entity)
 		}
 	}
+	return kept
+}
+
+// queryMatcher holds a query's criteria with storages resolved once per build
+type queryMatcher struct {
+	include    []IComponentStorage
+	exclude    []IComponentStorage
+	includeAny []IComponentStorage
+	predicates []func(Entity) bool
+	disabled   *SparseSet      // Entities to skip, nil when disabled entities are included
+	allowed    []uint64        // Signature bits an exact query permits, nil when not exact
+	signatures *signatureTable // Entity component bitsets, set when exact or masked
+	impossible bool            // A required component type is not registered
 
-	return NewQueryResult(result, q.world)
+	masked  bool   // Include and exclude are checked with require and forbid instead
+	require uint64 // Signature bits an entity must all have, when masked
+	forbid  uint64 // Signature bits an entity must have none of, when masked
 }
 
-// matchesEntity checks if an entity matches all query criteria
-func (q *Query) matchesEntity(entity Entity) bool {
-	// Check include (must have ALL)
+// matcher resolves the query's component IDs to storages
+// The matcher is the query's scratch one, valid until the next call
+func (q *Query) matcher() *queryMatcher {
+	registry := q.world.componentRegistry
+	m := &q.scratch
+	*m = queryMatcher{
+		include:    m.include[:0],
+		exclude:    m.exclude[:0],
+		includeAny: m.includeAny[:0],
+		predicates: q.predicates,
+		allowed:    m.allowed,
+	}
+	if !q.includeDisabled && q.world.disabled.Size() > 0 {
+		m.disabled = q.world.disabled
+	}
+
 	for _, id := range q.include {
-		if storage, exists := q.world.componentRegistry.GetStorageByID(id); exists {
+		if storage, exists := registry.GetStorageByID(id); exists {
+			m.include = append(m.include, storage)
+		} else {
+			m.impossible = true
+		}
+	}
+
+	// Without and WithoutAny both require the entity to have none of the types
+	for _, ids := range [][]ComponentID{q.exclude, q.excludeAny} {
+		for _, id := range ids {
+			if storage, exists := registry.GetStorageByID(id); exists {
+				m.exclude = append(m.exclude, storage)
+			}
+		}
+	}
+
+	for _, id := range q.includeAny {
+		if storage, exists := registry.GetStorageByID(id); exists {
+			m.includeAny = append(m.includeAny, storage)
+		}
+	}
+	// If none of the any-of types are registered nothing can match
+	if len(q.includeAny) > 0 && len(m.includeAny) == 0 {
+		m.impossible = true
+	}
+
+	// With every include and exclude ID in the signatures' first word, membership
+	// is two mask tests instead of a Contains per pool
+	m.masked = true
+	for _, ids := range [][]ComponentID{q.include, q.exclude, q.excludeAny} {
+		for _, id := range ids {
+			if id >= 64 {
+				m.masked = false
+			}
+		}
+	}
+	if m.masked {
+		m.signatures = registry.signatures
+		for _, id := range q.include {
+			m.require |= 1 << id
+		}
+		for _, ids := range [][]ComponentID{q.exclude, q.excludeAny} {
+			for _, id := range ids {
+				m.forbid |= 1 << id
+			}
+		}
+	}
+
+	if q.exact {
+		m.signatures = registry.signatures
+		if cap(m.allowed) < registry.signatures.stride {
+			m.allowed = make([]uint64, registry.signatures.stride)
+		}
+		m.allowed = m.allowed[:registry.signatures.stride]
+		clear(m.allowed)
+		for _, ids := range [][]ComponentID{q.include, q.includeAny} {
+			for _, id := range ids {
+				if int(id)/64 < len(m.allowed) {
+					m.allowed[id/64] |= 1 << (id % 64)
+				}
+			}
+		}
+	}
+
+	return m
+}
+
+// acceptsAll reports whether the matcher has no criteria left to check
+func (m *queryMatcher) acceptsAll() bool {
+	return !m.impossible && m.disabled == nil && len(m.include) == 0 && len(m.exclude) == 0 &&
+		len(m.includeAny) == 0 && len(m.predicates) == 0 && m.allowed == nil && m.require == 0 && m.forbid == 0
+}
+
+// matches checks if an entity matches all query criteria
+func (m *queryMatcher) matches(entity Entity) bool {
+	if m.impossible {
+		return false
+	}
+
+	if m.disabled != nil && m.disabled.Contains(entity) {
+		return false
+	}
+
+	if m.masked {
+		// Check include and exclude against the entity's signature
+		var signature uint64
+		if row := m.signatures.row(entity.Index()); row != nil {
+			signature = row[0]
+		}
+		if signature&m.require != m.require || signature&m.forbid != 0 {
+			return false
+		}
+	} else {
+		// Check include (must have ALL)
+		for _, storage := range m.include {
 			if !storage.Contains(entity) {
 				return false
 			}
-		} else {
-			return false // Component type not registered
 		}
-	}
 
-	// Check exclude (must have NONE)
-	for _, id := range q.exclude {
-		if storage, exists := q.world.componentRegistry.GetStorageByID(id); exists {
+		// Check exclude (must have NONE)
+		for _, storage := range m.exclude {
 			if storage.Contains(entity) {
 				return false
 			}
@@ -166,14 +596,12 @@ func (q *Query) matchesEntity(entity Entity) bool {
 	}
 
 	// Check includeAny (must have AT LEAST ONE)
-	if len(q.includeAny) > 0 {
+	if len(m.includeAny) > 0 {
 		hasAny := false
-		for _, id := range q.includeAny {
-			if storage, exists := q.world.componentRegistry.GetStorageByID(id); exists {
-				if storage.Contains(entity) {
-					hasAny = true
-					break
-				}
+		for _, storage := range m.includeAny {
+			if storage.Contains(entity) {
+				hasAny = true
+				break
 			}
 		}
 		if !hasAny {
@@ -181,15 +609,22 @@ func (q *Query) matchesEntity(entity Entity) bool {
 		}
 	}
 
-	// Check excludeAny (must have NONE)
-	for _, id := range q.excludeAny {
-		if storage, exists := q.world.componentRegistry.GetStorageByID(id); exists {
-			if storage.Contains(entity) {
+	// Check exact (must have nothing else)
+	if m.allowed != nil {
+		for i, word := range m.signatures.row(entity.Index()) {
+			if word&^m.allowed[i] != 0 {
 				return false
 			}
 		}
 	}
 
+	// Check predicates (must pass ALL)
+	for _, predicate := range m.predicates {
+		if !predicate(entity) {
+			return false
+		}
+	}
+
 	return true
 }
 
@@ -197,50 +632,98 @@ func (q *Query) matchesEntity(entity Entity) bool {
 type Iterator1[T1 any] struct {
 	result         *QueryResult
 	component1Pool *ComponentPool[T1]
+	modCount       uint64 // First pool's modCount when the result was built
 }
 
 // NewIterator1 creates a new single-component iterator
-func NewIterator1[T1 any](world *World) *Iterator1[T1] {
+func NewIterator1[T1 any](world *World, options ...QueryOption) *Iterator1[T1] {
 	pool1, _ := GetStorage[T1](world.componentRegistry)
 
 	query := NewQuery(world)
 	With[T1](query)
+	for _, option := range options {
+		option(query)
+	}
 	result := query.Build()
 
 	return &Iterator1[T1]{
 		result:         result,
+		modCount:       poolModCount(pool1),
 		component1Pool: pool1,
 	}
 }
 
 // ForEach iterates over entities with their components
 func (it *Iterator1[T1]) ForEach(fn func(Entity, *T1)) {
+	cursor := newDenseCursor(it.result, it.component1Pool, it.modCount)
 	for _, entity := range it.result.entities {
-		if comp1 := it.component1Pool.GetPtr(entity); comp1 != nil {
+		if comp1 := lookupDense(it.component1Pool, &cursor, entity); comp1 != nil {
 			fn(entity, comp1)
 		}
 	}
 }
 
+// ForEachIndexed is like ForEach but also passes each entity's position in the query result
+func (it *Iterator1[T1]) ForEachIndexed(fn func(int, Entity, *T1)) {
+	cursor := newDenseCursor(it.result, it.component1Pool, it.modCount)
+	for i, entity := range it.result.entities {
+		if comp1 := lookupDense(it.component1Pool, &cursor, entity); comp1 != nil {
+			fn(i, entity, comp1)
+		}
+	}
+}
+
+// ForEachWhile is like ForEach but stops as soon as fn returns false
+func (it *Iterator1[T1]) ForEachWhile(fn func(Entity, *T1) bool) {
+	cursor := newDenseCursor(it.result, it.component1Pool, it.modCount)
+	for _, entity := range it.result.entities {
+		if comp1 := lookupDense(it.component1Pool, &cursor, entity); comp1 != nil {
+			if !fn(entity, comp1) {
+				return
+			}
+		}
+	}
+}
+
+// All returns a range-over-func sequence of entities and their components
+// Breaking out of the loop stops the iteration
+func (it *Iterator1[T1]) All() iter.Seq2[Entity, *T1] {
+	return func(yield func(Entity, *T1) bool) {
+		cursor := newDenseCursor(it.result, it.component1Pool, it.modCount)
+		for _, entity := range it.result.entities {
+			if comp1 := lookupDense(it.component1Pool, &cursor, entity); comp1 != nil {
+				if !yield(entity, comp1) {
+					return
+				}
+			}
+		}
+	}
+}
+
 // Iterator2 provides iteration over entities with two component types
 type Iterator2[T1, T2 any] struct {
 	result         *QueryResult
 	component1Pool *ComponentPool[T1]
 	component2Pool *ComponentPool[T2]
+	modCount       uint64 // First pool's modCount when the result was built
 }
 
 // NewIterator2 creates a new two-component iterator
-func NewIterator2[T1, T2 any](world *World) *Iterator2[T1, T2] {
+func NewIterator2[T1, T2 any](world *World, options ...QueryOption) *Iterator2[T1, T2] {
 	pool1, _ := GetStorage[T1](world.componentRegistry)
 	pool2, _ := GetStorage[T2](world.componentRegistry)
 
 	query := NewQuery(world)
 	With[T1](query)
 	With[T2](query)
+	for _, option := range options {
+		option(query)
+	}
 	result := query.Build()
 
 	return &Iterator2[T1, T2]{
 		result:         result,
+		modCount:       poolModCount(pool1),
 		component1Pool: pool1,
 		component2Pool: pool2,
 	}
@@ -248,8 +731,9 @@ func NewIterator2[T1, T2 any](world *World) *Iterator2[T1, T2] {
 
 // ForEach iterates over entities with their components
 func (it *Iterator2[T1, T2]) ForEach(fn func(Entity, *T1, *T2)) {
+	cursor := newDenseCursor(it.result, it.component1Pool, it.modCount)
 	for _, entity := range it.result.entities {
-		comp1 := it.component1Pool.GetPtr(entity)
+		comp1 := lookupDense(it.component1Pool, &cursor, entity)
 		comp2 := it.component2Pool.GetPtr(entity)
 		if comp1 != nil && comp2 != nil {
 			fn(entity, comp1, comp2)
@@ -257,16 +741,66 @@ func (it *Iterator2[T1, T2]) ForEach(fn func(Entity, *T1, *T2)) {
 	}
 }
 
+// ForEachIndexed is like ForEach but also passes each entity's position in the query result
+func (it *Iterator2[T1, T2]) ForEachIndexed(fn func(int, Entity, *T1, *T2)) {
+	cursor := newDenseCursor(it.result, it.component1Pool, it.modCount)
+	for i, entity := range it.result.entities {
+		comp1 := lookupDense(it.component1Pool, &cursor, entity)
+		comp2 := it.component2Pool.GetPtr(entity)
+		if comp1 != nil && comp2 != nil {
+			fn(i, entity, comp1, comp2)
+		}
+	}
+}
+
+// ForEachWhile is like ForEach but stops as soon as fn returns false
+func (it *Iterator2[T1, T2]) ForEachWhile(fn func(Entity, *T1, *T2) bool) {
+	cursor := newDenseCursor(it.result, it.component1Pool, it.modCount)
+	for _, entity := range it.result.entities {
+		comp1 := lookupDense(it.component1Pool, &cursor, entity)
+		comp2 := it.component2Pool.GetPtr(entity)
+		if comp1 != nil && comp2 != nil {
+			if !fn(entity, comp1, comp2) {
+				return
+			}
+		}
+	}
+}
+
+// Row2 holds an entity's components as yielded by Iterator2.All
+type Row2[T1, T2 any] struct {
+	C1 *T1
+	C2 *T2
+}
+
+// All returns a range-over-func sequence of entities and their components
+// Breaking out of the loop stops the iteration
+func (it *Iterator2[T1, T2]) All() iter.Seq2[Entity, Row2[T1, T2]] {
+	return func(yield func(Entity, Row2[T1, T2]) bool) {
+		cursor := newDenseCursor(it.result, it.component1Pool, it.modCount)
+		for _, entity := range it.result.entities {
+			comp1 := lookupDense(it.component1Pool, &cursor, entity)
+			comp2 := it.component2Pool.GetPtr(entity)
+			if comp1 != nil && comp2 != nil {
+				if !yield(entity, Row2[T1, T2]{C1: comp1, C2: comp2}) {
+					return
+				}
+			}
+		}
+	}
+}
+
 // Iterator3 provides iteration over entities with three component types
 type Iterator3[T1, T2, T3 any] struct {
 	result         *QueryResult
 	component1Pool *ComponentPool[T1]
 	component2Pool *ComponentPool[T2]
 	component3Pool *ComponentPool[T3]
+	modCount       uint64 // First pool's modCount when the result was built
 }
 
 // NewIterator3 creates a new three-component iterator
-func NewIterator3[T1, T2, T3 any](world *World) *Iterator3[T1, T2, T3] {
+func NewIterator3[T1, T2, T3 any](world *World, options ...QueryOption) *Iterator3[T1, T2, T3] {
 	pool1, _ := GetStorage[T1](world.componentRegistry)
 	pool2, _ := GetStorage[T2](world.componentRegistry)
 	pool3, _ := GetStorage[T3](world.componentRegistry)
@@ -275,10 +809,14 @@ func NewIterator3[T1, T2, T3 any](world *World) *Iterator3[T1, T2, T3] {
 	With[T1](query)
 	With[T2](query)
 	With[T3](query)
+	for _, option := range options {
+		option(query)
+	}
 	result := query.Build()
 
 	return &Iterator3[T1, T2, T3]{
 		result:         result,
+		modCount:       poolModCount(pool1),
 		component1Pool: pool1,
 		component2Pool: pool2,
 		component3Pool: pool3,
@@ -287,8 +825,9 @@ func NewIterator3[T1, T2, T3 any](world *World) *Iterator3[T1, T2, T3] {
 
 // ForEach iterates over entities with their components
 func (it *Iterator3[T1, T2, T3]) ForEach(fn func(Entity, *T1, *T2, *T3)) {
+	cursor := newDenseCursor(it.result, it.component1Pool, it.modCount)
 	for _, entity := range it.result.entities {
-		comp1 := it.component1Pool.GetPtr(entity)
+		comp1 := lookupDense(it.component1Pool, &cursor, entity)
 		comp2 := it.component2Pool.GetPtr(entity)
 		comp3 := it.component3Pool.GetPtr(entity)
 		if comp1 != nil && comp2 != nil && comp3 != nil {
@@ -297,6 +836,275 @@ func (it *Iterator3[T1, T2, T3]) ForEach(fn func(Entity, *T1, *T2, *T3)) {
 	}
 }
 
+// ForEachIndexed is like ForEach but also passes each entity's position in the query result
+func (it *Iterator3[T1, T2, T3]) ForEachIndexed(fn func(int, Entity, *T1, *T2, *T3)) {
+	cursor := newDenseCursor(it.result, it.component1Pool, it.modCount)
+	for i, entity := range it.result.entities {
+		comp1 := lookupDense(it.component1Pool, &cursor, entity)
+		comp2 := it.component2Pool.GetPtr(entity)
+		comp3 := it.component3Pool.GetPtr(entity)
+		if comp1 != nil && comp2 != nil && comp3 != nil {
+			fn(i, entity, comp1, comp2, comp3)
+		}
+	}
+}
+
+// ForEachWhile is like ForEach but stops as soon as fn returns false
+func (it *Iterator3[T1, T2, T3]) ForEachWhile(fn func(Entity, *T1, *T2, *T3) bool) {
+	cursor := newDenseCursor(it.result, it.component1Pool, it.modCount)
+	for _, entity := range it.result.entities {
+		comp1 := lookupDense(it.component1Pool, &cursor, entity)
+		comp2 := it.component2Pool.GetPtr(entity)
+		comp3 := it.component3Pool.GetPtr(entity)
+		if comp1 != nil && comp2 != nil && comp3 != nil {
+			if !fn(entity, comp1, comp2, comp3) {
+				return
+			}
+		}
+	}
+}
+
+// Row3 holds an entity's components as yielded by Iterator3.All
+type Row3[T1, T2, T3 any] struct {
+	C1 *T1
+	C2 *T2
+	C3 *T3
+}
+
+// All returns a range-over-func sequence of entities and their components
+// Breaking out of the loop stops the iteration
+func (it *Iterator3[T1, T2, T3]) All() iter.Seq2[Entity, Row3[T1, T2, T3]] {
+	return func(yield func(Entity, Row3[T1, T2, T3]) bool) {
+		cursor := newDenseCursor(it.result, it.component1Pool, it.modCount)
+		for _, entity := range it.result.entities {
+			comp1 := lookupDense(it.component1Pool, &cursor, entity)
+			comp2 := it.component2Pool.GetPtr(entity)
+			comp3 := it.component3Pool.GetPtr(entity)
+			if comp1 != nil && comp2 != nil && comp3 != nil {
+				if !yield(entity, Row3[T1, T2, T3]{C1: comp1, C2: comp2, C3: comp3}) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Iterator4 provides iteration over entities with four component types
+type Iterator4[T1, T2, T3, T4 any] struct {
+	result         *QueryResult
+	component1Pool *ComponentPool[T1]
+	component2Pool *ComponentPool[T2]
+	component3Pool *ComponentPool[T3]
+	component4Pool *ComponentPool[T4]
+	modCount       uint64 // First pool's modCount when the result was built
+}
+
+// NewIterator4 creates a new four-component iterator
+func NewIterator4[T1, T2, T3, T4 any](world *World, options ...QueryOption) *Iterator4[T1, T2, T3, T4] {
+	pool1, _ := GetStorage[T1](world.componentRegistry)
+	pool2, _ := GetStorage[T2](world.componentRegistry)
+	pool3, _ := GetStorage[T3](world.componentRegistry)
+	pool4, _ := GetStorage[T4](world.componentRegistry)
+
+	query := NewQuery(world)
+	With[T1](query)
+	With[T2](query)
+	With[T3](query)
+	With[T4](query)
+	for _, option := range options {
+		option(query)
+	}
+	result := query.Build()
+
+	return &Iterator4[T1, T2, T3, T4]{
+		result:         result,
+		modCount:       poolModCount(pool1),
+		component1Pool: pool1,
+		component2Pool: pool2,
+		component3Pool: pool3,
+		component4Pool: pool4,
+	}
+}
+
+// ForEach iterates over entities with their components
+func (it *Iterator4[T1, T2, T3, T4]) ForEach(fn func(Entity, *T1, *T2, *T3, *T4)) {
+	cursor := newDenseCursor(it.result, it.component1Pool, it.modCount)
+	for _, entity := range it.result.entities {
+		comp1 := lookupDense(it.component1Pool, &cursor, entity)
+		comp2 := it.component2Pool.GetPtr(entity)
+		comp3 := it.component3Pool.GetPtr(entity)
+		comp4 := it.component4Pool.GetPtr(entity)
+		if comp1 != nil && comp2 != nil && comp3 != nil && comp4 != nil {
+			fn(entity, comp1, comp2, comp3, comp4)
+		}
+	}
+}
+
+// ForEachIndexed is like ForEach but also passes each entity's position in the query result
+func (it *Iterator4[T1, T2, T3, T4]) ForEachIndexed(fn func(int, Entity, *T1, *T2, *T3, *T4)) {
+	cursor := newDenseCursor(it.result, it.component1Pool, it.modCount)
+	for i, entity := range it.result.entities {
+		comp1 := lookupDense(it.component1Pool, &cursor, entity)
+		comp2 := it.component2Pool.GetPtr(entity)
+		comp3 := it.component3Pool.GetPtr(entity)
+		comp4 := it.component4Pool.GetPtr(entity)
+		if comp1 != nil && comp2 != nil && comp3 != nil && comp4 != nil {
+			fn(i, entity, comp1, comp2, comp3, comp4)
+		}
+	}
+}
+
+// ForEachWhile is like ForEach but stops as soon as fn returns false
+func (it *Iterator4[T1, T2, T3, T4]) ForEachWhile(fn func(Entity, *T1, *T2, *T3, *T4) bool) {
+	cursor := newDenseCursor(it.result, it.component1Pool, it.modCount)
+	for _, entity := range it.result.entities {
+		comp1 := lookupDense(it.component1Pool, &cursor, entity)
+		comp2 := it.component2Pool.GetPtr(entity)
+		comp3 := it.component3Pool.GetPtr(entity)
+		comp4 := it.component4Pool.GetPtr(entity)
+		if comp1 != nil && comp2 != nil && comp3 != nil && comp4 != nil {
+			if !fn(entity, comp1, comp2, comp3, comp4) {
+				return
+			}
+		}
+	}
+}
+
+// Iterator5 provides iteration over entities with five component types
+type Iterator5[T1, T2, T3, T4, T5 any] struct {
+	result         *QueryResult
+	component1Pool *ComponentPool[T1]
+	component2Pool *ComponentPool[T2]
+	component3Pool *ComponentPool[T3]
+	component4Pool *ComponentPool[T4]
+	component5Pool *ComponentPool[T5]
+	modCount       uint64 // First pool's modCount when the result was built
+}
+
+// NewIterator5 creates a new five-component iterator
+func NewIterator5[T1, T2, T3, T4, T5 any](world *World, options ...QueryOption) *Iterator5[T1, T2, T3, T4, T5] {
+	pool1, _ := GetStorage[T1](world.componentRegistry)
+	pool2, _ := GetStorage[T2](world.componentRegistry)
+	pool3, _ := GetStorage[T3](world.componentRegistry)
+	pool4, _ := GetStorage[T4](world.componentRegistry)
+	pool5, _ := GetStorage[T5](world.componentRegistry)
+
+	query := NewQuery(world)
+	With[T1](query)
+	With[T2](query)
+	With[T3](query)
+	With[T4](query)
+	With[T5](query)
+	for _, option := range options {
+		option(query)
+	}
+	result := query.Build()
+
+	return &Iterator5[T1, T2, T3, T4, T5]{
+		result:         result,
+		modCount:       poolModCount(pool1),
+		component1Pool: pool1,
+		component2Pool: pool2,
+		component3Pool: pool3,
+		component4Pool: pool4,
+		component5Pool: pool5,
+	}
+}
+
+// ForEach iterates over entities with their components
+func (it *Iterator5[T1, T2, T3, T4, T5]) ForEach(fn func(Entity, *T1, *T2, *T3, *T4, *T5)) {
+	cursor := newDenseCursor(it.result, it.component1Pool, it.modCount)
+	for _, entity := range it.result.entities {
+		comp1 := lookupDense(it.component1Pool, &cursor, entity)
+		comp2 := it.component2Pool.GetPtr(entity)
+		comp3 := it.component3Pool.GetPtr(entity)
+		comp4 := it.component4Pool.GetPtr(entity)
+		comp5 := it.component5Pool.GetPtr(entity)
+		if comp1 != nil && comp2 != nil && comp3 != nil && comp4 != nil && comp5 != nil {
+			fn(entity, comp1, comp2, comp3, comp4, comp5)
+		}
+	}
+}
+
+// ForEachIndexed is like ForEach but also passes each entity's position in the query result
+func (it *Iterator5[T1, T2, T3, T4, T5]) ForEachIndexed(fn func(int, Entity, *T1, *T2, *T3, *T4, *T5)) {
+	cursor := newDenseCursor(it.result, it.component1Pool, it.modCount)
+	for i, entity := range it.result.entities {
+		comp1 := lookupDense(it.component1Pool, &cursor, entity)
+		comp2 := it.component2Pool.GetPtr(entity)
+		comp3 := it.component3Pool.GetPtr(entity)
+		comp4 := it.component4Pool.GetPtr(entity)
+		comp5 := it.component5Pool.GetPtr(entity)
+		if comp1 != nil && comp2 != nil && comp3 != nil && comp4 != nil && comp5 != nil {
+			fn(i, entity, comp1, comp2, comp3, comp4, comp5)
+		}
+	}
+}
+
+// ForEachWhile is like ForEach but stops as soon as fn returns false
+func (it *Iterator5[T1, T2, T3, T4, T5]) ForEachWhile(fn func(Entity, *T1, *T2, *T3, *T4, *T5) bool) {
+	cursor := newDenseCursor(it.result, it.component1Pool, it.modCount)
+	for _, entity := range it.result.entities {
+		comp1 := lookupDense(it.component1Pool, &cursor, entity)
+		comp2 := it.component2Pool.GetPtr(entity)
+		comp3 := it.component3Pool.GetPtr(entity)
+		comp4 := it.component4Pool.GetPtr(entity)
+		comp5 := it.component5Pool.GetPtr(entity)
+		if comp1 != nil && comp2 != nil && comp3 != nil && comp4 != nil && comp5 != nil {
+			if !fn(entity, comp1, comp2, comp3, comp4, comp5) {
+				return
+			}
+		}
+	}
+}
+
+// Iterator1Opt2 provides iteration over entities with one required and two optional components
+type Iterator1Opt2[TReq, TOpt1, TOpt2 any] struct {
+	result       *QueryResult
+	requiredPool *ComponentPool[TReq]
+	opt1Pool     *ComponentPool[TOpt1]
+	opt2Pool     *ComponentPool[TOpt2]
+}
+
+// NewIterator1Opt2 creates a new iterator with one required and two optional components
+func NewIterator1Opt2[TReq, TOpt1, TOpt2 any](world *World) *Iterator1Opt2[TReq, TOpt1, TOpt2] {
+	requiredPool, _ := GetStorage[TReq](world.componentRegistry)
+	opt1Pool, _ := GetStorage[TOpt1](world.componentRegistry)
+	opt2Pool, _ := GetStorage[TOpt2](world.componentRegistry)
+
+	query := NewQuery(world)
+	With[TReq](query)
+	result := query.Build()
+
+	return &Iterator1Opt2[TReq, TOpt1, TOpt2]{
+		result:       result,
+		requiredPool: requiredPool,
+		opt1Pool:     opt1Pool,
+		opt2Pool:     opt2Pool,
+	}
+}
+
+// ForEach iterates over entities with the required component
+// Optional component pointers are nil when the entity lacks them
+func (it *Iterator1Opt2[TReq, TOpt1, TOpt2]) ForEach(fn func(Entity, *TReq, *TOpt1, *TOpt2)) {
+	for _, entity := range it.result.entities {
+		required := it.requiredPool.GetPtr(entity)
+		if required == nil {
+			continue
+		}
+
+		var opt1 *TOpt1
+		if it.opt1Pool != nil {
+			opt1 = it.opt1Pool.GetPtr(entity)
+		}
+		var opt2 *TOpt2
+		if it.opt2Pool != nil {
+			opt2 = it.opt2Pool.GetPtr(entity)
+		}
+		fn(entity, required, opt1, opt2)
+	}
+}
+
 // ViewBuilder provides a more flexible way to build queries
 type ViewBuilder struct {
 	world *World
@@ -335,7 +1143,109 @@ func (vb *ViewBuilder) ExcludeAny(componentIDs ...ComponentID) *ViewBuilder {
 	return vb
 }
 
+// Exact rejects entities holding components not passed to Include or IncludeAny
+func (vb *ViewBuilder) Exact() *ViewBuilder {
+	vb.query.Exact()
+	return vb
+}
+
 // Build executes the query
 func (vb *ViewBuilder) Build() *QueryResult {
 	return vb.query.Build()
 }
+
+// BuildErased executes the query and returns a result whose components are read
+// as untyped values by ID, for callers that only know component types at runtime
+func (vb *ViewBuilder) BuildErased() *ErasedResult {
+	return &ErasedResult{
+		QueryResult: vb.query.Build(),
+		include:     append([]ComponentID(nil), vb.query.include...),
+	}
+}
+
+// ViewEach1 builds the view and calls fn for every matching entity that also has T1,
+// with a pointer to it; Go methods can't take type parameters, hence a function
+func ViewEach1[T1 any](vb *ViewBuilder, fn func(Entity, *T1)) {
+	pool1, exists := GetStorage[T1](vb.world.componentRegistry)
+	if !exists {
+		return
+	}
+
+	for _, entity := range vb.Build().entities {
+		if comp1 := pool1.GetPtr(entity); comp1 != nil {
+			fn(entity, comp1)
+		}
+	}
+}
+
+// ViewEach2 is like ViewEach1 for entities that also have T1 and T2
+func ViewEach2[T1, T2 any](vb *ViewBuilder, fn func(Entity, *T1, *T2)) {
+	pool1, exists1 := GetStorage[T1](vb.world.componentRegistry)
+	pool2, exists2 := GetStorage[T2](vb.world.componentRegistry)
+	if !exists1 || !exists2 {
+		return
+	}
+
+	for _, entity := range vb.Build().entities {
+		comp1 := pool1.GetPtr(entity)
+		comp2 := pool2.GetPtr(entity)
+		if comp1 != nil && comp2 != nil {
+			fn(entity, comp1, comp2)
+		}
+	}
+}
+
+// ViewEach3 is like ViewEach1 for entities that also have T1, T2 and T3
+func ViewEach3[T1, T2, T3 any](vb *ViewBuilder, fn func(Entity, *T1, *T2, *T3)) {
+	pool1, exists1 := GetStorage[T1](vb.world.componentRegistry)
+	pool2, exists2 := GetStorage[T2](vb.world.componentRegistry)
+	pool3, exists3 := GetStorage[T3](vb.world.componentRegistry)
+	if !exists1 || !exists2 || !exists3 {
+		return
+	}
+
+	for _, entity := range vb.Build().entities {
+		comp1 := pool1.GetPtr(entity)
+		comp2 := pool2.GetPtr(entity)
+		comp3 := pool3.GetPtr(entity)
+		if comp1 != nil && comp2 != nil && comp3 != nil {
+			fn(entity, comp1, comp2, comp3)
+		}
+	}
+}
+
+// ErasedResult is a query result with type-erased component access
+type ErasedResult struct {
+	*QueryResult
+	include []ComponentID // Required component IDs, in the order passed to Include
+}
+
+// Get returns a copy of an entity's component by ID
+func (er *ErasedResult) Get(entity Entity, id ComponentID) (any, bool) {
+	storage, exists := er.world.componentRegistry.GetStorageByID(id)
+	if !exists {
+		return nil, false
+	}
+	return storage.GetAny(entity)
+}
+
+// ForEachValues calls fn for every entity with copies of its required components, in the
+// order their IDs were passed to Include; the values slice is reused between calls
+func (er *ErasedResult) ForEachValues(fn func(Entity, []any)) {
+	storages := make([]IComponentStorage, len(er.include))
+	for i, id := range er.include {
+		storage, exists := er.world.componentRegistry.GetStorageByID(id)
+		if !exists {
+			return
+		}
+		storages[i] = storage
+	}
+
+	values := make([]any, len(storages))
+	for _, entity := range er.entities {
+		for i, storage := range storages {
+			values[i], _ = storage.GetAny(entity)
+		}
+		fn(entity, values)
+	}
+}
@@ -0,0 +1,80 @@
+package ecs
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// cachedResult is a memoized query result and the world version it was built at
+type cachedResult struct {
+	result  *QueryResult
+	version uint64
+}
+
+// EnableQueryCache memoizes query results by component signature, so identical
+// queries from different systems in the same frame share one result
+// Entries are dropped when Update starts a new frame, on InvalidateQueries, and
+// whenever entities or components change structurally
+// Cached results are shared, callers must not modify their entity slices
+func (w *World) EnableQueryCache(enabled bool) {
+	if enabled {
+		if w.queryCache == nil {
+			w.queryCache = make(map[string]cachedResult)
+		}
+	} else {
+		w.queryCache = nil
+	}
+}
+
+// InvalidateQueries drops every memoized query result
+func (w *World) InvalidateQueries() {
+	if w.queryCache != nil {
+		clear(w.queryCache)
+	}
+}
+
+// cachedQuery returns the memoized result for a query, building it on a miss
+func (w *World) cachedQuery(q *Query) *QueryResult {
+	key := q.signature()
+	version := w.Version()
+	if entry, exists := w.queryCache[key]; exists && entry.version == version {
+		return entry.result
+	}
+
+	result := q.build()
+	w.queryCache[key] = cachedResult{result: result, version: version}
+	return result
+}
+
+// cacheable reports whether a query is fully described by its component sets
+// Read-only queries are never cached, as filling the cache would write to the world
+func (q *Query) cacheable() bool {
+	return len(q.predicates) == 0 && q.within == nil && !q.readOnly
+}
+
+// signature returns a canonical key for the query's component sets, so queries
+// listing the same types in a different order share a cache entry
+func (q *Query) signature() string {
+	var b strings.Builder
+	for i, ids := range [][]ComponentID{q.include, q.exclude, q.includeAny, q.excludeAny} {
+		if i > 0 {
+			b.WriteByte('|')
+		}
+		sorted := slices.Clone(ids)
+		slices.Sort(sorted)
+		for j, id := range slices.Compact(sorted) {
+			if j > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(strconv.FormatUint(uint64(id), 10))
+		}
+	}
+	if q.includeDisabled {
+		b.WriteString("|disabled")
+	}
+	if q.exact {
+		b.WriteString("|exact")
+	}
+	return b.String()
+}
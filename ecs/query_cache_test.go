@@ -0,0 +1,58 @@
+package ecs
+
+import "testing"
+
+// sameBacking reports whether two results share one entity slice
+func sameBacking(a, b *QueryResult) bool {
+	return len(a.entities) > 0 && len(b.entities) > 0 && &a.entities[0] == &b.entities[0]
+}
+
+func TestQueryCacheSharesIdenticalQueries(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 10)
+	AddComponent(w, entities[0], testTag{})
+	w.EnableQueryCache(true)
+
+	first := Without[testTag](With[testVelocity](With[testPosition](NewQuery(w)))).Build()
+	// The same component sets listed in another order hit the same entry
+	second := With[testPosition](Without[testTag](With[testVelocity](NewQuery(w)))).Build()
+	if !sameBacking(first, second) {
+		t.Fatalf("identical queries in one frame built separate results")
+	}
+	if other := With[testPosition](NewQuery(w)).Build(); sameBacking(first, other) {
+		t.Fatalf("a different query shared the cached result")
+	}
+
+	// A structural change invalidates the entry
+	RemoveComponent[testTag](w, entities[0])
+	third := Without[testTag](With[testVelocity](With[testPosition](NewQuery(w)))).Build()
+	if sameBacking(first, third) || third.Size() != first.Size()+1 {
+		t.Fatalf("cached result survived a structural change: %d entities", third.Size())
+	}
+
+	w.InvalidateQueries()
+	if len(w.queryCache) != 0 {
+		t.Fatalf("InvalidateQueries left %d entries", len(w.queryCache))
+	}
+}
+
+func TestQueryCacheClearedEachFrame(t *testing.T) {
+	w := NewWorld()
+	populateTestWorld(w, 4)
+	w.EnableQueryCache(true)
+
+	With[testPosition](NewQuery(w)).Build()
+	if len(w.queryCache) != 1 {
+		t.Fatalf("query cache holds %d entries, want 1", len(w.queryCache))
+	}
+	w.Update(0)
+	if len(w.queryCache) != 0 {
+		t.Fatalf("Update kept %d cached results", len(w.queryCache))
+	}
+
+	// Without the cache every Build returns a fresh result
+	w.EnableQueryCache(false)
+	if sameBacking(With[testPosition](NewQuery(w)).Build(), With[testPosition](NewQuery(w)).Build()) {
+		t.Fatalf("results shared with the cache disabled")
+	}
+}
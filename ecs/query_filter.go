@@ -0,0 +1,58 @@
+package ecs
+
+// FilterExpr is a boolean expression over component types for Query.Filter,
+// built with Has, And, Or and Not
+type FilterExpr struct {
+	resolve func(q *Query) queryExpr
+}
+
+// Has matches entities holding component T
+func Has[T any]() FilterExpr {
+	return FilterExpr{resolve: func(q *Query) queryExpr {
+		id := queriedID[T](q)
+		storage, _ := q.world.componentRegistry.GetStorageByID(id)
+		return &componentExpr{id: id, storage: storage}
+	}}
+}
+
+// And matches entities matching every operand
+func And(operands ...FilterExpr) FilterExpr {
+	return FilterExpr{resolve: func(q *Query) queryExpr {
+		return &andExpr{operands: resolveAll(q, operands)}
+	}}
+}
+
+// Or matches entities matching at least one operand
+func Or(operands ...FilterExpr) FilterExpr {
+	return FilterExpr{resolve: func(q *Query) queryExpr {
+		return &orExpr{operands: resolveAll(q, operands)}
+	}}
+}
+
+// Not matches entities not matching its operand
+func Not(operand FilterExpr) FilterExpr {
+	return FilterExpr{resolve: func(q *Query) queryExpr {
+		return &notExpr{operand: operand.resolve(q)}
+	}}
+}
+
+// resolveAll resolves a list of operands for a query
+func resolveAll(q *Query, operands []FilterExpr) []queryExpr {
+	resolved := make([]queryExpr, len(operands))
+	for i, operand := range operands {
+		resolved[i] = operand.resolve(q)
+	}
+	return resolved
+}
+
+// Filter narrows the query to entities matching a boolean expression, e.g.
+// And(Has[A](), Or(Has[B](), Not(Has[C]())))
+// Top-level Has and Not(Has) terms join the With/Without sets, the rest is checked
+// per entity; an expression that can match entities holding none of its types, such
+// as Not(Has[A]()) alone, needs With or WithAny criteria to gather candidates from
+func (q *Query) Filter(expr FilterExpr) *Query {
+	resolved := expr.resolve(q)
+	gather := !resolved.eval(func(*componentExpr) bool { return false })
+	q.compileExpr(resolved, gather)
+	return q
+}
@@ -0,0 +1,40 @@
+package ecs
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFilterNestedOrInsideAnd(t *testing.T) {
+	w := NewWorld()
+	populateOverlap(w, 300, 20)
+
+	has := func(check func(*World, Entity) bool) func(Entity) bool {
+		return func(entity Entity) bool { return check(w, entity) }
+	}
+	position, velocity := has(HasComponent[testPosition]), has(HasComponent[testVelocity])
+	health, tag := has(HasComponent[testHealth]), has(HasComponent[testTag])
+	movingOrUntagged := func(entity Entity) bool { return velocity(entity) || !tag(entity) }
+
+	got := NewQuery(w).Filter(And(Has[testHealth](), Or(Has[testVelocity](), Not(Has[testTag]())))).Build().Entities()
+	want := bruteForceMatch(w, []func(Entity) bool{health, movingOrUntagged}, nil)
+	if !slices.Equal(sortedByIndex(got), want) || len(want) == 0 {
+		t.Fatalf("And(health, Or(velocity, Not(tag))) = %d entities, want %d", len(got), len(want))
+	}
+
+	// An Or at the top gathers candidates from each of its types
+	got = NewQuery(w).Filter(Or(Has[testVelocity](), And(Has[testTag](), Not(Has[testHealth]())))).Build().Entities()
+	want = bruteForceMatch(w, []func(Entity) bool{func(e Entity) bool {
+		return velocity(e) || (tag(e) && !health(e))
+	}}, nil)
+	if !slices.Equal(sortedByIndex(got), want) {
+		t.Fatalf("Or(velocity, And(tag, Not(health))) = %d entities, want %d", len(got), len(want))
+	}
+
+	// Combined with the flat builder, a lone Not needs With to gather candidates
+	got = With[testPosition](NewQuery(w)).Filter(Not(Or(Has[testHealth](), Has[testTag]()))).Build().Entities()
+	want = bruteForceMatch(w, []func(Entity) bool{position}, []func(Entity) bool{health, tag})
+	if !slices.Equal(sortedByIndex(got), want) || len(want) == 0 {
+		t.Fatalf("With(position) Not(Or(health, tag)) = %d entities, want %d", len(got), len(want))
+	}
+}
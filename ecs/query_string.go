@@ -0,0 +1,285 @@
+package ecs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// queryExpr is a node of a parsed boolean query expression
+type queryExpr interface {
+	// eval evaluates the expression given a membership test for component terms
+	eval(has func(*componentExpr) bool) bool
+}
+
+// componentExpr matches entities holding a component type
+type componentExpr struct {
+	id      ComponentID
+	storage IComponentStorage
+}
+
+func (e *componentExpr) eval(has func(*componentExpr) bool) bool {
+	return has(e)
+}
+
+// notExpr negates its operand
+type notExpr struct {
+	operand queryExpr
+}
+
+func (e *notExpr) eval(has func(*componentExpr) bool) bool {
+	return !e.operand.eval(has)
+}
+
+// andExpr matches when all operands match
+type andExpr struct {
+	operands []queryExpr
+}
+
+func (e *andExpr) eval(has func(*componentExpr) bool) bool {
+	for _, operand := range e.operands {
+		if !operand.eval(has) {
+			return false
+		}
+	}
+	return true
+}
+
+// orExpr matches when at least one operand matches
+type orExpr struct {
+	operands []queryExpr
+}
+
+func (e *orExpr) eval(has func(*componentExpr) bool) bool {
+	for _, operand := range e.operands {
+		if operand.eval(has) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesExpr checks if an entity satisfies a parsed expression
+func matchesExpr(expr queryExpr, entity Entity) bool {
+	return expr.eval(func(c *componentExpr) bool {
+		return c.storage != nil && c.storage.Contains(entity)
+	})
+}
+
+// componentTerms collects the positive component terms of an expression
+func componentTerms(expr queryExpr, terms []*componentExpr) []*componentExpr {
+	switch e := expr.(type) {
+	case *componentExpr:
+		terms = append(terms, e)
+	case *notExpr:
+		terms = componentTerms(e.operand, terms)
+	case *andExpr:
+		for _, operand := range e.operands {
+			terms = componentTerms(operand, terms)
+		}
+	case *orExpr:
+		for _, operand := range e.operands {
+			terms = componentTerms(operand, terms)
+		}
+	}
+	return terms
+}
+
+// applyExpr compiles an expression into the query's include/exclude sets,
+// falling back to a predicate for parts the flat sets can't express
+func (q *Query) applyExpr(expr queryExpr) error {
+	// Entities are gathered from component pools, so an expression that matches
+	// an entity holding none of its components can't be evaluated
+	if expr.eval(func(*componentExpr) bool { return false }) {
+		return fmt.Errorf("ecs: query matches entities without any of its components")
+	}
+	q.compileExpr(expr, true)
+	return nil
+}
+
+// compileExpr adds an expression's top-level terms to the include/exclude sets and
+// the rest as predicates; with gather and no required component, the referenced
+// types become WithAny so candidates can be gathered from their pools
+func (q *Query) compileExpr(expr queryExpr, gather bool) {
+	operands := []queryExpr{expr}
+	if and, ok := expr.(*andExpr); ok {
+		operands = and.operands
+	}
+
+	hasInclude := false
+	for _, operand := range operands {
+		switch e := operand.(type) {
+		case *componentExpr:
+			q.include = append(q.include, e.id)
+			hasInclude = true
+			continue
+		case *notExpr:
+			if c, ok := e.operand.(*componentExpr); ok {
+				q.exclude = append(q.exclude, c.id)
+				continue
+			}
+		}
+		predicate := operand
+		q.predicates = append(q.predicates, func(entity Entity) bool {
+			return matchesExpr(predicate, entity)
+		})
+	}
+
+	// Without a required component, candidates come from any referenced pool
+	if gather && !hasInclude {
+		seen := make(map[ComponentID]bool)
+		for _, term := range componentTerms(expr, nil) {
+			if !seen[term.id] {
+				seen[term.id] = true
+				q.includeAny = append(q.includeAny, term.id)
+			}
+		}
+	}
+}
+
+// ParseQuery builds a query from a boolean expression over component type names
+// e.g. "Position & Velocity & !Player", supporting &, |, ! and parentheses
+// Names are resolved against registered types by full or unqualified name
+func (w *World) ParseQuery(expr string) (*Query, error) {
+	p := &queryParser{input: expr, registry: w.componentRegistry}
+	parsed, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	q := NewQuery(w)
+	if err := q.applyExpr(parsed); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// QueryString parses and executes a query expression
+func (w *World) QueryString(expr string) (*QueryResult, error) {
+	q, err := w.ParseQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	return q.Build(), nil
+}
+
+// queryParser is a recursive descent parser for query expressions
+//
+//	or    := and ('|' and)*
+//	and   := unary ('&' unary)*
+//	unary := '!' unary | name | '(' or ')'
+type queryParser struct {
+	input    string
+	pos      int
+	registry *ComponentRegistry
+}
+
+func (p *queryParser) parse() (queryExpr, error) {
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return nil, p.errorf("unexpected %q", p.input[p.pos])
+	}
+	return expr, nil
+}
+
+func (p *queryParser) parseOr() (queryExpr, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	operands := []queryExpr{first}
+	for p.accept('|') {
+		next, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, next)
+	}
+	if len(operands) == 1 {
+		return first, nil
+	}
+	return &orExpr{operands: operands}, nil
+}
+
+func (p *queryParser) parseAnd() (queryExpr, error) {
+	first, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	operands := []queryExpr{first}
+	for p.accept('&') {
+		next, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, next)
+	}
+	if len(operands) == 1 {
+		return first, nil
+	}
+	return &andExpr{operands: operands}, nil
+}
+
+func (p *queryParser) parseUnary() (queryExpr, error) {
+	if p.accept('!') {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{operand: operand}, nil
+	}
+
+	if p.accept('(') {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(')') {
+			return nil, p.errorf("expected ')'")
+		}
+		return expr, nil
+	}
+
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.input) && !strings.ContainsRune("&|!() \t\n\r", rune(p.input[p.pos])) {
+		p.pos++
+	}
+	if start == p.pos {
+		if p.pos >= len(p.input) {
+			return nil, p.errorf("unexpected end of expression")
+		}
+		return nil, p.errorf("unexpected %q", p.input[p.pos])
+	}
+
+	name := p.input[start:p.pos]
+	id, exists := p.registry.GetComponentIDByName(name)
+	if !exists {
+		return nil, fmt.Errorf("ecs: unknown component %q in query %q", name, p.input)
+	}
+	storage, _ := p.registry.GetStorageByID(id)
+	return &componentExpr{id: id, storage: storage}, nil
+}
+
+// accept consumes the next non-space character if it matches c
+func (p *queryParser) accept(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.input) && p.input[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) skipSpace() {
+	for p.pos < len(p.input) && strings.ContainsRune(" \t\n\r", rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *queryParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("ecs: %s at offset %d in query %q", fmt.Sprintf(format, args...), p.pos, p.input)
+}
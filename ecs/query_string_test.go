@@ -0,0 +1,71 @@
+package ecs
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestQueryStringMatchesProgrammaticQueries(t *testing.T) {
+	w := NewWorld()
+	populateOverlap(w, 120, 30)
+
+	tests := []struct {
+		expr string
+		want *Query
+	}{
+		{"testPosition", With[testPosition](NewQuery(w))},
+		{"testPosition & testVelocity", With[testVelocity](With[testPosition](NewQuery(w)))},
+		{"testPosition & !testTag", Without[testTag](With[testPosition](NewQuery(w)))},
+		{"testVelocity | testTag", WithAny[testTag](WithAny[testVelocity](NewQuery(w)))},
+		{"ecs.testHealth & (testVelocity | testTag)",
+			WithAny[testTag](WithAny[testVelocity](With[testHealth](NewQuery(w))))},
+	}
+	for _, test := range tests {
+		got, err := w.QueryString(test.expr)
+		if err != nil {
+			t.Fatalf("QueryString(%q): %v", test.expr, err)
+		}
+		want := test.want.Build()
+		if !slices.Equal(sortedByIndex(got.Entities()), sortedByIndex(want.Entities())) {
+			t.Fatalf("QueryString(%q) = %d entities, want %d", test.expr, got.Size(), want.Size())
+		}
+	}
+}
+
+func TestQueryStringPredicates(t *testing.T) {
+	w := NewWorld()
+	populateOverlap(w, 120, 30)
+
+	// Negations below the top level are checked per entity
+	got, err := w.QueryString("testPosition & !(testVelocity & testTag)")
+	if err != nil {
+		t.Fatalf("QueryString: %v", err)
+	}
+	for _, entity := range got.Entities() {
+		if HasComponent[testVelocity](w, entity) && HasComponent[testTag](w, entity) {
+			t.Fatalf("%v has both testVelocity and testTag", entity)
+		}
+	}
+	if got.Size() != 120-len(With[testTag](With[testVelocity](NewQuery(w))).Build().Entities()) {
+		t.Fatalf("QueryString with nested negation = %d entities", got.Size())
+	}
+}
+
+func TestQueryStringErrors(t *testing.T) {
+	w := NewWorld()
+	populateTestWorld(w, 3)
+
+	for _, expr := range []string{
+		"",
+		"testPosition &",
+		"testPosition & (testVelocity",
+		"testPosition testVelocity",
+		"testPosition & Missing",
+		"!testPosition",
+		"testPosition $ testVelocity",
+	} {
+		if _, err := w.QueryString(expr); err == nil {
+			t.Fatalf("QueryString(%q) succeeded, want an error", expr)
+		}
+	}
+}
@@ -0,0 +1,758 @@
+package ecs
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type testHealth struct{ HP int }
+type testTag struct{}
+
+// bruteForceMatch lists the live entities holding every type in with and none in
+// without, in index order, by checking each entity's components one by one
+func bruteForceMatch(w *World, with, without []func(Entity) bool) []Entity {
+	matches := make([]Entity, 0)
+	w.entityManager.ForEachAlive(func(entity Entity) {
+		for _, has := range with {
+			if !has(entity) {
+				return
+			}
+		}
+		for _, has := range without {
+			if has(entity) {
+				return
+			}
+		}
+		matches = append(matches, entity)
+	})
+	return matches
+}
+
+// sortedByIndex returns a copy of entities ordered by index
+func sortedByIndex(entities []Entity) []Entity {
+	sorted := slices.Clone(entities)
+	slices.SortFunc(sorted, func(a, b Entity) int { return int(a.Index()) - int(b.Index()) })
+	return sorted
+}
+
+// populateOverlap creates n entities that all hold testPosition, with overlap percent
+// of them also holding testVelocity and testHealth, and every third one tagged
+func populateOverlap(w *World, n, overlap int) {
+	for i := 0; i < n; i++ {
+		entity := w.CreateEntity()
+		AddComponent(w, entity, testPosition{X: float64(i)})
+		if i%100 < overlap {
+			AddComponent(w, entity, testVelocity{X: 1})
+		}
+		if i%100 < overlap || i%2 == 0 {
+			AddComponent(w, entity, testHealth{HP: i})
+		}
+		if i%3 == 0 {
+			AddComponent(w, entity, testTag{})
+		}
+	}
+}
+
+func TestMultiWithQueryMatchesBruteForce(t *testing.T) {
+	w := NewWorld()
+	populateOverlap(w, 500, 40)
+	// Removals shuffle pool order, so intersection must not rely on it
+	for i := 0; i < 500; i += 7 {
+		RemoveComponent[testHealth](w, makeEntity(uint32(i), 0))
+	}
+
+	has := func(check func(*World, Entity) bool) func(Entity) bool {
+		return func(entity Entity) bool { return check(w, entity) }
+	}
+	position, velocity := has(HasComponent[testPosition]), has(HasComponent[testVelocity])
+	health, tag := has(HasComponent[testHealth]), has(HasComponent[testTag])
+
+	got := With[testHealth](With[testVelocity](With[testPosition](NewQuery(w)))).Build().Entities()
+	want := bruteForceMatch(w, []func(Entity) bool{position, velocity, health}, nil)
+	if !slices.Equal(sortedByIndex(got), want) {
+		t.Fatalf("With x3 = %d entities, want %d", len(got), len(want))
+	}
+
+	got = Without[testTag](With[testHealth](With[testPosition](NewQuery(w)))).Build().Entities()
+	want = bruteForceMatch(w, []func(Entity) bool{position, health}, []func(Entity) bool{tag})
+	if !slices.Equal(sortedByIndex(got), want) {
+		t.Fatalf("With x2 Without = %d entities, want %d", len(got), len(want))
+	}
+}
+
+func TestMultiWithQueryKeepsSmallestPoolOrder(t *testing.T) {
+	w := NewWorld()
+	populateOverlap(w, 100, 30)
+	velocities, _ := GetStorage[testVelocity](w.componentRegistry)
+
+	got := With[testVelocity](With[testPosition](NewQuery(w))).Build().Entities()
+	if !slices.Equal(got, velocities.Entities().Data()) {
+		t.Fatalf("result does not follow the smallest pool's dense order")
+	}
+}
+
+func TestQueryWithinRefinesResult(t *testing.T) {
+	w := NewWorld()
+	populateOverlap(w, 200, 50)
+	broad := With[testHealth](NewQuery(w)).Build()
+
+	has := func(check func(*World, Entity) bool) func(Entity) bool {
+		return func(entity Entity) bool { return check(w, entity) }
+	}
+	health, velocity := has(HasComponent[testHealth]), has(HasComponent[testVelocity])
+	tag := has(HasComponent[testTag])
+
+	got := With[testVelocity](w.QueryWithin(broad)).Build().Entities()
+	want := bruteForceMatch(w, []func(Entity) bool{health, velocity}, nil)
+	if !slices.Equal(sortedByIndex(got), want) {
+		t.Fatalf("QueryWithin + With = %d entities, want %d", len(got), len(want))
+	}
+
+	got = Without[testTag](w.QueryWithin(broad)).Build().Entities()
+	want = bruteForceMatch(w, []func(Entity) bool{health}, []func(Entity) bool{tag})
+	if !slices.Equal(sortedByIndex(got), want) {
+		t.Fatalf("QueryWithin + Without = %d entities, want %d", len(got), len(want))
+	}
+
+	// Candidates destroyed after the broad result was built are dropped
+	destroyed := broad.Entities()[0]
+	w.DestroyEntity(destroyed)
+	got = w.QueryWithin(broad).Build().Entities()
+	if len(got) != broad.Size()-1 || slices.Contains(got, destroyed) {
+		t.Fatalf("QueryWithin kept %d of %d candidates after destroying %s", len(got), broad.Size(), destroyed)
+	}
+}
+
+func BenchmarkQueryMultiWith(b *testing.B) {
+	for _, size := range []int{1000, 10000, 100000} {
+		for _, overlap := range []int{10, 50, 100} {
+			w := NewWorld()
+			populateOverlap(w, size, overlap)
+			query := With[testHealth](With[testVelocity](With[testPosition](NewQuery(w))))
+			b.Run(fmt.Sprintf("size=%d/overlap=%d%%", size, overlap), func(b *testing.B) {
+				var result *QueryResult
+				for i := 0; i < b.N; i++ {
+					result = query.BuildInto(result)
+				}
+			})
+		}
+	}
+}
+
+type testName struct{ Value string }
+
+func TestIter1Opt2OptionalComponents(t *testing.T) {
+	w := NewWorld()
+	type layout struct{ position, velocity bool }
+	layouts := map[Entity]layout{}
+	for i, l := range []layout{{false, false}, {true, false}, {false, true}, {true, true}} {
+		entity := w.CreateEntity()
+		AddComponent(w, entity, testName{Value: fmt.Sprint(i)})
+		if l.position {
+			AddComponent(w, entity, testPosition{X: float64(i)})
+		}
+		if l.velocity {
+			AddComponent(w, entity, testVelocity{X: float64(i)})
+		}
+		layouts[entity] = l
+	}
+	// Entities without the required component are not visited
+	AddComponent(w, w.CreateEntity(), testPosition{})
+
+	visited := 0
+	Iter1Opt2[testName, testPosition, testVelocity](w).ForEach(
+		func(entity Entity, name *testName, pos *testPosition, vel *testVelocity) {
+			visited++
+			l, exists := layouts[entity]
+			if !exists {
+				t.Fatalf("visited %v, which has no name", entity)
+			}
+			if (pos != nil) != l.position || (vel != nil) != l.velocity {
+				t.Fatalf("entity %s: position %v, velocity %v, want present %v, %v", name.Value, pos, vel, l.position, l.velocity)
+			}
+			if pos != nil && fmt.Sprint(pos.X) != name.Value {
+				t.Fatalf("entity %s got another entity's position %v", name.Value, pos)
+			}
+		})
+	if visited != 4 {
+		t.Fatalf("visited %d entities, want 4", visited)
+	}
+}
+
+func TestViewBuildErasedFromRuntimeIDs(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 6)
+	AddComponent(w, entities[2], testTag{})
+
+	// Assemble the archetype from names as a modding layer would
+	var include []ComponentID
+	for _, name := range []string{"testPosition", "testVelocity"} {
+		id, ok := w.componentRegistry.GetComponentIDByName(name)
+		if !ok {
+			t.Fatalf("component %q not registered", name)
+		}
+		include = append(include, id)
+	}
+	tagID, _ := w.componentRegistry.GetComponentIDByName("testTag")
+
+	result := w.View().Include(include...).Exclude(tagID).BuildErased()
+	if got, want := sortedByIndex(result.Entities()), []Entity{entities[0], entities[4]}; !slices.Equal(got, want) {
+		t.Fatalf("BuildErased matched %v, want %v", got, want)
+	}
+
+	value, ok := result.Get(entities[4], include[0])
+	if position, isPosition := value.(testPosition); !ok || !isPosition || position.X != 4 {
+		t.Fatalf("Get position = %v, %v", value, ok)
+	}
+	if _, ok := result.Get(entities[1], include[1]); ok {
+		t.Fatalf("Get found a velocity on an entity without one")
+	}
+
+	visited := 0
+	result.ForEachValues(func(entity Entity, values []any) {
+		visited++
+		if len(values) != 2 {
+			t.Fatalf("ForEachValues passed %d values, want 2", len(values))
+		}
+		position, _ := values[0].(testPosition)
+		velocity, _ := values[1].(testVelocity)
+		if position.X != float64(entity.Index()) || velocity.X != 1 {
+			t.Fatalf("values for %s = %v", entity, values)
+		}
+	})
+	if visited != 2 {
+		t.Fatalf("ForEachValues visited %d entities, want 2", visited)
+	}
+}
+
+func TestDeterministicQueryOrder(t *testing.T) {
+	// Both runs destroy the same entities, in a different incidental order
+	run := func(reverse bool) []Entity {
+		w := NewWorld()
+		w.SetDeterministic(true)
+		entities := populateTestWorld(w, 40)
+		var doomed []Entity
+		for i, e := range entities {
+			if i%3 == 0 {
+				doomed = append(doomed, e)
+			}
+		}
+		if reverse {
+			slices.Reverse(doomed)
+		}
+		for _, e := range doomed {
+			w.DestroyEntity(e)
+		}
+		return With[testVelocity](With[testPosition](NewQuery(w))).Build().Entities()
+	}
+
+	first, second := run(false), run(true)
+	if !slices.Equal(first, second) {
+		t.Fatalf("deterministic runs differ:\n%v\n%v", first, second)
+	}
+	if !slices.Equal(first, sortedByIndex(first)) {
+		t.Fatalf("deterministic result is not in index order: %v", first)
+	}
+}
+
+func TestForEachIndexedMatchesResultOrder(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 20)
+	// Removals reorder the pools, so result order differs from creation order
+	for i := 0; i < len(entities); i += 3 {
+		RemoveComponent[testPosition](w, entities[i])
+	}
+
+	it := Iter1[testPosition](w)
+	want := it.result.Entities()
+	next := 0
+	it.ForEachIndexed(func(i int, entity Entity, p *testPosition) {
+		if i != next || entity != want[i] {
+			t.Fatalf("index %d for %s, want %d for %s", i, entity, next, want[next])
+		}
+		next++
+	})
+	if next != len(want) {
+		t.Fatalf("ForEachIndexed visited %d entities, want %d", next, len(want))
+	}
+
+	it2 := Iter2[testPosition, testVelocity](w)
+	want = it2.result.Entities()
+	next = 0
+	it2.ForEachIndexed(func(i int, entity Entity, p *testPosition, v *testVelocity) {
+		if i != next || entity != want[i] {
+			t.Fatalf("Iter2 index %d for %s, want %d for %s", i, entity, next, want[next])
+		}
+		next++
+	})
+	if next != len(want) || next == 0 {
+		t.Fatalf("Iter2 ForEachIndexed visited %d entities, want %d", next, len(want))
+	}
+}
+
+func TestIterDenseFastPath(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 20)
+	// Velocity is the smaller pool, so it drives the result and is read by position
+	it := Iter2[testVelocity, testPosition](w)
+	if cursor := newDenseCursor(it.result, it.component1Pool, it.modCount); !cursor.active {
+		t.Fatalf("fast path inactive for a result driven by the first pool")
+	}
+
+	visited := 0
+	it.ForEach(func(entity Entity, v *testVelocity, p *testPosition) {
+		visited++
+		if v != it.component1Pool.GetPtr(entity) || p.X != float64(entity.Index()) {
+			t.Fatalf("%s got velocity %p and position %v, want its own", entity, v, p)
+		}
+	})
+	if visited != 10 {
+		t.Fatalf("visited %d entities, want 10", visited)
+	}
+
+	// A pool changed after the iterator was built falls back to sparse lookups
+	it = Iter2[testVelocity, testPosition](w)
+	RemoveComponent[testVelocity](w, entities[0])
+	visited = 0
+	it.ForEach(func(entity Entity, v *testVelocity, _ *testPosition) {
+		visited++
+		if entity == entities[0] || v != it.component1Pool.GetPtr(entity) {
+			t.Fatalf("%s got a stale component after the pool changed", entity)
+		}
+	})
+	if visited != 9 {
+		t.Fatalf("visited %d entities after removal, want 9", visited)
+	}
+}
+
+func BenchmarkIter2DenseFastPath(b *testing.B) {
+	w := NewWorld()
+	populateOverlap(w, 100000, 50)
+	it := Iter2[testVelocity, testPosition](w)
+
+	b.Run("dense", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			it.ForEach(func(_ Entity, v *testVelocity, p *testPosition) {
+				p.X += v.X
+			})
+		}
+	})
+	// Both components through sparse lookups, as before the fast path
+	b.Run("sparse", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, entity := range it.result.entities {
+				v, p := it.component1Pool.GetPtr(entity), it.component2Pool.GetPtr(entity)
+				if v != nil && p != nil {
+					p.X += v.X
+				}
+			}
+		}
+	})
+}
+
+func TestRangeStopsOnBreak(t *testing.T) {
+	w := NewWorld()
+	populateTestWorld(w, 10)
+	for _, e := range Iter1[testPosition](w).result.Entities() {
+		AddComponent(w, e, testHealth{HP: 1})
+	}
+
+	visited := 0
+	for range Range1[testPosition](w) {
+		visited++
+		if visited == 3 {
+			break
+		}
+	}
+	if visited != 3 {
+		t.Fatalf("Range1 ran %d times after break at 3", visited)
+	}
+
+	visited = 0
+	for entity, row := range Range2[testPosition, testVelocity](w) {
+		if entity.Index()%2 != 0 || row.C2.X != 1 {
+			t.Fatalf("Range2 yielded %s with %v", entity, row.C2)
+		}
+		visited++
+		break
+	}
+	if visited != 1 {
+		t.Fatalf("Range2 ran %d times after an immediate break", visited)
+	}
+
+	// continue skips to the next entity and writes through the rows persist
+	visited = 0
+	for entity, row := range Range3[testPosition, testVelocity, testHealth](w) {
+		visited++
+		if entity.Index() == 0 {
+			continue
+		}
+		row.C3.HP = 0
+	}
+	if visited != 5 {
+		t.Fatalf("Range3 visited %d entities, want 5", visited)
+	}
+	for entity, h := range Range1[testHealth](w) {
+		moving := entity.Index()%2 == 0 && entity.Index() != 0
+		if moving != (h.HP == 0) {
+			t.Fatalf("%s health = %d after Range3", entity, h.HP)
+		}
+	}
+}
+
+func TestQueryResultFilterByComponentValue(t *testing.T) {
+	w := NewWorld()
+	populateOverlap(w, 40, 50)
+	result := With[testHealth](NewQuery(w)).Build()
+
+	low := result.Filter(func(entity Entity) bool {
+		h, _ := GetComponent[testHealth](w, entity)
+		return h.HP < 10
+	})
+	var want []Entity
+	for _, entity := range result.Entities() {
+		if h, _ := GetComponent[testHealth](w, entity); h.HP < 10 {
+			want = append(want, entity)
+		}
+	}
+	if !slices.Equal(low.Entities(), want) || len(want) == 0 || len(want) == result.Size() {
+		t.Fatalf("Filter kept %v, want %v", low.Entities(), want)
+	}
+
+	// The original result is untouched and the filtered one chains with ForEach
+	if result.Size() <= low.Size() {
+		t.Fatalf("Filter changed the source result")
+	}
+	visited := 0
+	low.ForEach(func(Entity) { visited++ })
+	if visited != len(want) {
+		t.Fatalf("ForEach on the filtered result visited %d, want %d", visited, len(want))
+	}
+}
+
+func TestCollectSkipsEntitiesWithoutComponent(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 6)
+	// A result of mixed entities: every position, only some of them moving
+	result := With[testPosition](NewQuery(w)).Build()
+
+	pairs := CollectPairs[testVelocity](result)
+	values := Collect[testVelocity](result)
+	if len(pairs) != 3 || len(values) != 3 {
+		t.Fatalf("collected %d pairs and %d values, want 3", len(pairs), len(values))
+	}
+	for i, pair := range pairs {
+		if pair.Entity.Index()%2 != 0 || pair.Value != values[i] {
+			t.Fatalf("pair %d = %+v, value %v", i, pair, values[i])
+		}
+	}
+
+	positions := CollectPairs[testPosition](result)
+	for i, pair := range positions {
+		if pair.Entity != result.Entities()[i] || pair.Value.X != float64(pair.Entity.Index()) {
+			t.Fatalf("pair %d = %+v does not follow result order", i, pair)
+		}
+	}
+	if len(positions) != len(entities) {
+		t.Fatalf("collected %d positions, want %d", len(positions), len(entities))
+	}
+	if got := Collect[testHealth](result); got != nil {
+		t.Fatalf("Collect of an unregistered type = %v, want nil", got)
+	}
+}
+
+func TestForEachWhileStopsAtFalse(t *testing.T) {
+	w := NewWorld()
+	populateTestWorld(w, 10)
+
+	it := Iter1[testPosition](w)
+	order := it.result.Entities()
+	var visited []Entity
+	it.ForEachWhile(func(entity Entity, p *testPosition) bool {
+		visited = append(visited, entity)
+		return p.X != 4 // Stop at the entity created fifth
+	})
+	stop := slices.IndexFunc(order, func(e Entity) bool { return e.Index() == 4 })
+	if !slices.Equal(visited, order[:stop+1]) {
+		t.Fatalf("ForEachWhile visited %v, want %v", visited, order[:stop+1])
+	}
+
+	// Returning true throughout visits every entity
+	count := 0
+	Iter2[testPosition, testVelocity](w).ForEachWhile(func(Entity, *testPosition, *testVelocity) bool {
+		count++
+		return true
+	})
+	if count != 5 {
+		t.Fatalf("ForEachWhile returning true visited %d, want 5", count)
+	}
+
+	count = 0
+	Iter2[testPosition, testVelocity](w).ForEachWhile(func(Entity, *testPosition, *testVelocity) bool {
+		count++
+		return false
+	})
+	if count != 1 {
+		t.Fatalf("ForEachWhile returning false visited %d, want 1", count)
+	}
+}
+
+func TestViewEachWithExclusion(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 8)
+	for _, e := range entities[:4] {
+		AddComponent(w, e, testTag{})
+		AddComponent(w, e, testHealth{HP: 1})
+	}
+	AddComponent(w, entities[6], testHealth{HP: 1})
+	tagID, _ := GetComponentID[testTag](w.componentRegistry)
+	positionID, _ := GetComponentID[testPosition](w.componentRegistry)
+
+	var visited []Entity
+	ViewEach2(w.View().Include(positionID).Exclude(tagID), func(e Entity, p *testPosition, v *testVelocity) {
+		visited = append(visited, e)
+		p.X += v.X
+	})
+	// Of the moving entities 0, 2, 4 and 6, the first two are tagged
+	if got := sortedByIndex(visited); !slices.Equal(got, []Entity{entities[4], entities[6]}) {
+		t.Fatalf("ViewEach2 visited %v, want %v", got, []Entity{entities[4], entities[6]})
+	}
+	if p, _ := GetComponent[testPosition](w, entities[6]); p.X != 7 {
+		t.Fatalf("write through ViewEach2 pointer lost, X = %v", p.X)
+	}
+
+	visited = nil
+	ViewEach1(w.View().Include(positionID).Exclude(tagID), func(e Entity, p *testPosition) {
+		visited = append(visited, e)
+	})
+	if len(visited) != 4 {
+		t.Fatalf("ViewEach1 visited %d entities, want 4", len(visited))
+	}
+
+	visited = nil
+	ViewEach3(w.View().Include(positionID).Exclude(tagID), func(e Entity, _ *testPosition, _ *testVelocity, h *testHealth) {
+		visited = append(visited, e)
+	})
+	if !slices.Equal(visited, []Entity{entities[6]}) {
+		t.Fatalf("ViewEach3 visited %v, want %v", visited, entities[6])
+	}
+
+	// An unregistered component type matches nothing
+	ViewEach1(w.View().Include(positionID), func(Entity, *testName) {
+		t.Fatalf("ViewEach1 visited an entity for an unregistered type")
+	})
+}
+
+func TestExactQueryRejectsExtraComponents(t *testing.T) {
+	w := NewWorld()
+	pure := w.CreateEntity()
+	AddComponent(w, pure, testPosition{})
+	AddComponent(w, pure, testVelocity{})
+	extra := w.CreateEntity()
+	AddComponent(w, extra, testPosition{})
+	AddComponent(w, extra, testVelocity{})
+	AddComponent(w, extra, testHealth{})
+	partial := w.CreateEntity()
+	AddComponent(w, partial, testPosition{})
+
+	if got := With[testVelocity](With[testPosition](NewQuery(w))).Exact().Build().Entities(); !slices.Equal(got, []Entity{pure}) {
+		t.Fatalf("Exact {position, velocity} = %v, want only %s", got, pure)
+	}
+	if got := With[testVelocity](With[testPosition](NewQuery(w))).Build().Size(); got != 2 {
+		t.Fatalf("without Exact matched %d, want 2", got)
+	}
+
+	// WithAny types count as listed
+	q := With[testPosition](NewQuery(w))
+	WithAny[testVelocity](q)
+	WithAny[testHealth](q)
+	if got := sortedByIndex(q.Exact().Build().Entities()); !slices.Equal(got, []Entity{pure, extra}) {
+		t.Fatalf("Exact with WithAny = %v, want %s and %s", got, pure, extra)
+	}
+
+	positionID, _ := GetComponentID[testPosition](w.componentRegistry)
+	velocityID, _ := GetComponentID[testVelocity](w.componentRegistry)
+	if got := w.View().Include(positionID, velocityID).Exact().Build().Entities(); !slices.Equal(got, []Entity{pure}) {
+		t.Fatalf("ViewBuilder Exact = %v, want only %s", got, pure)
+	}
+
+	// Losing the extra component makes the entity match
+	RemoveComponent[testHealth](w, extra)
+	if got := With[testVelocity](With[testPosition](NewQuery(w))).Exact().Build().Size(); got != 2 {
+		t.Fatalf("Exact after removing the extra component matched %d, want 2", got)
+	}
+}
+
+func TestBuildIntoReusesResult(t *testing.T) {
+	w := NewWorld()
+	populateOverlap(w, 200, 30)
+	query := With[testVelocity](With[testPosition](NewQuery(w)))
+
+	result := query.BuildInto(nil)
+	if !slices.Equal(result.Entities(), query.Build().Entities()) {
+		t.Fatalf("BuildInto matched %d entities, Build %d", result.Size(), query.Build().Size())
+	}
+	backing := &result.entities[0]
+	if again := query.BuildInto(result); again != result || &again.entities[0] != backing {
+		t.Fatalf("BuildInto allocated a new result or slice for the same query")
+	}
+	if allocs := testing.AllocsPerRun(10, func() { query.BuildInto(result) }); allocs != 0 {
+		t.Fatalf("rebuilding into a warm result allocated %v times", allocs)
+	}
+
+	// A released result comes back with its old entities gone
+	w.ReleaseResult(result)
+	tagged := With[testTag](NewQuery(w)).BuildInto(nil)
+	if !slices.Equal(tagged.Entities(), With[testTag](NewQuery(w)).Build().Entities()) {
+		t.Fatalf("pooled result kept stale entities")
+	}
+	w.ReleaseResult(nil)
+}
+
+func BenchmarkBuildVsBuildInto(b *testing.B) {
+	w := NewWorld()
+	populateOverlap(w, 10000, 50)
+	query := With[testVelocity](With[testPosition](NewQuery(w)))
+
+	b.Run("Build", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			query.Build()
+		}
+	})
+	b.Run("BuildInto", func(b *testing.B) {
+		b.ReportAllocs()
+		var result *QueryResult
+		for i := 0; i < b.N; i++ {
+			result = query.BuildInto(result)
+		}
+	})
+	b.Run("BuildInto/released", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			w.ReleaseResult(query.BuildInto(nil))
+		}
+	})
+}
+
+func TestBuildStrictRejectsUnregisteredTypes(t *testing.T) {
+	w := NewWorld()
+	populateTestWorld(w, 4)
+
+	// testName was never added, so the query itself is all that registered it
+	result, err := With[testName](With[testPosition](NewQuery(w))).BuildStrict()
+	if err == nil || result != nil || !strings.Contains(err.Error(), "ecs.testName") {
+		t.Fatalf("BuildStrict = %v, %v, want an error naming ecs.testName", result, err)
+	}
+	if _, err := Without[testHealth](With[testPosition](NewQuery(w))).BuildStrict(); err == nil {
+		t.Fatalf("BuildStrict accepted an unregistered exclude type")
+	}
+
+	// Plain Build still treats the types as held by no one
+	if n := With[testName](NewQuery(w)).Build().Size(); n != 0 {
+		t.Fatalf("Build matched %d entities for an unregistered type", n)
+	}
+
+	// Once the type is really used the strict build succeeds
+	AddComponent(w, makeEntity(1, 0), testName{Value: "b"})
+	result, err = With[testName](With[testPosition](NewQuery(w))).BuildStrict()
+	if err != nil || result.Size() != 1 {
+		t.Fatalf("BuildStrict after registering = %v, %v", result, err)
+	}
+}
+
+func TestWithAnyOrderIsReproducible(t *testing.T) {
+	build := func() (*World, []Entity) {
+		w := NewWorld()
+		populateOverlap(w, 300, 25)
+		// Removals reshuffle the pools' dense order
+		for i := 0; i < 300; i += 11 {
+			RemoveComponent[testHealth](w, makeEntity(uint32(i), 0))
+			RemoveComponent[testVelocity](w, makeEntity(uint32(i+5), 0))
+		}
+		// Listed against ID order, which must not matter
+		q := WithAny[testVelocity](WithAny[testHealth](WithAny[testTag](NewQuery(w))))
+		return w, q.Build().Entities()
+	}
+	w, got := build()
+
+	// Storages in ascending ID order, each in dense order, skipping entities seen before
+	var want []Entity
+	for _, entities := range [][]Entity{
+		mustStorage[testVelocity](t, w).Entities().Data(),
+		mustStorage[testHealth](t, w).Entities().Data(),
+		mustStorage[testTag](t, w).Entities().Data(),
+	} {
+		for _, entity := range entities {
+			if !slices.Contains(want, entity) {
+				want = append(want, entity)
+			}
+		}
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("WithAny result isn't in ID then dense order")
+	}
+
+	for range 5 {
+		q := WithAny[testTag](WithAny[testHealth](WithAny[testVelocity](NewQuery(w))))
+		if !slices.Equal(q.Build().Entities(), got) {
+			t.Fatalf("repeated WithAny builds differ")
+		}
+	}
+	// A fresh world given the same operations, as in another run, agrees exactly
+	if _, again := build(); !slices.Equal(again, got) {
+		t.Fatalf("WithAny result differs between identical worlds")
+	}
+}
+
+// mustStorage returns T's pool, failing the test if T isn't registered
+func mustStorage[T any](t *testing.T, w *World) *ComponentPool[T] {
+	t.Helper()
+	pool, exists := GetStorage[T](w.componentRegistry)
+	if !exists {
+		t.Fatalf("component type not registered")
+	}
+	return pool
+}
+
+func TestExplicitRegistrationEndsQueryOnly(t *testing.T) {
+	w := NewWorld()
+	populateTestWorld(w, 2)
+
+	// Queries touch the types first, then they are registered explicitly
+	With[testName](NewQuery(w)).Build()
+	With[testHealth](NewQuery(w)).Build()
+	id, _ := GetComponentID[testName](w.componentRegistry)
+	if err := RegisterAs[testName](w, id); err != nil {
+		t.Fatal(err)
+	}
+	Register[testHealth](w.componentRegistry)
+
+	if _, err := With[testHealth](With[testName](NewQuery(w))).BuildStrict(); err != nil {
+		t.Fatalf("BuildStrict after explicit registration: %v", err)
+	}
+}
+
+func TestConcurrentRegisterNotLeftQueryOnly(t *testing.T) {
+	for range 50 {
+		w := NewWorld()
+		w.SetConcurrentRegistration(true)
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			With[testName](NewQuery(w))
+		}()
+		go func() {
+			defer wg.Done()
+			Register[testName](w.componentRegistry)
+		}()
+		wg.Wait()
+		if _, err := With[testName](NewQuery(w)).BuildStrict(); err != nil {
+			t.Fatalf("explicitly registered type left query-only: %v", err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package ecs
+
+// ReadOnlyWorld is a view of a World that only exposes read operations
+// Mutation is prevented statically: the view has no methods that add, remove or
+// destroy anything, and the read helpers hand out component copies
+type ReadOnlyWorld struct {
+	world   *World
+	version uint64
+	frozen  bool // Reads panic once the world changed structurally after Freeze
+}
+
+// Freeze returns a read-only view of the world's current state, e.g. for rendering
+// The view shares storage with the world instead of copying it; any structural
+// change to the world afterwards (create/destroy, add/remove component) makes
+// the view stale and reads through it panic instead of returning mixed state
+// Reads never register component types or fill the query cache, so several
+// goroutines can read the view at once while the world is left alone
+func (w *World) Freeze() *ReadOnlyWorld {
+	return &ReadOnlyWorld{
+		world:   w,
+		version: w.Version(),
+		frozen:  true,
+	}
+}
+
+// ReadOnly returns a live read-only view of the world, e.g. to hand to systems that
+// must only read; unlike Freeze it follows the world's changes and never goes stale
+func (w *World) ReadOnly() *ReadOnlyWorld {
+	return &ReadOnlyWorld{world: w}
+}
+
+// Stale checks if the world changed structurally since the view was frozen
+func (rw *ReadOnlyWorld) Stale() bool {
+	return rw.frozen && rw.world.Version() != rw.version
+}
+
+// checkVersion panics if the view no longer reflects the world
+func (rw *ReadOnlyWorld) checkVersion() {
+	if rw.Stale() {
+		panic("ecs: world structurally modified after Freeze")
+	}
+}
+
+// IsValidEntity checks if an entity is valid
+func (rw *ReadOnlyWorld) IsValidEntity(entity Entity) bool {
+	rw.checkVersion()
+	return rw.world.IsValidEntity(entity)
+}
+
+// Stats returns statistics about the world
+func (rw *ReadOnlyWorld) Stats() WorldStats {
+	rw.checkVersion()
+	return rw.world.Stats()
+}
+
+// Query creates a new query over the world that leaves the world untouched: component
+// types are looked up instead of registered, so a type nothing registered yet matches
+// no entities, and results bypass the query cache
+func (rw *ReadOnlyWorld) Query() *Query {
+	rw.checkVersion()
+	q := rw.world.Query()
+	q.readOnly = true
+	return q
+}
+
+// QueryString parses and executes a query expression, bypassing the query cache
+func (rw *ReadOnlyWorld) QueryString(expr string) (*QueryResult, error) {
+	rw.checkVersion()
+	q, err := rw.world.ParseQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	q.readOnly = true
+	return q.build(), nil
+}
+
+// ReadComponent retrieves a copy of an entity's component through a read-only view
+func ReadComponent[T any](rw *ReadOnlyWorld, entity Entity) (T, bool) {
+	rw.checkVersion()
+	return GetComponent[T](rw.world, entity)
+}
+
+// ReadHasComponent checks if an entity has a component through a read-only view
+func ReadHasComponent[T any](rw *ReadOnlyWorld, entity Entity) bool {
+	rw.checkVersion()
+	return HasComponent[T](rw.world, entity)
+}
+
+// ReadEach1 iterates entities with component T1, passing component copies
+func ReadEach1[T1 any](rw *ReadOnlyWorld, fn func(Entity, T1)) {
+	rw.checkVersion()
+	pool1, exists := GetStorage[T1](rw.world.componentRegistry)
+	if !exists {
+		return
+	}
+	for _, entity := range With[T1](rw.Query()).build().entities {
+		fn(entity, *pool1.GetPtr(entity))
+	}
+}
+
+// ReadEach2 iterates entities with components T1 and T2, passing component copies
+func ReadEach2[T1, T2 any](rw *ReadOnlyWorld, fn func(Entity, T1, T2)) {
+	rw.checkVersion()
+	pool1, exists1 := GetStorage[T1](rw.world.componentRegistry)
+	pool2, exists2 := GetStorage[T2](rw.world.componentRegistry)
+	if !exists1 || !exists2 {
+		return
+	}
+	for _, entity := range With[T2](With[T1](rw.Query())).build().entities {
+		fn(entity, *pool1.GetPtr(entity), *pool2.GetPtr(entity))
+	}
+}
+
+// ReadEach3 iterates entities with components T1, T2 and T3, passing component copies
+func ReadEach3[T1, T2, T3 any](rw *ReadOnlyWorld, fn func(Entity, T1, T2, T3)) {
+	rw.checkVersion()
+	pool1, exists1 := GetStorage[T1](rw.world.componentRegistry)
+	pool2, exists2 := GetStorage[T2](rw.world.componentRegistry)
+	pool3, exists3 := GetStorage[T3](rw.world.componentRegistry)
+	if !exists1 || !exists2 || !exists3 {
+		return
+	}
+	for _, entity := range With[T3](With[T2](With[T1](rw.Query()))).build().entities {
+		fn(entity, *pool1.GetPtr(entity), *pool2.GetPtr(entity), *pool3.GetPtr(entity))
+	}
+}
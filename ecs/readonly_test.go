@@ -0,0 +1,186 @@
+package ecs
+
+import (
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+)
+
+type testUnused struct{}
+
+func TestFrozenViewReads(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 10)
+	view := w.Freeze()
+
+	if got := With[testPosition](view.Query()).Build().Size(); got != 10 {
+		t.Fatalf("query through frozen view = %d entities, want 10", got)
+	}
+	if pos, ok := ReadComponent[testPosition](view, entities[3]); !ok || pos.X != 3 {
+		t.Fatalf("ReadComponent = %v, %v, want X 3", pos, ok)
+	}
+
+	count := 0
+	ReadEach2(view, func(entity Entity, pos testPosition, vel testVelocity) {
+		count++
+	})
+	if count != 5 {
+		t.Fatalf("ReadEach2 visited %d entities, want 5", count)
+	}
+	if view.Stale() {
+		t.Fatalf("reads made the frozen view stale")
+	}
+}
+
+func TestFrozenViewUnregisteredTypes(t *testing.T) {
+	w := NewWorld()
+	populateTestWorld(w, 5)
+	version := w.Version()
+	view := w.Freeze()
+
+	if got := With[testUnused](view.Query()).Build().Size(); got != 0 {
+		t.Fatalf("query on unregistered type = %d entities, want 0", got)
+	}
+	if got := Without[testUnused](With[testPosition](view.Query())).Build().Size(); got != 5 {
+		t.Fatalf("Without unregistered type = %d entities, want 5", got)
+	}
+	if got := view.Query().Filter(Or(Has[testPosition](), Has[testUnused]())).Build().Size(); got != 5 {
+		t.Fatalf("Filter with unregistered type = %d entities, want 5", got)
+	}
+	ReadEach1(view, func(Entity, testUnused) {
+		t.Fatalf("ReadEach1 visited an entity for an unregistered type")
+	})
+	ReadEach3(view, func(Entity, testPosition, testVelocity, testUnused) {
+		t.Fatalf("ReadEach3 visited an entity for an unregistered type")
+	})
+
+	if _, exists := GetComponentID[testUnused](w.componentRegistry); exists {
+		t.Fatalf("reading through the view registered a component type")
+	}
+	if w.Version() != version || view.Stale() {
+		t.Fatalf("reading through the view changed the world's version")
+	}
+}
+
+func TestFrozenViewBypassesQueryCache(t *testing.T) {
+	w := NewWorld()
+	populateTestWorld(w, 5)
+	w.EnableQueryCache(true)
+	view := w.Freeze()
+
+	With[testPosition](view.Query()).Build()
+	if _, err := view.QueryString("testPosition"); err != nil {
+		t.Fatalf("QueryString: %v", err)
+	}
+	if len(w.queryCache) != 0 {
+		t.Fatalf("query cache holds %d entries after read-only queries, want 0", len(w.queryCache))
+	}
+}
+
+func TestFrozenViewPanicsAfterStructuralChange(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 5)
+	view := w.Freeze()
+
+	// Component values can change without invalidating the view
+	GetComponentPtr[testPosition](w, entities[0]).X = 100
+	if pos, _ := ReadComponent[testPosition](view, entities[0]); pos.X != 100 {
+		t.Fatalf("ReadComponent = %v, want the updated value", pos)
+	}
+
+	w.DestroyEntity(entities[1])
+	if !view.Stale() {
+		t.Fatalf("view not stale after destroying an entity")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("read through a stale view did not panic")
+		}
+	}()
+	ReadEach1(view, func(Entity, testPosition) {})
+}
+
+func TestLiveViewFollowsWorld(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 5)
+	view := w.ReadOnly()
+
+	w.DestroyEntity(entities[0])
+	if view.Stale() || view.IsValidEntity(entities[0]) {
+		t.Fatalf("live view did not follow the world")
+	}
+	if got := With[testPosition](view.Query()).Build().Size(); got != 4 {
+		t.Fatalf("query through live view = %d entities, want 4", got)
+	}
+}
+
+func TestFrozenViewConcurrentReads(t *testing.T) {
+	w := NewWorld()
+	populateTestWorld(w, 100)
+	view := w.Freeze()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sum := 0.0
+			ReadEach1(view, func(_ Entity, pos testPosition) { sum += pos.X })
+			With[testUnused](view.Query()).Build()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestReadOnlyWorldHasNoMutators(t *testing.T) {
+	view := reflect.TypeFor[*ReadOnlyWorld]()
+	for _, name := range []string{"CreateEntity", "DestroyEntity", "DisableEntity", "Update", "Clear"} {
+		if _, exists := view.MethodByName(name); exists {
+			t.Fatalf("ReadOnlyWorld exposes mutator %s", name)
+		}
+	}
+}
+
+func TestReadOnlyWorldReads(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 6)
+	rw := w.ReadOnly()
+
+	if p, ok := ReadComponent[testPosition](rw, entities[3]); !ok || p.X != 3 {
+		t.Fatalf("ReadComponent = %v, %v", p, ok)
+	}
+	if ReadHasComponent[testVelocity](rw, entities[3]) || !ReadHasComponent[testVelocity](rw, entities[2]) {
+		t.Fatalf("ReadHasComponent disagrees with the world")
+	}
+	want := []Entity{entities[0], entities[2], entities[4]}
+	if got := sortedByIndex(With[testVelocity](rw.Query()).Build().Entities()); !slices.Equal(got, want) {
+		t.Fatalf("Query matched %v, want %v", got, want)
+	}
+	visited := 0
+	ReadEach2(rw, func(e Entity, p testPosition, v testVelocity) {
+		visited++
+		if p.X != float64(e.Index()) || v.X != 1 {
+			t.Fatalf("ReadEach2 passed %v, %v for %s", p, v, e)
+		}
+	})
+	if visited != 3 || rw.Stats().EntityCount != 6 {
+		t.Fatalf("ReadEach2 visited %d entities, stats %+v", visited, rw.Stats())
+	}
+
+	// Reading an unregistered type matches nothing and registers nothing
+	ReadEach1(rw, func(Entity, testName) { t.Fatalf("visited an unregistered type") })
+	if _, exists := GetStorage[testName](w.componentRegistry); exists {
+		t.Fatalf("reading through the view registered a type")
+	}
+
+	// A live view follows the world, a frozen one goes stale
+	frozen := w.Freeze()
+	w.DestroyEntity(entities[0])
+	if rw.Stale() || rw.IsValidEntity(entities[0]) || !frozen.Stale() {
+		t.Fatalf("live view stale %v, frozen view stale %v", rw.Stale(), frozen.Stale())
+	}
+	expectPanic(t, "ecs: world structurally modified after Freeze", func() {
+		ReadComponent[testPosition](frozen, entities[1])
+	})
+}
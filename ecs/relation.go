@@ -0,0 +1,123 @@
+package ecs
+
+import "reflect"
+
+// relationStore holds the links of one relation type as adjacency sets in both directions
+type relationStore struct {
+	from map[Entity]*SparseSet // Targets of each source
+	to   map[Entity]*SparseSet // Sources of each target
+}
+
+// newRelationStore creates an empty relation store
+func newRelationStore() *relationStore {
+	return &relationStore{
+		from: make(map[Entity]*SparseSet),
+		to:   make(map[Entity]*SparseSet),
+	}
+}
+
+// link adds entity to the adjacency set of key, creating it on first use
+func link(sets map[Entity]*SparseSet, key, entity Entity) bool {
+	set, exists := sets[key]
+	if !exists {
+		set = NewSparseSet()
+		sets[key] = set
+	}
+	return set.Insert(entity)
+}
+
+// unlink removes entity from the adjacency set of key, dropping the set once empty
+func unlink(sets map[Entity]*SparseSet, key, entity Entity) bool {
+	set, exists := sets[key]
+	if !exists || !set.Remove(entity) {
+		return false
+	}
+	if set.Empty() {
+		delete(sets, key)
+	}
+	return true
+}
+
+// removeEntity drops every link of the relation that starts or ends at entity
+func (rs *relationStore) removeEntity(entity Entity) {
+	if targets, exists := rs.from[entity]; exists {
+		for _, target := range targets.Data() {
+			unlink(rs.to, target, entity)
+		}
+		delete(rs.from, entity)
+	}
+	if sources, exists := rs.to[entity]; exists {
+		for _, source := range sources.Data() {
+			unlink(rs.from, source, entity)
+		}
+		delete(rs.to, entity)
+	}
+}
+
+// relationsOf returns the store for relation type R, creating it if create is set
+// and otherwise returning nil when R was never used
+func relationsOf[R any](w *World, create bool) *relationStore {
+	relationType := reflect.TypeFor[R]()
+	store, exists := w.relations[relationType]
+	if !exists && create {
+		store = newRelationStore()
+		w.relations[relationType] = store
+	}
+	return store
+}
+
+// AddRelation links a to b with the directed relation R, a marker type such as
+// struct{} Likes; returns false if either entity is invalid or the link exists
+// Links are removed when either entity is destroyed
+func AddRelation[R any](w *World, a, b Entity) bool {
+	if !w.entityManager.IsValid(a) || !w.entityManager.IsValid(b) {
+		w.logf(LogLevelWarn, "AddRelation[%s] between %s and %s ignored: invalid entity", reflect.TypeFor[R](), a, b)
+		return false
+	}
+
+	store := relationsOf[R](w, true)
+	if !link(store.from, a, b) {
+		return false
+	}
+	link(store.to, b, a)
+	return true
+}
+
+// RemoveRelation removes the R link from a to b
+// Returns false if there was no such link
+func RemoveRelation[R any](w *World, a, b Entity) bool {
+	store := relationsOf[R](w, false)
+	if store == nil || !unlink(store.from, a, b) {
+		return false
+	}
+	unlink(store.to, b, a)
+	return true
+}
+
+// HasRelation checks if a is linked to b with relation R
+func HasRelation[R any](w *World, a, b Entity) bool {
+	store := relationsOf[R](w, false)
+	if store == nil {
+		return false
+	}
+	targets, exists := store.from[a]
+	return exists && targets.Contains(b)
+}
+
+// RelationsFrom returns the entities a is linked to with relation R
+func RelationsFrom[R any](w *World, a Entity) []Entity {
+	store := relationsOf[R](w, false)
+	if store == nil || store.from[a] == nil {
+		return nil
+	}
+	return append([]Entity(nil), store.from[a].Data()...)
+}
+
+// RelationsTo returns the entities linked to b with relation R
+func RelationsTo[R any](w *World, b Entity) []Entity {
+	store := relationsOf[R](w, false)
+	if store == nil || store.to[b] == nil {
+		return nil
+	}
+	return append([]Entity(nil), store.to[b].Data()...)
+}
@@ -0,0 +1,83 @@
+package ecs
+
+import (
+	"slices"
+	"testing"
+)
+
+type testLikes struct{}
+type testOwes struct{}
+
+func TestRelationsAreDirected(t *testing.T) {
+	w := NewWorld()
+	a, b, c := w.CreateEntity(), w.CreateEntity(), w.CreateEntity()
+
+	if !AddRelation[testLikes](w, a, b) || AddRelation[testLikes](w, a, b) {
+		t.Fatalf("AddRelation should succeed once and then report the existing link")
+	}
+	AddRelation[testLikes](w, c, b)
+	AddRelation[testOwes](w, b, a)
+
+	if !HasRelation[testLikes](w, a, b) || HasRelation[testLikes](w, b, a) {
+		t.Fatalf("Likes should only hold from a to b")
+	}
+	if HasRelation[testOwes](w, a, b) || !HasRelation[testOwes](w, b, a) {
+		t.Fatalf("relation types should be independent")
+	}
+	if got := RelationsTo[testLikes](w, b); !slices.Equal(got, []Entity{a, c}) {
+		t.Fatalf("RelationsTo = %v, want [%v %v]", got, a, c)
+	}
+	if got := RelationsFrom[testLikes](w, b); len(got) != 0 {
+		t.Fatalf("RelationsFrom(b) = %v, want none", got)
+	}
+
+	if !RemoveRelation[testLikes](w, a, b) || RemoveRelation[testLikes](w, a, b) {
+		t.Fatalf("RemoveRelation should succeed once")
+	}
+	if got := RelationsTo[testLikes](w, b); !slices.Equal(got, []Entity{c}) {
+		t.Fatalf("RelationsTo after removal = %v, want [%v]", got, c)
+	}
+}
+
+func TestDestroyRemovesRelations(t *testing.T) {
+	w := NewWorld()
+	a, b, c := w.CreateEntity(), w.CreateEntity(), w.CreateEntity()
+	AddRelation[testLikes](w, a, b)
+	AddRelation[testLikes](w, b, c)
+	AddRelation[testOwes](w, c, b)
+
+	w.DestroyEntity(b)
+
+	if got := RelationsFrom[testLikes](w, a); len(got) != 0 {
+		t.Fatalf("a still likes %v after b was destroyed", got)
+	}
+	if got := RelationsTo[testLikes](w, c); len(got) != 0 {
+		t.Fatalf("c still liked by %v after b was destroyed", got)
+	}
+	if got := RelationsFrom[testOwes](w, c); len(got) != 0 {
+		t.Fatalf("c still owes %v after b was destroyed", got)
+	}
+	if AddRelation[testLikes](w, a, b) {
+		t.Fatalf("AddRelation accepted a destroyed entity")
+	}
+}
+
+func TestResetRemovesRelationsOfDestroyedEntities(t *testing.T) {
+	w := NewWorld()
+	RegisterPersistent[testSettings](w)
+	config := w.CreateEntity()
+	AddComponent(w, config, testSettings{})
+	other := w.CreateEntity()
+	AddRelation[testOwes](w, config, other)
+
+	w.Reset()
+
+	if got := RelationsFrom[testOwes](w, config); len(got) != 0 {
+		t.Fatalf("persistent entity still owes %v after Reset", got)
+	}
+	// The destroyed entity's index is reused, and must not inherit its links
+	reused := w.CreateEntity()
+	if HasRelation[testOwes](w, config, reused) || len(RelationsTo[testOwes](w, reused)) != 0 {
+		t.Fatalf("recycled entity inherited relations")
+	}
+}
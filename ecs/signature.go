@@ -0,0 +1,150 @@
+package ecs
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"sort"
+)
+
+// signatureTable stores a component bitset per entity index, where bit N is set
+// when the entity holds the component with ID N
+type signatureTable struct {
+	stride int      // Number of uint64 words per entity
+	words  []uint64 // Flat bitsets, stride words per entity index
+}
+
+// newSignatureTable creates an empty signature table
+func newSignatureTable() *signatureTable {
+	return &signatureTable{
+		stride: 1,
+		words:  make([]uint64, 0),
+	}
+}
+
+// ensureType widens every bitset so it can hold the given component ID
+func (st *signatureTable) ensureType(id ComponentID) {
+	needed := int(id)/64 + 1
+	if needed <= st.stride {
+		return
+	}
+
+	rows := len(st.words) / st.stride
+	newWords := make([]uint64, rows*needed)
+	for row := 0; row < rows; row++ {
+		copy(newWords[row*needed:], st.words[row*st.stride:(row+1)*st.stride])
+	}
+	st.words = newWords
+	st.stride = needed
+}
+
+// row returns the bitset for an entity index, or nil if it holds no components
+func (st *signatureTable) row(index uint32) []uint64 {
+	start := int(index) * st.stride
+	if start+st.stride > len(st.words) {
+		return nil
+	}
+	return st.words[start : start+st.stride]
+}
+
+// set marks an entity as holding a component
+func (st *signatureTable) set(entity Entity, id ComponentID) {
+	st.ensureType(id)
+	start := int(entity.Index()) * st.stride
+	if needed := start + st.stride; needed > len(st.words) {
+		st.words = append(st.words, make([]uint64, needed-len(st.words))...)
+	}
+	st.words[start+int(id)/64] |= 1 << (id % 64)
+}
+
+// unset marks an entity as no longer holding a component
+func (st *signatureTable) unset(entity Entity, id ComponentID) {
+	if row := st.row(entity.Index()); row != nil && int(id)/64 < len(row) {
+		row[id/64] &^= 1 << (id % 64)
+	}
+}
+
+// EntitySignature returns the entity's component membership as a bitmask where
+// bit N is set if it holds the component with ID N
+// Only IDs below 64 are represented; use EntitySignatureBits for more types
+func (w *World) EntitySignature(entity Entity) uint64 {
+	if !w.entityManager.IsValid(entity) {
+		return 0
+	}
+
+	if row := w.componentRegistry.signatures.row(entity.Index()); row != nil {
+		return row[0]
+	}
+	return 0
+}
+
+// EntitySignatureBits returns the entity's full component bitset, one bit per
+// registered component ID, packed into 64-bit words
+func (w *World) EntitySignatureBits(entity Entity) []uint64 {
+	signatures := w.componentRegistry.signatures
+	words := make([]uint64, signatures.stride)
+	if !w.entityManager.IsValid(entity) {
+		return words
+	}
+
+	copy(words, signatures.row(entity.Index()))
+	return words
+}
+
+// ArchetypeStat reports how many entities share one component signature
+type ArchetypeStat struct {
+	Components []ComponentID // Component IDs in the signature, ascending
+	Count      int           // Number of live entities with exactly this signature
+}
+
+// ArchetypeStats groups live entities by component signature, most common first
+// Many distinct signatures with few entities each indicates fragmented layouts
+func (w *World) ArchetypeStats() []ArchetypeStat {
+	signatures := w.componentRegistry.signatures
+	groups := make(map[string]*ArchetypeStat)
+	key := make([]byte, signatures.stride*8)
+
+	w.entityManager.ForEachAlive(func(entity Entity) {
+		row := signatures.row(entity.Index())
+		clear(key)
+		for i, word := range row {
+			binary.LittleEndian.PutUint64(key[i*8:], word)
+		}
+
+		if stat, exists := groups[string(key)]; exists {
+			stat.Count++
+			return
+		}
+
+		components := make([]ComponentID, 0)
+		for i, word := range row {
+			for word != 0 {
+				bit := bits.TrailingZeros64(word)
+				components = append(components, ComponentID(i*64+bit))
+				word &^= 1 << bit
+			}
+		}
+		groups[string(key)] = &ArchetypeStat{Components: components, Count: 1}
+	})
+
+	stats := make([]ArchetypeStat, 0, len(groups))
+	for _, stat := range groups {
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		return lessComponentIDs(stats[i].Components, stats[j].Components)
+	})
+	return stats
+}
+
+// lessComponentIDs orders ID lists lexicographically for deterministic output
+func lessComponentIDs(a, b []ComponentID) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
@@ -0,0 +1,145 @@
+package ecs
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestEntitySignatureFollowsComponents(t *testing.T) {
+	w := NewWorld()
+	position := Register[testPosition](w.componentRegistry)
+	velocity := Register[testVelocity](w.componentRegistry)
+	entity := w.CreateEntity()
+
+	AddComponent(w, entity, testPosition{})
+	if got := w.EntitySignature(entity); got != 1<<position {
+		t.Fatalf("signature after adding position = %b, want %b", got, uint64(1)<<position)
+	}
+	AddComponent(w, entity, testVelocity{})
+	if got := w.EntitySignature(entity); got != 1<<position|1<<velocity {
+		t.Fatalf("signature after adding velocity = %b", got)
+	}
+	RemoveComponent[testPosition](w, entity)
+	if got := w.EntitySignature(entity); got != 1<<velocity {
+		t.Fatalf("signature after removing position = %b, want %b", got, uint64(1)<<velocity)
+	}
+
+	w.DestroyEntity(entity)
+	if got := w.EntitySignature(entity); got != 0 {
+		t.Fatalf("signature of destroyed entity = %b, want 0", got)
+	}
+	// A recycled index starts with an empty signature
+	if got := w.EntitySignature(w.CreateEntity()); got != 0 {
+		t.Fatalf("signature of recycled entity = %b, want 0", got)
+	}
+}
+
+func TestEntitySignatureBitsBeyond64Types(t *testing.T) {
+	w := NewWorld()
+	low := Register[testPosition](w.componentRegistry)
+	w.componentRegistry.nextID = 100
+	high := Register[testVelocity](w.componentRegistry)
+	entity := w.CreateEntity()
+
+	AddComponent(w, entity, testPosition{})
+	AddComponent(w, entity, testVelocity{})
+	bits := w.EntitySignatureBits(entity)
+	if len(bits) < 2 || bits[low/64] != 1<<(low%64) || bits[high/64] != 1<<(high%64) {
+		t.Fatalf("signature bits = %b, want bits %d and %d", bits, low, high)
+	}
+	// The single word form only represents the first 64 IDs
+	if got := w.EntitySignature(entity); got != 1<<low {
+		t.Fatalf("signature = %b, want only bit %d", got, low)
+	}
+
+	RemoveComponent[testVelocity](w, entity)
+	if bits := w.EntitySignatureBits(entity); bits[high/64] != 0 {
+		t.Fatalf("bit %d still set after removal: %b", high, bits)
+	}
+}
+
+func TestArchetypeStatsDistribution(t *testing.T) {
+	w := NewWorld()
+	position := Register[testPosition](w.componentRegistry)
+	velocity := Register[testVelocity](w.componentRegistry)
+	health := Register[testHealth](w.componentRegistry)
+
+	// 5 {position}, 3 {position, velocity}, 1 {health}, 2 empty
+	for i := 0; i < 8; i++ {
+		entity := w.CreateEntity()
+		AddComponent(w, entity, testPosition{})
+		if i < 3 {
+			AddComponent(w, entity, testVelocity{})
+		}
+	}
+	AddComponent(w, w.CreateEntity(), testHealth{})
+	w.CreateEntity()
+	w.CreateEntity()
+	// Destroyed entities are not counted
+	w.DestroyEntity(populateTestWorld(w, 1)[0])
+
+	want := []ArchetypeStat{
+		{Components: []ComponentID{position}, Count: 5},
+		{Components: []ComponentID{position, velocity}, Count: 3},
+		{Components: []ComponentID{}, Count: 2},
+		{Components: []ComponentID{health}, Count: 1},
+	}
+	got := w.ArchetypeStats()
+	if len(got) != len(want) {
+		t.Fatalf("ArchetypeStats = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i].Count != want[i].Count || !slices.Equal(got[i].Components, want[i].Components) {
+			t.Fatalf("ArchetypeStats = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestMaskedMatcherAgreesWithContains(t *testing.T) {
+	w := NewWorld()
+	populateOverlap(w, 400, 35)
+	for i := 0; i < 400; i += 9 {
+		RemoveComponent[testPosition](w, makeEntity(uint32(i), 0))
+	}
+	masked := Without[testTag](With[testHealth](With[testVelocity](With[testPosition](NewQuery(w))))).matcher()
+	if !masked.masked {
+		t.Fatalf("a query over four low IDs didn't use signature masks")
+	}
+	unmasked := *masked
+	unmasked.masked = false
+
+	matched := 0
+	w.entityManager.ForEachAlive(func(entity Entity) {
+		if masked.matches(entity) != unmasked.matches(entity) {
+			t.Fatalf("%s: masked %v, per-pool %v", entity, masked.matches(entity), unmasked.matches(entity))
+		}
+		if masked.matches(entity) {
+			matched++
+		}
+	})
+	if matched == 0 {
+		t.Fatalf("no entity matched, the comparison is vacuous")
+	}
+}
+
+func BenchmarkMatcherFourComponents(b *testing.B) {
+	w := NewWorld()
+	populateOverlap(w, 10000, 50)
+	masked := Without[testTag](With[testHealth](With[testVelocity](With[testPosition](NewQuery(w))))).matcher()
+	unmasked := *masked
+	unmasked.masked = false
+	entities := aliveEntities(w.entityManager)
+
+	for _, m := range []struct {
+		name    string
+		matcher *queryMatcher
+	}{{"masked", masked}, {"contains", &unmasked}} {
+		b.Run(m.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, entity := range entities {
+					m.matcher.matches(entity)
+				}
+			}
+		})
+	}
+}
@@ -1,36 +1,59 @@
 package ecs
 
+import "slices"
+
+// sparsePageSize is the number of entity indices covered by one sparse page
+const sparsePageSize = 1024
+
 // SparseSet is a data structure that provides O(1) insertion, deletion, and lookup
 // It's the foundation for efficient component storage in the ECS
 type SparseSet struct {
-	sparse []int32  // Maps entity index to dense array index (-1 means not present)
-	dense  []Entity // Packed array of entities
-	size   int      // Current number of elements
+	sparse [][]int32 // Pages mapping entity index to dense array index (-1 means not present)
+	dense  []Entity  // Packed array of entities
+	size   int       // Current number of elements
 }
 
 // NewSparseSet creates a new sparse set
 func NewSparseSet() *SparseSet {
 	return &SparseSet{
-		sparse: make([]int32, 0),
+		sparse: make([][]int32, 0),
 		dense:  make([]Entity, 0),
 		size:   0,
 	}
 }
 
-// ensureCapacity ensures the sparse array can hold the given entity index
-func (ss *SparseSet) ensureCapacity(entityIndex uint32) {
-	needed := int(entityIndex) + 1
-	if len(ss.sparse) < needed {
-		// Grow sparse array to accommodate entity
-		oldLen := len(ss.sparse)
-		newSparse := make([]int32, needed)
+// ensurePage allocates the sparse page holding the given entity index
+// Pages are allocated lazily so memory scales with the spread of indices, not the max
+func (ss *SparseSet) ensurePage(entityIndex uint32) {
+	page := int(entityIndex / sparsePageSize)
+	if len(ss.sparse) <= page {
+		newSparse := make([][]int32, page+1)
 		copy(newSparse, ss.sparse)
+		ss.sparse = newSparse
+	}
+
+	if ss.sparse[page] == nil {
 		// Initialize new slots to -1 (not present)
-		for i := oldLen; i < needed; i++ {
-			newSparse[i] = -1
+		slots := make([]int32, sparsePageSize)
+		for i := range slots {
+			slots[i] = -1
 		}
-		ss.sparse = newSparse
+		ss.sparse[page] = slots
+	}
+}
+
+// sparseIndex returns the dense index stored for an entity index, or -1 if none
+func (ss *SparseSet) sparseIndex(entityIndex uint32) int32 {
+	page := int(entityIndex / sparsePageSize)
+	if page >= len(ss.sparse) || ss.sparse[page] == nil {
+		return -1
 	}
+	return ss.sparse[page][entityIndex%sparsePageSize]
+}
+
+// setSparseIndex stores the dense index for an entity index whose page exists
+func (ss *SparseSet) setSparseIndex(entityIndex uint32, denseIndex int32) {
+	ss.sparse[entityIndex/sparsePageSize][entityIndex%sparsePageSize] = denseIndex
 }
 
 // Contains checks if an entity exists in the set
@@ -39,30 +62,41 @@ func (ss *SparseSet) Contains(entity Entity) bool {
 		return false
 	}
 
-	entityIndex := entity.Index()
-	if int(entityIndex) >= len(ss.sparse) {
-		return false
-	}
+	denseIndex := ss.sparseIndex(entity.Index())
+	return denseIndex >= 0 && int(denseIndex) < ss.size && ss.dense[denseIndex] == entity
+}
 
-	denseIndex := ss.sparse[entityIndex]
+// ContainsUnsafe is Contains without the validity and page checks, for hot loops
+// The caller must guarantee the set currently holds entity's index, as entity itself
+// or another generation of it, so the answer is whether the generations match; for
+// any other entity it may panic, as removals and Shrink free sparse pages
+func (ss *SparseSet) ContainsUnsafe(entity Entity) bool {
+	index := uint32(entity) & EntityIndexMask
+	denseIndex := ss.sparse[index/sparsePageSize][index%sparsePageSize]
 	return denseIndex >= 0 && int(denseIndex) < ss.size && ss.dense[denseIndex] == entity
 }
 
+// IndexUnsafe returns the dense index of an entity the caller knows is in the set,
+// without any checks; the result is meaningless for entities not in the set
+func (ss *SparseSet) IndexUnsafe(entity Entity) int {
+	index := uint32(entity) & EntityIndexMask
+	return int(ss.sparse[index/sparsePageSize][index%sparsePageSize])
+}
+
 // Insert adds an entity to the set
 func (ss *SparseSet) Insert(entity Entity) bool {
 	if !entity.IsValid() {
 		return false
 	}
 
-	entityIndex := entity.Index()
-	ss.ensureCapacity(entityIndex)
-
 	if ss.Contains(entity) {
 		return false // Already present
 	}
 
 	// Add new entity
-	ss.sparse[entityIndex] = int32(ss.size)
+	entityIndex := entity.Index()
+	ss.ensurePage(entityIndex)
+	ss.setSparseIndex(entityIndex, int32(ss.size))
 
 	// Grow dense array if needed
 	if len(ss.dense) <= ss.size {
@@ -75,6 +109,22 @@ func (ss *SparseSet) Insert(entity Entity) bool {
 	return true
 }
 
+// insertOrIndex returns the dense index of entity, appending it first if it isn't in
+// the set, with a single sparse lookup; dense must already have room for it
+func (ss *SparseSet) insertOrIndex(entity Entity) (int, bool) {
+	entityIndex := entity.Index()
+	ss.ensurePage(entityIndex)
+	slot := &ss.sparse[entityIndex/sparsePageSize][entityIndex%sparsePageSize]
+	if denseIndex := int(*slot); denseIndex >= 0 && denseIndex < ss.size && ss.dense[denseIndex] == entity {
+		return denseIndex, false
+	}
+
+	*slot = int32(ss.size)
+	ss.dense = append(ss.dense[:ss.size], entity)
+	ss.size++
+	return ss.size - 1, true
+}
+
 // Remove removes an entity from the set
 func (ss *SparseSet) Remove(entity Entity) bool {
 	if !ss.Contains(entity) {
@@ -82,22 +132,86 @@ func (ss *SparseSet) Remove(entity Entity) bool {
 	}
 
 	entityIndex := entity.Index()
-	denseIndex := ss.sparse[entityIndex]
+	denseIndex := ss.sparseIndex(entityIndex)
 	lastIndex := int32(ss.size - 1)
 
 	if denseIndex != lastIndex {
 		// Move last element to the removed element's position (swap-and-pop)
 		lastEntity := ss.dense[lastIndex]
 		ss.dense[denseIndex] = lastEntity
-		ss.sparse[lastEntity.Index()] = denseIndex
+		ss.setSparseIndex(lastEntity.Index(), denseIndex)
 	}
 
-	ss.sparse[entityIndex] = -1
+	ss.setSparseIndex(entityIndex, -1)
 	ss.size--
 
 	return true
 }
 
+// removeOrdered removes an entity by shifting the entities after it down one place,
+// keeping the dense order stable at O(n) cost instead of swap-and-pop's O(1)
+func (ss *SparseSet) removeOrdered(entity Entity) bool {
+	if !ss.Contains(entity) {
+		return false
+	}
+
+	denseIndex := int(ss.sparseIndex(entity.Index()))
+	copy(ss.dense[denseIndex:ss.size], ss.dense[denseIndex+1:ss.size])
+	ss.size--
+	for i := denseIndex; i < ss.size; i++ {
+		ss.setSparseIndex(ss.dense[i].Index(), int32(i))
+	}
+	ss.setSparseIndex(entity.Index(), -1)
+	return true
+}
+
+// reserve grows the dense array's capacity to hold at least n entities
+func (ss *SparseSet) reserve(n int) {
+	if n > cap(ss.dense) {
+		dense := make([]Entity, len(ss.dense), n)
+		copy(dense, ss.dense)
+		ss.dense = dense
+	}
+}
+
+// Shrink releases memory left over from removals: the dense array is reallocated
+// when it is less than half full, and sparse pages with no entities are freed
+func (ss *SparseSet) Shrink() {
+	if cap(ss.dense) > 2*ss.size {
+		ss.dense = slices.Clone(ss.dense[:ss.size])
+	}
+	ss.shrinkPages()
+}
+
+// ShrinkToFit reallocates the dense array to exactly the set's size and frees
+// empty sparse pages, e.g. after bulk loading into an over-reserved set
+func (ss *SparseSet) ShrinkToFit() {
+	if cap(ss.dense) != ss.size {
+		dense := make([]Entity, ss.size)
+		copy(dense, ss.dense[:ss.size])
+		ss.dense = dense
+	}
+	ss.shrinkPages()
+}
+
+// shrinkPages frees sparse pages with no entities and trims trailing ones
+func (ss *SparseSet) shrinkPages() {
+	used := 0
+	for page, slots := range ss.sparse {
+		if slots == nil {
+			continue
+		}
+		if slices.ContainsFunc(slots, func(dense int32) bool { return dense >= 0 }) {
+			used = page + 1
+		} else {
+			ss.sparse[page] = nil
+		}
+	}
+	if used < len(ss.sparse) {
+		ss.sparse = slices.Clone(ss.sparse[:used])
+	}
+}
+
 // Size returns the number of entities in the set
 func (ss *SparseSet) Size() int {
 	return ss.size
@@ -111,9 +225,11 @@ func (ss *SparseSet) Empty() bool {
 // Clear removes all entities from the set
 func (ss *SparseSet) Clear() {
 	ss.size = 0
-	// Reset sparse array
-	for i := range ss.sparse {
-		ss.sparse[i] = -1
+	// Reset sparse pages
+	for _, page := range ss.sparse {
+		for i := range page {
+			page[i] = -1
+		}
 	}
 }
 
@@ -135,7 +251,7 @@ func (ss *SparseSet) Index(entity Entity) int {
 	if !ss.Contains(entity) {
 		return -1
 	}
-	return int(ss.sparse[entity.Index()])
+	return int(ss.sparseIndex(entity.Index()))
 }
 
 // ForEach iterates over all entities in the set
@@ -159,8 +275,8 @@ func (ss *SparseSet) Swap(i, j int) {
 	ss.dense[j] = entityI
 
 	// Update sparse array
-	ss.sparse[entityI.Index()] = int32(j)
-	ss.sparse[entityJ.Index()] = int32(i)
+	ss.setSparseIndex(entityI.Index(), int32(j))
+	ss.setSparseIndex(entityJ.Index(), int32(i))
 }
 
 // Sort sorts the entities using the provided comparison function
@@ -176,7 +292,7 @@ func (ss *SparseSet) Sort(less func(Entity, Entity) bool) {
 }
 
 // Respect maintains the order of entities according to another sparse set
-// This is useful for implementing groups
+// This is useful for implementing groups; runs in O(n+m)
 func (ss *SparseSet) Respect(other *SparseSet) {
 	if other.size == 0 {
 		return
@@ -193,17 +309,9 @@ func (ss *SparseSet) Respect(other *SparseSet) {
 		}
 	}
 
-	// Then add remaining entities
+	// Then add remaining entities, those already placed are exactly the ones in other
 	for i := 0; i < ss.size; i++ {
-		entity := ss.dense[i]
-		found := false
-		for j := 0; j < len(newDense); j++ {
-			if newDense[j] == entity {
-				found = true
-				break
-			}
-		}
-		if !found {
+		if entity := ss.dense[i]; !other.Contains(entity) {
 			newDense = append(newDense, entity)
 		}
 	}
@@ -211,6 +319,53 @@ func (ss *SparseSet) Respect(other *SparseSet) {
 	// Update dense array and sparse indices
 	copy(ss.dense[:len(newDense)], newDense)
 	for i, entity := range newDense {
-		ss.sparse[entity.Index()] = int32(i)
+		ss.setSparseIndex(entity.Index(), int32(i))
+	}
+}
+
+// Intersect returns the entities present in both sets
+// Iterates the smaller set and probes the larger one, so it runs in O(min(n, m))
+func (ss *SparseSet) Intersect(other *SparseSet) []Entity {
+	return ss.intersectInto(make([]Entity, 0, min(ss.size, other.size)), other)
+}
+
+// intersectInto appends the entities present in both sets to dst, in the smaller
+// set's dense order (this set's on a tie)
+func (ss *SparseSet) intersectInto(dst []Entity, other *SparseSet) []Entity {
+	small, large := ss, other
+	if other.size < ss.size {
+		small, large = other, ss
+	}
+
+	dst = slices.Grow(dst, small.size)
+	for i := 0; i < small.size; i++ {
+		if entity := small.dense[i]; large.Contains(entity) {
+			dst = append(dst, entity)
+		}
+	}
+	return dst
+}
+
+// Union returns the entities present in either set
+// Entities of this set come first, followed by those only in other
+func (ss *SparseSet) Union(other *SparseSet) []Entity {
+	result := make([]Entity, 0, ss.size+other.size)
+	result = append(result, ss.Data()...)
+	for i := 0; i < other.size; i++ {
+		if entity := other.dense[i]; !ss.Contains(entity) {
+			result = append(result, entity)
+		}
+	}
+	return result
+}
+
+// Difference returns the entities present in this set but not in other
+func (ss *SparseSet) Difference(other *SparseSet) []Entity {
+	result := make([]Entity, 0, ss.size)
+	for i := 0; i < ss.size; i++ {
+		if entity := ss.dense[i]; !other.Contains(entity) {
+			result = append(result, entity)
+		}
 	}
+	return result
 }
@@ -0,0 +1,214 @@
+package ecs
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// newTestSet returns a sparse set holding the entities with the given indices
+func newTestSet(indices ...int) *SparseSet {
+	set := NewSparseSet()
+	for _, index := range indices {
+		set.Insert(makeEntity(uint32(index), 0))
+	}
+	return set
+}
+
+// indicesOf returns the sorted indices of entities
+func indicesOf(entities []Entity) []int {
+	indices := make([]int, len(entities))
+	for i, entity := range entities {
+		indices[i] = int(entity.Index())
+	}
+	slices.Sort(indices)
+	return indices
+}
+
+func TestSparseSetOperations(t *testing.T) {
+	tests := []struct {
+		name                      string
+		a, b                      *SparseSet
+		intersect, union, aMinusB []int
+	}{
+		{"disjoint", newTestSet(1, 2, 3), newTestSet(4, 5), nil, []int{1, 2, 3, 4, 5}, []int{1, 2, 3}},
+		{"overlapping", newTestSet(1, 2, 3, 4), newTestSet(3, 4, 5), []int{3, 4}, []int{1, 2, 3, 4, 5}, []int{1, 2}},
+		{"subset", newTestSet(2, 3), newTestSet(1, 2, 3, 4), []int{2, 3}, []int{1, 2, 3, 4}, nil},
+		{"empty", newTestSet(), newTestSet(1), nil, []int{1}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, ab := range [][2]*SparseSet{{test.a, test.b}, {test.b, test.a}} {
+				if got := indicesOf(ab[0].Intersect(ab[1])); !slices.Equal(got, test.intersect) {
+					t.Fatalf("Intersect = %v, want %v", got, test.intersect)
+				}
+				if got := indicesOf(ab[0].Union(ab[1])); !slices.Equal(got, test.union) {
+					t.Fatalf("Union = %v, want %v", got, test.union)
+				}
+			}
+			if got := indicesOf(test.a.Difference(test.b)); !slices.Equal(got, test.aMinusB) {
+				t.Fatalf("Difference = %v, want %v", got, test.aMinusB)
+			}
+		})
+	}
+}
+
+func TestSparseSetOperationsCompareGenerations(t *testing.T) {
+	a, b := NewSparseSet(), NewSparseSet()
+	a.Insert(makeEntity(1, 0))
+	b.Insert(makeEntity(1, 1))
+
+	if got := a.Intersect(b); len(got) != 0 {
+		t.Fatalf("Intersect matched different generations: %v", got)
+	}
+	if got := a.Union(b); len(got) != 2 {
+		t.Fatalf("Union = %v, want both generations", got)
+	}
+}
+
+func BenchmarkSparseSetIntersect(b *testing.B) {
+	for _, size := range []int{1000, 100000} {
+		small, large := NewSparseSet(), NewSparseSet()
+		for i := 0; i < size; i++ {
+			large.Insert(makeEntity(uint32(i), 0))
+			if i%10 == 0 {
+				small.Insert(makeEntity(uint32(i*3/2), 0))
+			}
+		}
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				large.Intersect(small)
+			}
+		})
+	}
+}
+
+func TestSparseSetPagesScaleWithSpread(t *testing.T) {
+	indices := []int{3, 200000, 900000, 900001, EntityIndexMask - 1}
+	set := newTestSet(indices...)
+
+	pages := 0
+	for _, page := range set.sparse {
+		if page != nil {
+			pages++
+		}
+	}
+	if pages != 4 {
+		t.Fatalf("%d sparse pages allocated, want 4", pages)
+	}
+
+	// A flat sparse array would need 4 bytes per index up to the largest, 4 MiB here
+	bytes := len(set.sparse)*24 + pages*sparsePageSize*4
+	if bytes > 64<<10 {
+		t.Fatalf("sparse layer uses %d bytes for %d entities", bytes, len(indices))
+	}
+
+	for i, index := range indices {
+		entity := makeEntity(uint32(index), 0)
+		if !set.Contains(entity) || set.Index(entity) != i {
+			t.Fatalf("entity at index %d: Contains %v, Index %d", index, set.Contains(entity), set.Index(entity))
+		}
+	}
+	if set.Contains(makeEntity(500000, 0)) || set.Index(makeEntity(500000, 0)) != -1 {
+		t.Fatalf("entity in an unallocated page reported present")
+	}
+
+	set.Remove(makeEntity(uint32(indices[1]), 0))
+	if set.Contains(makeEntity(uint32(indices[1]), 0)) || !set.Contains(makeEntity(uint32(indices[4]), 0)) {
+		t.Fatalf("Remove broke membership across pages")
+	}
+	if errs := set.validate(); len(errs) > 0 {
+		t.Fatalf("sparse set inconsistent: %v", errs)
+	}
+}
+
+func TestSparseSetShrinkAfterMassRemoval(t *testing.T) {
+	indices := make([]int, 10*sparsePageSize)
+	for i := range indices {
+		indices[i] = i
+	}
+	set := newTestSet(indices...)
+
+	// Keep every hundredth entity of the first page only
+	var kept []int
+	for _, index := range indices {
+		if index < sparsePageSize && index%100 == 0 {
+			kept = append(kept, index)
+		} else {
+			set.Remove(makeEntity(uint32(index), 0))
+		}
+	}
+	set.Shrink()
+
+	if cap(set.dense) > 2*len(kept) {
+		t.Fatalf("dense capacity %d after shrinking to %d entities", cap(set.dense), len(kept))
+	}
+	if len(set.sparse) != 1 {
+		t.Fatalf("%d sparse pages after shrinking, want 1", len(set.sparse))
+	}
+	if got := indicesOf(set.Data()); !slices.Equal(got, kept) {
+		t.Fatalf("members after Shrink = %v, want %v", got, kept)
+	}
+	for _, index := range kept {
+		if !set.Contains(makeEntity(uint32(index), 0)) {
+			t.Fatalf("kept entity %d lost by Shrink", index)
+		}
+	}
+	if set.Contains(makeEntity(sparsePageSize+1, 0)) {
+		t.Fatalf("removed entity in a freed page reported present")
+	}
+	if errs := set.validate(); len(errs) > 0 {
+		t.Fatalf("sparse set inconsistent after Shrink: %v", errs)
+	}
+
+	// The set still grows back after shrinking
+	set.Insert(makeEntity(5*sparsePageSize, 0))
+	if !set.Contains(makeEntity(5*sparsePageSize, 0)) || set.Size() != len(kept)+1 {
+		t.Fatalf("insert after Shrink failed")
+	}
+}
+
+func TestSparseSetUnsafeMatchesSafe(t *testing.T) {
+	set := newTestSet(0, 5, 9, 4097)
+	set.Remove(makeEntity(5, 0))
+	// Only indices the set holds are allowed, in any generation
+	for _, entity := range []Entity{makeEntity(0, 0), makeEntity(0, 2), makeEntity(9, 0), makeEntity(9, 1), makeEntity(4097, 0)} {
+		if set.ContainsUnsafe(entity) != set.Contains(entity) {
+			t.Fatalf("ContainsUnsafe(%s) = %v, Contains says %v", entity, set.ContainsUnsafe(entity), set.Contains(entity))
+		}
+		if set.Contains(entity) && set.IndexUnsafe(entity) != set.Index(entity) {
+			t.Fatalf("IndexUnsafe(%s) = %d, want %d", entity, set.IndexUnsafe(entity), set.Index(entity))
+		}
+	}
+}
+
+func BenchmarkSparseSetContains(b *testing.B) {
+	set := NewSparseSet()
+	for i := 0; i < 100000; i++ {
+		set.Insert(makeEntity(uint32(i*3), 0))
+	}
+	// Every other probe is a stale generation of a held index
+	probes := make([]Entity, 100000)
+	for i := range probes {
+		probes[i] = makeEntity(uint32(i*3), uint32(i%2))
+	}
+
+	b.Run("safe", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, entity := range probes {
+				if set.Contains(entity) {
+					_ = set.Index(entity)
+				}
+			}
+		}
+	})
+	b.Run("unsafe", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, entity := range probes {
+				if set.ContainsUnsafe(entity) {
+					_ = set.IndexUnsafe(entity)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,150 @@
+package ecs
+
+import "math"
+
+// spatialCell identifies one cell of a SpatialHash2D grid
+type spatialCell struct {
+	x, y int64
+}
+
+// SpatialHash2D indexes the entities holding position component T in a uniform grid
+// for fast neighbourhood queries
+// Entities are indexed as T is added, re-indexed as it is replaced and dropped as it
+// is removed; in-place moves are picked up from change detection (GetComponentMut or
+// MarkChanged) before every query, or explicitly with Update or Rebuild
+type SpatialHash2D[T any] struct {
+	pool     *ComponentPool[T]
+	position func(*T) (float64, float64)
+	cellSize float64
+	cells    map[spatialCell][]Entity
+	where    map[Entity]spatialCell // Cell each indexed entity is stored in
+}
+
+// NewSpatialHash2D creates a spatial hash over component T with square cells of
+// cellSize, indexing the entities that already have T
+// Cells around the typical query radius give the best performance
+func NewSpatialHash2D[T any](w *World, cellSize float64, position func(*T) (float64, float64)) *SpatialHash2D[T] {
+	if cellSize <= 0 {
+		panic("ecs: spatial hash cell size must be positive")
+	}
+
+	Register[T](w.componentRegistry)
+	pool, _ := GetStorage[T](w.componentRegistry)
+
+	sh := &SpatialHash2D[T]{
+		pool:     pool,
+		position: position,
+		cellSize: cellSize,
+		cells:    make(map[spatialCell][]Entity),
+		where:    make(map[Entity]spatialCell),
+	}
+	pool.onInsert = append(pool.onInsert, sh.Update)
+	pool.onRemove = append(pool.onRemove, sh.unindex)
+	pool.onReplace = append(pool.onReplace, func(entity Entity, _, _ T) {
+		sh.Update(entity)
+	})
+	sh.Rebuild()
+	return sh
+}
+
+// cellAt returns the cell containing a point
+func (sh *SpatialHash2D[T]) cellAt(x, y float64) spatialCell {
+	return spatialCell{
+		x: int64(math.Floor(x / sh.cellSize)),
+		y: int64(math.Floor(y / sh.cellSize)),
+	}
+}
+
+// Update re-indexes an entity from its current position
+func (sh *SpatialHash2D[T]) Update(entity Entity) {
+	component := sh.pool.GetPtr(entity)
+	if component == nil {
+		sh.unindex(entity)
+		return
+	}
+
+	cell := sh.cellAt(sh.position(component))
+	if current, indexed := sh.where[entity]; indexed {
+		if current == cell {
+			return
+		}
+		sh.unindex(entity)
+	}
+	sh.cells[cell] = append(sh.cells[cell], entity)
+	sh.where[entity] = cell
+}
+
+// unindex removes an entity from its cell
+func (sh *SpatialHash2D[T]) unindex(entity Entity) {
+	cell, indexed := sh.where[entity]
+	if !indexed {
+		return
+	}
+
+	entities := sh.cells[cell]
+	for i, other := range entities {
+		if other == entity {
+			entities[i] = entities[len(entities)-1]
+			entities = entities[:len(entities)-1]
+			break
+		}
+	}
+	if len(entities) == 0 {
+		delete(sh.cells, cell)
+	} else {
+		sh.cells[cell] = entities
+	}
+	delete(sh.where, entity)
+}
+
+// Rebuild re-indexes every entity with T from scratch
+func (sh *SpatialHash2D[T]) Rebuild() {
+	clear(sh.cells)
+	clear(sh.where)
+	for _, entity := range sh.pool.Entities().Data() {
+		sh.Update(entity)
+	}
+}
+
+// sync re-indexes the entities whose position was marked changed
+func (sh *SpatialHash2D[T]) sync() {
+	if sh.pool.changed == nil {
+		return
+	}
+	for _, entity := range sh.pool.changed.Data() {
+		sh.Update(entity)
+	}
+}
+
+// QueryAABB returns the entities whose position lies within the axis-aligned box
+func (sh *SpatialHash2D[T]) QueryAABB(minX, minY, maxX, maxY float64) []Entity {
+	return sh.query(minX, minY, maxX, maxY, func(x, y float64) bool {
+		return x >= minX && x <= maxX && y >= minY && y <= maxY
+	})
+}
+
+// QueryRadius returns the entities whose position lies within distance r of (x, y)
+func (sh *SpatialHash2D[T]) QueryRadius(x, y, r float64) []Entity {
+	return sh.query(x-r, y-r, x+r, y+r, func(px, py float64) bool {
+		dx, dy := px-x, py-y
+		return dx*dx+dy*dy <= r*r
+	})
+}
+
+// query tests the entities of every cell overlapping the box against inside
+func (sh *SpatialHash2D[T]) query(minX, minY, maxX, maxY float64, inside func(x, y float64) bool) []Entity {
+	sh.sync()
+
+	result := make([]Entity, 0)
+	lo, hi := sh.cellAt(minX, minY), sh.cellAt(maxX, maxY)
+	for cx := lo.x; cx <= hi.x; cx++ {
+		for cy := lo.y; cy <= hi.y; cy++ {
+			for _, entity := range sh.cells[spatialCell{x: cx, y: cy}] {
+				if inside(sh.position(&sh.pool.components[sh.pool.entities.Index(entity)])) {
+					result = append(result, entity)
+				}
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,90 @@
+package ecs
+
+import (
+	"slices"
+	"testing"
+)
+
+// testPositionXY extracts a testPosition's coordinates for a spatial hash
+func testPositionXY(p *testPosition) (float64, float64) { return p.X, p.Y }
+
+// newGridWorld places one entity at every integer point of a size x size grid
+func newGridWorld(size int) (*World, map[[2]int]Entity) {
+	w := NewWorld()
+	grid := make(map[[2]int]Entity, size*size)
+	for x := range size {
+		for y := range size {
+			entity := w.CreateEntity()
+			AddComponent(w, entity, testPosition{X: float64(x), Y: float64(y)})
+			grid[[2]int{x, y}] = entity
+		}
+	}
+	return w, grid
+}
+
+// bruteForceRadius lists the entities within r of (x, y) by checking every position
+func bruteForceRadius(w *World, x, y, r float64) []Entity {
+	var matches []Entity
+	for entity, p := range Range1[testPosition](w) {
+		if dx, dy := p.X-x, p.Y-y; dx*dx+dy*dy <= r*r {
+			matches = append(matches, entity)
+		}
+	}
+	return sortedByIndex(matches)
+}
+
+func TestSpatialHashQueryRadius(t *testing.T) {
+	w, grid := newGridWorld(10)
+	sh := NewSpatialHash2D(w, 2, testPositionXY)
+
+	for _, q := range []struct{ x, y, r float64 }{{5, 5, 1}, {0, 0, 2.5}, {4.5, 7, 3}, {-20, -20, 1}, {5, 5, 100}} {
+		got := sortedByIndex(sh.QueryRadius(q.x, q.y, q.r))
+		if want := bruteForceRadius(w, q.x, q.y, q.r); !slices.Equal(got, want) {
+			t.Fatalf("QueryRadius(%v, %v, %v) = %d entities, want %d", q.x, q.y, q.r, len(got), len(want))
+		}
+	}
+
+	got := sortedByIndex(sh.QueryAABB(2, 3, 3, 4))
+	want := sortedByIndex([]Entity{grid[[2]int{2, 3}], grid[[2]int{2, 4}], grid[[2]int{3, 3}], grid[[2]int{3, 4}]})
+	if !slices.Equal(got, want) {
+		t.Fatalf("QueryAABB = %v, want %v", got, want)
+	}
+}
+
+func TestSpatialHashStaysInSync(t *testing.T) {
+	w, grid := newGridWorld(4)
+	sh := NewSpatialHash2D(w, 1, testPositionXY)
+	mover, removed := grid[[2]int{0, 0}], grid[[2]int{3, 3}]
+
+	// Moves through change detection are picked up by the next query
+	GetComponentMut[testPosition](w, mover).X = 20
+	if got := sh.QueryRadius(20, 0, 0.5); !slices.Equal(got, []Entity{mover}) {
+		t.Fatalf("after a tracked move QueryRadius = %v, want %v", got, mover)
+	}
+	if slices.Contains(sh.QueryRadius(0, 0, 0.5), mover) {
+		t.Fatalf("moved entity still found at its old position")
+	}
+
+	// Replacing the component re-indexes, removing it unindexes
+	AddComponent(w, mover, testPosition{X: -5, Y: -5})
+	if got := sh.QueryAABB(-6, -6, -4, -4); !slices.Equal(got, []Entity{mover}) {
+		t.Fatalf("after replacing QueryAABB = %v, want %v", got, mover)
+	}
+	RemoveComponent[testPosition](w, removed)
+	w.DestroyEntity(grid[[2]int{3, 2}])
+	if got := sh.QueryRadius(3, 3, 1); len(got) != 1 || got[0] != grid[[2]int{2, 3}] {
+		t.Fatalf("after removal QueryRadius = %v, want only %v", got, grid[[2]int{2, 3}])
+	}
+
+	// Untracked in-place writes need an explicit Rebuild
+	positions, _ := GetStorage[testPosition](w.componentRegistry)
+	positions.GetPtr(grid[[2]int{1, 1}]).Y = 50
+	sh.Rebuild()
+	if got := sh.QueryRadius(1, 50, 0.5); !slices.Equal(got, []Entity{grid[[2]int{1, 1}]}) {
+		t.Fatalf("after Rebuild QueryRadius = %v", got)
+	}
+
+	expectPanic(t, "ecs: spatial hash cell size must be positive", func() {
+		NewSpatialHash2D(w, 0, testPositionXY)
+	})
+}
@@ -1,5 +1,10 @@
 package ecs
 
+import (
+	"slices"
+	"time"
+)
+
 // System represents a system that processes entities
 type System interface {
 	// Update is called every frame/tick
@@ -9,24 +14,140 @@ type System interface {
 	GetName() string
 }
 
+// PreUpdater is implemented by systems that need a setup pass before any system updates
+type PreUpdater interface {
+	PreUpdate(world *World, deltaTime float64)
+}
+
+// PostUpdater is implemented by systems that need a teardown pass after all systems updated
+type PostUpdater interface {
+	PostUpdate(world *World, deltaTime float64)
+}
+
+// Detacher is implemented by systems that attach to world state outside Update, e.g.
+// component pool trackers; RemoveSystem and Clear call Detach on them
+type Detacher interface {
+	Detach()
+}
+
+// DefaultStage is the stage systems added with AddSystem belong to
+const DefaultStage = "default"
+
 // SystemManager manages all systems in the ECS
 type SystemManager struct {
 	systems []System
 	enabled map[System]bool
+
+	stages     map[System]string // Stage each system belongs to
+	stageOrder []string          // Order in which stages run
+	ordered    []System          // Systems in run order, nil when it needs rebuilding
+
+	profiling bool
+	timings   map[string]time.Duration       // Update duration per system name, last frame
+	averages  map[string]time.Duration       // Exponential moving average of timings
+	accesses  map[string]map[ComponentID]int // Pool accesses per system name and component
+
+	recoverPanics bool
+	panicHandler  func(system string, recovered any) // Receives recovered panics, nil logs them
+
+	duplicateNames DuplicateNamePolicy
 }
 
+// DuplicateNamePolicy controls how AddSystem treats a system whose name is already in use
+type DuplicateNamePolicy int
+
+const (
+	// DuplicateNamesAllow adds the system; by-name lookups and removal find the first added
+	DuplicateNamesAllow DuplicateNamePolicy = iota
+	// DuplicateNamesWarn adds the system and logs a warning through the world's logger
+	DuplicateNamesWarn
+	// DuplicateNamesReject refuses to add the system
+	DuplicateNamesReject
+)
+
+// profileSmoothing is the weight of the latest frame in the rolling average timings
+const profileSmoothing = 0.1
+
 // NewSystemManager creates a new system manager
 func NewSystemManager() *SystemManager {
 	return &SystemManager{
-		systems: make([]System, 0),
-		enabled: make(map[System]bool),
+		systems:    make([]System, 0),
+		enabled:    make(map[System]bool),
+		stages:     make(map[System]string),
+		stageOrder: []string{DefaultStage},
 	}
 }
 
-// AddSystem adds a system to the manager
-func (sm *SystemManager) AddSystem(system System) {
+// AddSystem adds a system to the manager in the default stage
+// Returns false if the system was not added, see AddSystemToStage
+func (sm *SystemManager) AddSystem(system System) bool {
+	return sm.AddSystemToStage(DefaultStage, system)
+}
+
+// AddSystemToStage adds a system to the manager in the given stage
+// Within a stage, systems run in insertion order
+// Returns false without adding it if this instance was already added, or if its name
+// is taken and the duplicate name policy is DuplicateNamesReject
+func (sm *SystemManager) AddSystemToStage(stage string, system System) bool {
+	if _, added := sm.stages[system]; added {
+		return false
+	}
+	if sm.duplicateNames == DuplicateNamesReject && sm.HasSystemNamed(system.GetName()) {
+		return false
+	}
+
 	sm.systems = append(sm.systems, system)
 	sm.enabled[system] = true
+	sm.stages[system] = stage
+	sm.ordered = nil
+	return true
+}
+
+// SetDuplicateNamePolicy sets how AddSystem treats a system whose name is already in use
+// The default, DuplicateNamesAllow, keeps the historical behaviour
+func (sm *SystemManager) SetDuplicateNamePolicy(policy DuplicateNamePolicy) {
+	sm.duplicateNames = policy
+}
+
+// HasSystemNamed checks if any added system has the given name
+func (sm *SystemManager) HasSystemNamed(name string) bool {
+	_, exists := sm.GetSystemByName(name)
+	return exists
+}
+
+// SetStageOrder sets the order in which stages run, e.g. Input, Simulation, Render
+// DefaultStage runs first unless it is listed; systems in unlisted stages are skipped
+func (sm *SystemManager) SetStageOrder(stages []string) {
+	sm.stageOrder = append([]string(nil), stages...)
+	sm.ordered = nil
+}
+
+// GetStageOrder returns the order in which stages run
+func (sm *SystemManager) GetStageOrder() []string {
+	return sm.stageOrder
+}
+
+// orderedSystems returns the systems grouped by stage in run order
+func (sm *SystemManager) orderedSystems() []System {
+	if sm.ordered != nil {
+		return sm.ordered
+	}
+
+	stageOrder := sm.stageOrder
+	if !slices.Contains(stageOrder, DefaultStage) {
+		stageOrder = append([]string{DefaultStage}, stageOrder...)
+	}
+
+	ordered := make([]System, 0, len(sm.systems))
+	for _, stage := range stageOrder {
+		for _, system := range sm.systems {
+			if sm.stages[system] == stage {
+				ordered = append(ordered, system)
+			}
+		}
+	}
+	sm.ordered = ordered
+	return ordered
 }
 
 // RemoveSystem removes a system from the manager
@@ -36,11 +157,28 @@ func (sm *SystemManager) RemoveSystem(system System) {
 			// Remove system from slice
 			sm.systems = append(sm.systems[:i], sm.systems[i+1:]...)
 			delete(sm.enabled, system)
+			delete(sm.stages, system)
+			sm.ordered = nil
+			if detacher, ok := system.(Detacher); ok {
+				detacher.Detach()
+			}
 			break
 		}
 	}
 }
 
+// RemoveSystemByName removes the first system whose GetName matches
+// Returns false if no system has that name
+func (sm *SystemManager) RemoveSystemByName(name string) bool {
+	system, exists := sm.GetSystemByName(name)
+	if !exists {
+		return false
+	}
+
+	sm.RemoveSystem(system)
+	return true
+}
+
 // EnableSystem enables a system
 func (sm *SystemManager) EnableSystem(system System) {
 	sm.enabled[system] = true
@@ -57,13 +195,139 @@ func (sm *SystemManager) IsEnabled(system System) bool {
 	return exists && enabled
 }
 
-// Update updates all enabled systems
+// Update updates all enabled systems stage by stage in three phases:
+// every PreUpdate, then every Update, then every PostUpdate
 func (sm *SystemManager) Update(world *World, deltaTime float64) {
-	for _, system := range sm.systems {
-		if sm.IsEnabled(system) {
-			system.Update(world, deltaTime)
+	systems := sm.orderedSystems()
+
+	for _, system := range systems {
+		if pre, ok := system.(PreUpdater); ok && sm.IsEnabled(system) {
+			sm.run(world, system, pre.PreUpdate, deltaTime)
+		}
+	}
+
+	var current string
+	if sm.profiling {
+		clear(sm.timings)
+		world.componentRegistry.setAccessHook(func(id ComponentID) {
+			counts, exists := sm.accesses[current]
+			if !exists {
+				counts = make(map[ComponentID]int)
+				sm.accesses[current] = counts
+			}
+			counts[id]++
+		})
+		// Removed even if a system panics, or later accesses would be charged to it
+		defer world.componentRegistry.setAccessHook(nil)
+	}
+
+	for _, system := range systems {
+		if !sm.IsEnabled(system) {
+			continue
+		}
+
+		if sm.profiling {
+			current = system.GetName()
+			start := time.Now()
+			sm.run(world, system, system.Update, deltaTime)
+			sm.timings[current] += time.Since(start)
+		} else {
+			sm.run(world, system, system.Update, deltaTime)
+		}
+	}
+
+	if sm.profiling {
+		world.componentRegistry.setAccessHook(nil)
+		for name, elapsed := range sm.timings {
+			average, seen := sm.averages[name]
+			if !seen {
+				average = elapsed
+			}
+			sm.averages[name] = average + time.Duration(profileSmoothing*float64(elapsed-average))
+		}
+	}
+
+	for _, system := range systems {
+		if post, ok := system.(PostUpdater); ok && sm.IsEnabled(system) {
+			sm.run(world, system, post.PostUpdate, deltaTime)
+		}
+	}
+}
+
+// run calls one phase of a system, recovering a panic if RecoverPanics is on
+func (sm *SystemManager) run(world *World, system System, phase func(*World, float64), deltaTime float64) {
+	if sm.recoverPanics {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				if sm.panicHandler != nil {
+					sm.panicHandler(system.GetName(), recovered)
+				} else {
+					world.logf(LogLevelError, "system %q panicked: %v", system.GetName(), recovered)
+				}
+			}
+		}()
+	}
+	phase(world, deltaTime)
+}
+
+// RecoverPanics isolates system failures: a panic in a system's PreUpdate, Update
+// or PostUpdate is recovered and reported, and the remaining systems still run
+func (sm *SystemManager) RecoverPanics(enabled bool) {
+	sm.recoverPanics = enabled
+}
+
+// SetPanicHandler sets the callback that receives panics recovered from systems;
+// nil reports them through the world's logger at error level
+func (sm *SystemManager) SetPanicHandler(handler func(system string, recovered any)) {
+	sm.panicHandler = handler
+}
+
+// EnableProfiling turns per-system Update timing and access counting on or off
+// Enabling resets previously collected timings and access counts
+func (sm *SystemManager) EnableProfiling(enabled bool) {
+	sm.profiling = enabled
+	if enabled {
+		sm.timings = make(map[string]time.Duration)
+		sm.averages = make(map[string]time.Duration)
+		sm.accesses = make(map[string]map[ComponentID]int)
+	}
+}
+
+// IsProfiling checks if per-system timing is enabled
+func (sm *SystemManager) IsProfiling() bool {
+	return sm.profiling
+}
+
+// Timings returns how long each system's Update took in the last frame, by system name
+func (sm *SystemManager) Timings() map[string]time.Duration {
+	result := make(map[string]time.Duration, len(sm.timings))
+	for name, elapsed := range sm.timings {
+		result[name] = elapsed
+	}
+	return result
+}
+
+// AverageTimings returns a rolling (exponential moving) average of Update durations by system name
+func (sm *SystemManager) AverageTimings() map[string]time.Duration {
+	result := make(map[string]time.Duration, len(sm.averages))
+	for name, elapsed := range sm.averages {
+		result[name] = elapsed
+	}
+	return result
+}
+
+// AccessProfile returns how many times each system's Update called Get, GetPtr,
+// Insert or Remove on each component pool since profiling was enabled, by system name
+func (sm *SystemManager) AccessProfile() map[string]map[ComponentID]int {
+	result := make(map[string]map[ComponentID]int, len(sm.accesses))
+	for name, counts := range sm.accesses {
+		copied := make(map[ComponentID]int, len(counts))
+		for id, count := range counts {
+			copied[id] = count
 		}
+		result[name] = copied
 	}
+	return result
 }
 
 // GetSystems returns all systems
@@ -71,6 +335,16 @@ func (sm *SystemManager) GetSystems() []System {
 	return sm.systems
 }
 
+// GetSystemByName returns the first system whose GetName matches, in insertion order
+func (sm *SystemManager) GetSystemByName(name string) (System, bool) {
+	for _, system := range sm.systems {
+		if system.GetName() == name {
+			return system, true
+		}
+	}
+	return nil, false
+}
+
 // GetEnabledSystems returns all enabled systems
 func (sm *SystemManager) GetEnabledSystems() []System {
 	enabled := make([]System, 0)
@@ -84,8 +358,15 @@ func (sm *SystemManager) GetEnabledSystems() []System {
 
 // Clear removes all systems
 func (sm *SystemManager) Clear() {
+	for _, system := range sm.systems {
+		if detacher, ok := system.(Detacher); ok {
+			detacher.Detach()
+		}
+	}
 	sm.systems = sm.systems[:0]
 	sm.enabled = make(map[System]bool)
+	sm.stages = make(map[System]string)
+	sm.ordered = nil
 }
 
 // BaseSystem provides a basic implementation of System interface
@@ -113,19 +394,21 @@ func (bs *BaseSystem) Update(world *World, deltaTime float64) {
 type System1[T1 any] struct {
 	*BaseSystem
 	updateFunc func(*World, float64, Entity, *T1)
+	options    []QueryOption
 }
 
 // NewSystem1 creates a new single-component system
-func NewSystem1[T1 any](name string, updateFunc func(*World, float64, Entity, *T1)) *System1[T1] {
+func NewSystem1[T1 any](name string, updateFunc func(*World, float64, Entity, *T1), options ...QueryOption) *System1[T1] {
 	return &System1[T1]{
 		BaseSystem: NewBaseSystem(name),
 		updateFunc: updateFunc,
+		options:    options,
 	}
 }
 
 // Update processes all entities with the required component
 func (s *System1[T1]) Update(world *World, deltaTime float64) {
-	Iter1[T1](world).ForEach(func(entity Entity, comp1 *T1) {
+	Iter1[T1](world, s.options...).ForEach(func(entity Entity, comp1 *T1) {
 		s.updateFunc(world, deltaTime, entity, comp1)
 	})
 }
@@ -134,19 +417,21 @@ func (s *System1[T1]) Update(world *World, deltaTime float64) {
 type System2[T1, T2 any] struct {
 	*BaseSystem
 	updateFunc func(*World, float64, Entity, *T1, *T2)
+	options    []QueryOption
 }
 
 // NewSystem2 creates a new two-component system
-func NewSystem2[T1, T2 any](name string, updateFunc func(*World, float64, Entity, *T1, *T2)) *System2[T1, T2] {
+func NewSystem2[T1, T2 any](name string, updateFunc func(*World, float64, Entity, *T1, *T2), options ...QueryOption) *System2[T1, T2] {
 	return &System2[T1, T2]{
 		BaseSystem: NewBaseSystem(name),
 		updateFunc: updateFunc,
+		options:    options,
 	}
 }
 
 // Update processes all entities with the required components
 func (s *System2[T1, T2]) Update(world *World, deltaTime float64) {
-	Iter2[T1, T2](world).ForEach(func(entity Entity, comp1 *T1, comp2 *T2) {
+	Iter2[T1, T2](world, s.options...).ForEach(func(entity Entity, comp1 *T1, comp2 *T2) {
 		s.updateFunc(world, deltaTime, entity, comp1, comp2)
 	})
 }
@@ -155,19 +440,67 @@ func (s *System2[T1, T2]) Update(world *World, deltaTime float64) {
 type System3[T1, T2, T3 any] struct {
 	*BaseSystem
 	updateFunc func(*World, float64, Entity, *T1, *T2, *T3)
+	options    []QueryOption
 }
 
 // NewSystem3 creates a new three-component system
-func NewSystem3[T1, T2, T3 any](name string, updateFunc func(*World, float64, Entity, *T1, *T2, *T3)) *System3[T1, T2, T3] {
+func NewSystem3[T1, T2, T3 any](name string, updateFunc func(*World, float64, Entity, *T1, *T2, *T3), options ...QueryOption) *System3[T1, T2, T3] {
 	return &System3[T1, T2, T3]{
 		BaseSystem: NewBaseSystem(name),
 		updateFunc: updateFunc,
+		options:    options,
 	}
 }
 
 // Update processes all entities with the required components
 func (s *System3[T1, T2, T3]) Update(world *World, deltaTime float64) {
-	Iter3[T1, T2, T3](world).ForEach(func(entity Entity, comp1 *T1, comp2 *T2, comp3 *T3) {
+	Iter3[T1, T2, T3](world, s.options...).ForEach(func(entity Entity, comp1 *T1, comp2 *T2, comp3 *T3) {
 		s.updateFunc(world, deltaTime, entity, comp1, comp2, comp3)
 	})
 }
+
+// System4 is a convenience system that processes entities with four component types
+type System4[T1, T2, T3, T4 any] struct {
+	*BaseSystem
+	updateFunc func(*World, float64, Entity, *T1, *T2, *T3, *T4)
+	options    []QueryOption
+}
+
+// NewSystem4 creates a new four-component system
+func NewSystem4[T1, T2, T3, T4 any](name string, updateFunc func(*World, float64, Entity, *T1, *T2, *T3, *T4), options ...QueryOption) *System4[T1, T2, T3, T4] {
+	return &System4[T1, T2, T3, T4]{
+		BaseSystem: NewBaseSystem(name),
+		updateFunc: updateFunc,
+		options:    options,
+	}
+}
+
+// Update processes all entities with the required components
+func (s *System4[T1, T2, T3, T4]) Update(world *World, deltaTime float64) {
+	Iter4[T1, T2, T3, T4](world, s.options...).ForEach(func(entity Entity, comp1 *T1, comp2 *T2, comp3 *T3, comp4 *T4) {
+		s.updateFunc(world, deltaTime, entity, comp1, comp2, comp3, comp4)
+	})
+}
+
+// System5 is a convenience system that processes entities with five component types
+type System5[T1, T2, T3, T4, T5 any] struct {
+	*BaseSystem
+	updateFunc func(*World, float64, Entity, *T1, *T2, *T3, *T4, *T5)
+	options    []QueryOption
+}
+
+// NewSystem5 creates a new five-component system
+func NewSystem5[T1, T2, T3, T4, T5 any](name string, updateFunc func(*World, float64, Entity, *T1, *T2, *T3, *T4, *T5), options ...QueryOption) *System5[T1, T2, T3, T4, T5] {
+	return &System5[T1, T2, T3, T4, T5]{
+		BaseSystem: NewBaseSystem(name),
+		updateFunc: updateFunc,
+		options:    options,
+	}
+}
+
+// Update processes all entities with the required components
+func (s *System5[T1, T2, T3, T4, T5]) Update(world *World, deltaTime float64) {
+	Iter5[T1, T2, T3, T4, T5](world, s.options...).ForEach(func(entity Entity, comp1 *T1, comp2 *T2, comp3 *T3, comp4 *T4, comp5 *T5) {
+		s.updateFunc(world, deltaTime, entity, comp1, comp2, comp3, comp4, comp5)
+	})
+}
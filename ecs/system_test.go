@@ -0,0 +1,402 @@
+package ecs
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// testRecorder is a system that appends "<name>:update" to a shared call log
+type testRecorder struct {
+	name  string
+	calls *[]string
+}
+
+func (r *testRecorder) Update(w *World, deltaTime float64) {
+	*r.calls = append(*r.calls, r.name+":update")
+}
+
+func (r *testRecorder) GetName() string { return r.name }
+
+func TestSystemByNameToggling(t *testing.T) {
+	w := NewWorld()
+	var calls []string
+	first := &testRecorder{name: "physics", calls: &calls}
+	w.AddSystem(first)
+	w.AddSystem(&testRecorder{name: "render", calls: &calls})
+	// Duplicate names resolve to the first system added
+	w.AddSystem(&testRecorder{name: "physics", calls: &calls})
+
+	if system, ok := w.systemManager.GetSystemByName("physics"); !ok || system != first {
+		t.Fatalf("GetSystemByName = %v, %v, want the first physics system", system, ok)
+	}
+	if _, ok := w.systemManager.GetSystemByName("audio"); ok {
+		t.Fatalf("GetSystemByName found a missing system")
+	}
+
+	if !w.SetSystemEnabledByName("render", false) || w.SetSystemEnabledByName("audio", false) {
+		t.Fatalf("SetSystemEnabledByName should succeed only for known names")
+	}
+	w.Update(0)
+	if want := []string{"physics:update", "physics:update"}; !slices.Equal(calls, want) {
+		t.Fatalf("calls with render disabled = %v, want %v", calls, want)
+	}
+
+	calls = nil
+	w.SetSystemEnabledByName("render", true)
+	w.SetSystemEnabledByName("physics", false)
+	w.Update(0)
+	if want := []string{"render:update", "physics:update"}; !slices.Equal(calls, want) {
+		t.Fatalf("calls after toggling = %v, want %v", calls, want)
+	}
+}
+
+// testPhasedRecorder also records the PreUpdate and PostUpdate phases
+type testPhasedRecorder struct {
+	testRecorder
+}
+
+func (r *testPhasedRecorder) PreUpdate(w *World, deltaTime float64) {
+	*r.calls = append(*r.calls, r.name+":pre")
+}
+
+func (r *testPhasedRecorder) PostUpdate(w *World, deltaTime float64) {
+	*r.calls = append(*r.calls, r.name+":post")
+}
+
+func TestSystemPhasesRunInOrder(t *testing.T) {
+	w := NewWorld()
+	var calls []string
+	a := &testPhasedRecorder{testRecorder{name: "a", calls: &calls}}
+	w.AddSystem(a)
+	w.AddSystem(&testRecorder{name: "plain", calls: &calls})
+	w.AddSystem(&testPhasedRecorder{testRecorder{name: "b", calls: &calls}})
+
+	w.Update(0)
+	want := []string{"a:pre", "b:pre", "a:update", "plain:update", "b:update", "a:post", "b:post"}
+	if !slices.Equal(calls, want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+
+	// Disabled systems skip every phase
+	calls = nil
+	w.systemManager.DisableSystem(a)
+	w.Update(0)
+	want = []string{"b:pre", "plain:update", "b:update", "b:post"}
+	if !slices.Equal(calls, want) {
+		t.Fatalf("calls with a disabled = %v, want %v", calls, want)
+	}
+}
+
+func TestSystemStagesRunInConfiguredOrder(t *testing.T) {
+	w := NewWorld()
+	var calls []string
+	w.AddSystemToStage("render", &testRecorder{name: "draw", calls: &calls})
+	w.AddSystemToStage("simulation", &testRecorder{name: "physics", calls: &calls})
+	w.AddSystemToStage("input", &testRecorder{name: "keyboard", calls: &calls})
+	w.AddSystemToStage("simulation", &testRecorder{name: "ai", calls: &calls})
+	w.AddSystem(&testRecorder{name: "default", calls: &calls})
+	w.AddSystemToStage("debug", &testRecorder{name: "overlay", calls: &calls})
+	w.systemManager.SetStageOrder([]string{"input", "simulation", "render"})
+
+	w.Update(0)
+	// The default stage runs first when unlisted, and unlisted stages are skipped
+	want := []string{"default:update", "keyboard:update", "physics:update", "ai:update", "draw:update"}
+	if !slices.Equal(calls, want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+
+	calls = nil
+	w.systemManager.SetStageOrder([]string{"render", DefaultStage, "debug"})
+	w.Update(0)
+	want = []string{"draw:update", "default:update", "overlay:update"}
+	if !slices.Equal(calls, want) {
+		t.Fatalf("calls after reordering = %v, want %v", calls, want)
+	}
+}
+
+// testSleeper is a system whose Update takes at least delay
+type testSleeper struct {
+	name  string
+	delay time.Duration
+}
+
+func (s *testSleeper) Update(w *World, deltaTime float64) {
+	time.Sleep(s.delay)
+}
+
+func (s *testSleeper) GetName() string { return s.name }
+
+func TestSystemProfilingTimesUpdates(t *testing.T) {
+	w := NewWorld()
+	w.AddSystem(&testSleeper{name: "slow", delay: 5 * time.Millisecond})
+	w.AddSystem(&testSleeper{name: "fast"})
+
+	w.Update(0)
+	if timings := w.systemManager.Timings(); len(timings) != 0 {
+		t.Fatalf("timings collected with profiling off: %v", timings)
+	}
+
+	w.systemManager.EnableProfiling(true)
+	w.Update(0)
+	w.Update(0)
+	timings := w.systemManager.Timings()
+	if timings["slow"] < 5*time.Millisecond || timings["slow"] <= timings["fast"] {
+		t.Fatalf("timings = %v, want slow at least 5ms and above fast", timings)
+	}
+	averages := w.systemManager.AverageTimings()
+	if averages["slow"] <= averages["fast"] {
+		t.Fatalf("average timings = %v, want slow above fast", averages)
+	}
+}
+
+func TestRemoveSystemByName(t *testing.T) {
+	w := NewWorld()
+	var calls []string
+	first := &testRecorder{name: "ai", calls: &calls}
+	second := &testRecorder{name: "ai", calls: &calls}
+	w.AddSystem(first)
+	w.AddSystem(&testRecorder{name: "physics", calls: &calls})
+	w.AddSystem(second)
+
+	if !w.RemoveSystemByName("ai") {
+		t.Fatalf("RemoveSystemByName returned false for an existing name")
+	}
+	if w.RemoveSystemByName("audio") {
+		t.Fatalf("RemoveSystemByName returned true for a missing name")
+	}
+	if systems := w.systemManager.GetSystems(); len(systems) != 2 || systems[1] != second {
+		t.Fatalf("systems = %v, want physics and the second ai", systems)
+	}
+	if w.systemManager.IsEnabled(first) {
+		t.Fatalf("removed system still marked enabled")
+	}
+
+	w.Update(0)
+	if want := []string{"physics:update", "ai:update"}; !slices.Equal(calls, want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestDuplicateSystemNamePolicies(t *testing.T) {
+	w := NewWorld()
+	var calls, warnings []string
+	w.SetLogger(func(level, msg string) {
+		if level == LogLevelWarn {
+			warnings = append(warnings, msg)
+		}
+	})
+	first := &testRecorder{name: "ai", calls: &calls}
+	if !w.AddSystem(first) || w.AddSystem(first) {
+		t.Fatalf("AddSystem should accept a system once")
+	}
+
+	w.systemManager.SetDuplicateNamePolicy(DuplicateNamesReject)
+	warnings = nil
+	if w.AddSystem(&testRecorder{name: "ai", calls: &calls}) {
+		t.Fatalf("AddSystem accepted a duplicate name under DuplicateNamesReject")
+	}
+	if len(w.systemManager.GetSystems()) != 1 || len(warnings) != 1 {
+		t.Fatalf("rejecting kept %d systems and logged %v", len(w.systemManager.GetSystems()), warnings)
+	}
+
+	w.systemManager.SetDuplicateNamePolicy(DuplicateNamesWarn)
+	warnings = nil
+	second := &testRecorder{name: "ai", calls: &calls}
+	if !w.AddSystem(second) || len(warnings) != 1 {
+		t.Fatalf("DuplicateNamesWarn should add the system and warn once, logged %v", warnings)
+	}
+
+	// Removing by name is deterministic: the first added goes, then the second
+	w.RemoveSystemByName("ai")
+	if systems := w.systemManager.GetSystems(); len(systems) != 1 || systems[0] != second {
+		t.Fatalf("systems after the first removal = %v, want the second ai", systems)
+	}
+	// A removed instance can be added again
+	if !w.AddSystem(first) {
+		t.Fatalf("re-adding a removed system failed")
+	}
+	w.RemoveSystemByName("ai")
+	if systems := w.systemManager.GetSystems(); len(systems) != 1 || systems[0] != first {
+		t.Fatalf("systems after the second removal = %v, want the re-added first ai", systems)
+	}
+}
+
+// testMover reads positions and velocities through the world and writes positions
+type testMover struct {
+	entities []Entity
+}
+
+func (m *testMover) Update(w *World, deltaTime float64) {
+	for _, entity := range m.entities {
+		vel, _ := GetComponent[testVelocity](w, entity)
+		pos, _ := GetComponent[testPosition](w, entity)
+		pos.X += vel.X
+		AddComponent(w, entity, pos)
+	}
+}
+
+func (m *testMover) GetName() string { return "mover" }
+
+func TestSystemAccessProfileAttribution(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 3)
+	position, _ := GetComponentID[testPosition](w.componentRegistry)
+	velocity, _ := GetComponentID[testVelocity](w.componentRegistry)
+	Register[testHealth](w.componentRegistry)
+	w.AddSystem(&testMover{entities: entities})
+	var calls []string
+	w.AddSystem(&testRecorder{name: "idle", calls: &calls})
+
+	w.systemManager.EnableProfiling(true)
+	// Accesses outside a system are not attributed
+	GetComponent[testHealth](w, entities[0])
+	w.Update(0)
+
+	profile := w.SystemAccessProfile()
+	mover := profile["mover"]
+	// One Get and one Insert of the position, one Get of the velocity per entity
+	if mover[position] != 6 || mover[velocity] != 3 || len(mover) != 2 {
+		t.Fatalf("mover accesses = %v, want %d: 6 and %d: 3", mover, position, velocity)
+	}
+	if len(profile["idle"]) != 0 {
+		t.Fatalf("idle system attributed accesses %v", profile["idle"])
+	}
+
+	w.systemManager.EnableProfiling(false)
+	w.Update(0)
+	if got := w.SystemAccessProfile()["mover"][position]; got != 6 {
+		t.Fatalf("accesses counted with profiling off: %d", got)
+	}
+}
+
+func TestSystem4And5RunForMatchingEntities(t *testing.T) {
+	w := NewWorld()
+	entities := make([]Entity, 4)
+	for i := range entities {
+		entities[i] = w.CreateEntity()
+		AddComponent(w, entities[i], testPosition{})
+		AddComponent(w, entities[i], testVelocity{X: 1})
+		AddComponent(w, entities[i], testHealth{HP: i})
+	}
+	// Only the first two hold all four components, only the first all five
+	AddComponent(w, entities[0], testName{Value: "a"})
+	AddComponent(w, entities[1], testName{Value: "b"})
+	AddComponent(w, entities[0], testSettings{Volume: 1})
+	AddComponent(w, entities[2], testSettings{Volume: 1})
+
+	var four, five []Entity
+	w.AddSystem(NewSystem4("four", func(w *World, dt float64, e Entity, p *testPosition, v *testVelocity, h *testHealth, n *testName) {
+		p.X += v.X
+		four = append(four, e)
+	}))
+	w.AddSystem(NewSystem5("five", func(w *World, dt float64, e Entity, p *testPosition, v *testVelocity, h *testHealth, n *testName, s *testSettings) {
+		five = append(five, e)
+	}))
+	w.Update(0)
+
+	if got := sortedByIndex(four); !slices.Equal(got, entities[:2]) {
+		t.Fatalf("System4 ran for %v, want %v", got, entities[:2])
+	}
+	if !slices.Equal(five, entities[:1]) {
+		t.Fatalf("System5 ran for %v, want %v", five, entities[:1])
+	}
+	if p, _ := GetComponent[testPosition](w, entities[1]); p.X != 1 {
+		t.Fatalf("System4 did not update components through its pointers, X = %v", p.X)
+	}
+	if p, _ := GetComponent[testPosition](w, entities[2]); p.X != 0 {
+		t.Fatalf("System4 updated a non-matching entity, X = %v", p.X)
+	}
+}
+
+func TestSystemExcludingSkipsTaggedEntities(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 6)
+	for _, e := range entities[:3] {
+		AddComponent(w, e, testTag{})
+	}
+
+	var one, two []Entity
+	w.AddSystem(NewSystem1("one", func(w *World, dt float64, e Entity, p *testPosition) {
+		one = append(one, e)
+	}, Excluding[testTag]()))
+	w.AddSystem(NewSystem2("two", func(w *World, dt float64, e Entity, p *testPosition, v *testVelocity) {
+		two = append(two, e)
+	}, Excluding[testTag]()))
+	w.Update(0)
+
+	if got := sortedByIndex(one); !slices.Equal(got, entities[3:]) {
+		t.Fatalf("System1 excluding the tag ran for %v, want %v", got, entities[3:])
+	}
+	// Of the moving entities 0, 2 and 4, only 4 is untagged
+	if !slices.Equal(two, []Entity{entities[4]}) {
+		t.Fatalf("System2 excluding the tag ran for %v, want %v", two, entities[4:5])
+	}
+
+	// Removing the tag makes the entity eligible again on the next update
+	RemoveComponent[testTag](w, entities[2])
+	two = nil
+	w.Update(0)
+	if got := sortedByIndex(two); !slices.Equal(got, []Entity{entities[2], entities[4]}) {
+		t.Fatalf("System2 after untagging ran for %v", got)
+	}
+}
+
+// testPanicker is a system whose Update always panics
+type testPanicker struct{}
+
+func (testPanicker) Update(w *World, deltaTime float64) { panic("boom") }
+
+func (testPanicker) GetName() string { return "panicker" }
+
+func TestRecoverPanicsIsolatesSystems(t *testing.T) {
+	w := NewWorld()
+	var calls []string
+	w.AddSystem(&testRecorder{name: "before", calls: &calls})
+	w.AddSystem(testPanicker{})
+	w.AddSystem(&testRecorder{name: "after", calls: &calls})
+
+	var handled []string
+	w.GetSystemManager().RecoverPanics(true)
+	w.GetSystemManager().SetPanicHandler(func(system string, recovered any) {
+		handled = append(handled, system+":"+recovered.(string))
+	})
+	w.Update(0)
+
+	if want := []string{"before:update", "after:update"}; !slices.Equal(calls, want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+	if want := []string{"panicker:boom"}; !slices.Equal(handled, want) {
+		t.Fatalf("panic handler received %v, want %v", handled, want)
+	}
+
+	// Without a handler the panic goes to the world's logger
+	var logged []string
+	w.SetLogger(func(level, msg string) { logged = append(logged, level) })
+	w.GetSystemManager().SetPanicHandler(nil)
+	w.Update(0)
+	if !slices.Contains(logged, LogLevelError) {
+		t.Fatalf("recovered panic was not logged at error level, got %v", logged)
+	}
+}
+
+func TestPanicsPropagateByDefault(t *testing.T) {
+	w := NewWorld()
+	w.AddSystem(testPanicker{})
+	expectPanic(t, "boom", func() { w.Update(0) })
+}
+
+func TestPanickingSystemRemovesAccessHook(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 2)
+	w.AddSystem(testPanicker{})
+	w.systemManager.EnableProfiling(true)
+	expectPanic(t, "boom", func() { w.Update(0) })
+
+	// With the hook left behind these would be charged to the dead system
+	GetComponent[testPosition](w, entities[0])
+	GetComponent[testVelocity](w, entities[0])
+	if accesses := w.SystemAccessProfile()["panicker"]; len(accesses) != 0 {
+		t.Fatalf("accesses after the panic were attributed to it: %v", accesses)
+	}
+}
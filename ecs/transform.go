@@ -0,0 +1,209 @@
+package ecs
+
+import (
+	"fmt"
+	"math"
+)
+
+// ChildOf is the relation linking a child entity to its parent, see SetParent
+type ChildOf struct{}
+
+// SetParent makes child a child of parent, replacing its previous parent
+// Returns an error if either entity is invalid or parent is child or one of its descendants
+func SetParent(w *World, child, parent Entity) error {
+	if !w.entityManager.IsValid(child) || !w.entityManager.IsValid(parent) {
+		return fmt.Errorf("%w: SetParent of %s to %s", ErrInvalidEntity, child, parent)
+	}
+	for ancestor, ok := parent, true; ok; ancestor, ok = Parent(w, ancestor) {
+		if ancestor == child {
+			return fmt.Errorf("ecs: parenting %s to %s would create a cycle", child, parent)
+		}
+	}
+
+	RemoveParent(w, child)
+	AddRelation[ChildOf](w, child, parent)
+	return nil
+}
+
+// RemoveParent detaches child from its parent, making it a root
+// Returns false if it had no parent
+func RemoveParent(w *World, child Entity) bool {
+	parent, exists := Parent(w, child)
+	return exists && RemoveRelation[ChildOf](w, child, parent)
+}
+
+// Parent returns the parent of an entity, if it has one
+func Parent(w *World, child Entity) (Entity, bool) {
+	store := relationsOf[ChildOf](w, false)
+	if store == nil || store.from[child] == nil {
+		return NullEntity, false
+	}
+	return store.from[child].At(0), true
+}
+
+// Children returns the direct children of an entity
+func Children(w *World, parent Entity) []Entity {
+	return RelationsTo[ChildOf](w, parent)
+}
+
+// DestroyEntityRecursive destroys an entity and all its descendants, children before
+// their parents, so OnEntityDestroyed callbacks run for every destroyed entity while
+// its components and its parent are still in place
+// Returns the number of entities destroyed
+func (w *World) DestroyEntityRecursive(entity Entity) int {
+	if !w.entityManager.IsValid(entity) {
+		w.logf(LogLevelWarn, "DestroyEntityRecursive on invalid entity %s ignored", entity)
+		return 0
+	}
+
+	// Walk the hierarchy depth-first; reversing the pre-order visit puts every
+	// child before its parent
+	order := make([]Entity, 0)
+	stack := []Entity{entity}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		order = append(order, current)
+		stack = append(stack, Children(w, current)...)
+	}
+
+	destroyed := 0
+	for i := len(order) - 1; i >= 0; i-- {
+		if w.DestroyEntity(order[i]) {
+			destroyed++
+		}
+	}
+	return destroyed
+}
+
+// Transform is a 2D position, rotation in radians and uniform scale
+type Transform struct {
+	X, Y     float64
+	Rotation float64
+	Scale    float64
+}
+
+// NewTransform creates an unrotated, unscaled transform at a position
+func NewTransform(x, y float64) Transform {
+	return Transform{X: x, Y: y, Scale: 1}
+}
+
+// Compose returns child expressed in the space t is expressed in, i.e. t applied after child
+func (t Transform) Compose(child Transform) Transform {
+	sin, cos := math.Sincos(t.Rotation)
+	x, y := child.X*t.Scale, child.Y*t.Scale
+	return Transform{
+		X:        t.X + x*cos - y*sin,
+		Y:        t.Y + x*sin + y*cos,
+		Rotation: t.Rotation + child.Rotation,
+		Scale:    t.Scale * child.Scale,
+	}
+}
+
+// LocalTransform is an entity's transform relative to its parent, or to the world for roots
+type LocalTransform struct {
+	Transform
+}
+
+// WorldTransform is an entity's transform in world space, written by TransformSystem
+type WorldTransform struct {
+	Transform
+	parent Entity // Parent the transform was computed under, to notice reparenting
+}
+
+// TransformSystem computes WorldTransform from LocalTransform down the parent/child
+// hierarchy, parents before children
+// Only entities whose LocalTransform was added, replaced or marked changed
+// (GetComponentMut, MarkChanged), that were reparented, or whose parent was
+// recomputed are recomputed; a parent without LocalTransform counts as no parent
+// The system consumes the LocalTransform change flags of the entities it recomputes
+type TransformSystem struct {
+	*BaseSystem
+	pool  *ComponentPool[LocalTransform] // Pool tracked into dirty, nil when detached
+	dirty *SparseSet                     // Entities whose LocalTransform was added or replaced
+	stack []transformStep                // Pending entities of the current pass
+}
+
+// transformStep is an entity waiting to be propagated to, with its parent's result
+type transformStep struct {
+	entity, parent Entity
+	parentWorld    Transform // Ignored for roots (parent NullEntity)
+	parentChanged  bool
+}
+
+// NewTransformSystem creates a transform propagation system
+func NewTransformSystem() *TransformSystem {
+	return &TransformSystem{
+		BaseSystem: NewBaseSystem("TransformSystem"),
+		dirty:      NewSparseSet(),
+	}
+}
+
+// Update recomputes the world transforms that are out of date
+func (s *TransformSystem) Update(world *World, deltaTime float64) {
+	Register[LocalTransform](world.componentRegistry)
+	pool, _ := GetStorage[LocalTransform](world.componentRegistry)
+	if pool != s.pool {
+		// First update, or the type was registered anew: move over and recompute everything
+		s.Detach()
+		s.pool = pool
+		pool.track(s.dirty)
+		for _, entity := range pool.Entities().Data() {
+			s.dirty.Insert(entity)
+		}
+	}
+
+	// Roots first; children are pushed after their parent so parents always come first
+	s.stack = s.stack[:0]
+	for _, entity := range pool.Entities().Data() {
+		if parent, exists := Parent(world, entity); !exists || !pool.Contains(parent) {
+			s.stack = append(s.stack, transformStep{entity: entity, parent: NullEntity})
+		}
+	}
+	store := relationsOf[ChildOf](world, false)
+	for len(s.stack) > 0 {
+		step := s.stack[len(s.stack)-1]
+		s.stack = s.stack[:len(s.stack)-1]
+		computed, changed := s.update(world, step)
+
+		if store == nil || store.to[step.entity] == nil {
+			continue
+		}
+		for _, child := range store.to[step.entity].Data() {
+			if pool.Contains(child) {
+				s.stack = append(s.stack, transformStep{child, step.entity, computed, changed})
+			}
+		}
+	}
+	s.dirty.Clear()
+}
+
+// update recomputes an entity's world transform if it is out of date, returning it
+// and whether it changed
+func (s *TransformSystem) update(world *World, step transformStep) (Transform, bool) {
+	current := GetComponentPtr[WorldTransform](world, step.entity)
+	changed := step.parentChanged || s.dirty.Contains(step.entity) || current == nil || current.parent != step.parent
+	if s.pool.changed != nil && s.pool.changed.Remove(step.entity) {
+		changed = true
+	}
+	if !changed {
+		return current.Transform, false
+	}
+
+	computed := s.pool.GetPtr(step.entity).Transform
+	if step.parent != NullEntity {
+		computed = step.parentWorld.Compose(computed)
+	}
+	AddComponent(world, step.entity, WorldTransform{Transform: computed, parent: step.parent})
+	return computed, true
+}
+
+// Detach stops tracking LocalTransform changes, called when the system is removed
+// The next Update attaches again and recomputes every world transform
+func (s *TransformSystem) Detach() {
+	if s.pool != nil {
+		s.pool.untrack(s.dirty)
+		s.pool = nil
+	}
+	s.dirty.Clear()
+}
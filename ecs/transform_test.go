@@ -0,0 +1,185 @@
+package ecs
+
+import (
+	"maps"
+	"math"
+	"testing"
+)
+
+// expectWorldTransform checks an entity's world position, rotation and scale
+func expectWorldTransform(t *testing.T, w *World, entity Entity, want Transform) {
+	t.Helper()
+	got, ok := GetComponent[WorldTransform](w, entity)
+	if !ok {
+		t.Fatalf("%s has no WorldTransform", entity)
+	}
+	const epsilon = 1e-9
+	if math.Abs(got.X-want.X) > epsilon || math.Abs(got.Y-want.Y) > epsilon ||
+		math.Abs(got.Rotation-want.Rotation) > epsilon || math.Abs(got.Scale-want.Scale) > epsilon {
+		t.Fatalf("%s world transform = %+v, want %+v", entity, got.Transform, want)
+	}
+}
+
+func TestTransformSystemThreeLevels(t *testing.T) {
+	w := NewWorld()
+	root, child, grandchild := w.CreateEntity(), w.CreateEntity(), w.CreateEntity()
+	// Added children first so storage order isn't parent-before-child
+	AddComponent(w, grandchild, LocalTransform{NewTransform(0, 1)})
+	AddComponent(w, child, LocalTransform{NewTransform(1, 0)})
+	AddComponent(w, root, LocalTransform{Transform{X: 10, Rotation: math.Pi / 2, Scale: 2}})
+	if err := SetParent(w, child, root); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetParent(w, grandchild, child); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewTransformSystem()
+	s.Update(w, 0)
+	expectWorldTransform(t, w, root, Transform{X: 10, Rotation: math.Pi / 2, Scale: 2})
+	expectWorldTransform(t, w, child, Transform{X: 10, Y: 2, Rotation: math.Pi / 2, Scale: 2})
+	expectWorldTransform(t, w, grandchild, Transform{X: 8, Y: 2, Rotation: math.Pi / 2, Scale: 2})
+
+	// Moving the root through change detection carries its descendants along
+	GetComponentMut[LocalTransform](w, root).Y = 5
+	s.Update(w, 0)
+	expectWorldTransform(t, w, child, Transform{X: 10, Y: 7, Rotation: math.Pi / 2, Scale: 2})
+	expectWorldTransform(t, w, grandchild, Transform{X: 8, Y: 7, Rotation: math.Pi / 2, Scale: 2})
+
+	// Reparenting recomputes under the new parent, detaching makes a root
+	if err := SetParent(w, grandchild, root); err != nil {
+		t.Fatal(err)
+	}
+	s.Update(w, 0)
+	expectWorldTransform(t, w, grandchild, Transform{X: 8, Y: 5, Rotation: math.Pi / 2, Scale: 2})
+	RemoveParent(w, child)
+	s.Update(w, 0)
+	expectWorldTransform(t, w, child, NewTransform(1, 0))
+
+	if err := SetParent(w, root, grandchild); err == nil {
+		t.Fatalf("parenting the root to its grandchild was allowed")
+	}
+}
+
+func TestDestroyEntityRecursiveRunsCallbacks(t *testing.T) {
+	w := NewWorld()
+	root, child, other := w.CreateEntity(), w.CreateEntity(), w.CreateEntity()
+	grandchildren := []Entity{w.CreateEntity(), w.CreateEntity()}
+	bystander := w.CreateEntity()
+	for _, link := range [][2]Entity{{child, root}, {other, root}, {grandchildren[0], child}, {grandchildren[1], child}} {
+		if err := SetParent(w, link[0], link[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, e := range []Entity{root, child, other, grandchildren[0], grandchildren[1], bystander} {
+		AddComponent(w, e, testHealth{HP: i})
+	}
+
+	seen := make(map[Entity]int)
+	w.OnEntityDestroyed(func(e Entity) {
+		h, ok := GetComponent[testHealth](w, e)
+		if !ok {
+			t.Fatalf("callback for %s ran after its components were removed", e)
+		}
+		// Children go first, so their parent is still attached
+		if e != root {
+			if _, hasParent := Parent(w, e); !hasParent {
+				t.Fatalf("callback for %s ran after its parent was destroyed", e)
+			}
+		}
+		seen[e] = h.HP
+	})
+
+	if n := w.DestroyEntityRecursive(root); n != 5 {
+		t.Fatalf("DestroyEntityRecursive destroyed %d entities, want 5", n)
+	}
+	want := map[Entity]int{root: 0, child: 1, other: 2, grandchildren[0]: 3, grandchildren[1]: 4}
+	if !maps.Equal(seen, want) {
+		t.Fatalf("callbacks saw %v, want %v", seen, want)
+	}
+	if !w.IsValidEntity(bystander) || w.IsValidEntity(grandchildren[1]) {
+		t.Fatalf("DestroyEntityRecursive destroyed outside the subtree or missed a grandchild")
+	}
+	if w.DestroyEntityRecursive(root) != 0 {
+		t.Fatalf("destroying a destroyed root again succeeded")
+	}
+}
+
+func TestTransformSystemFollowsReregisteredPool(t *testing.T) {
+	w := NewWorld()
+	parent, child := w.CreateEntity(), w.CreateEntity()
+	AddComponent(w, parent, LocalTransform{NewTransform(1, 0)})
+	AddComponent(w, child, LocalTransform{NewTransform(0, 1)})
+	if err := SetParent(w, child, parent); err != nil {
+		t.Fatal(err)
+	}
+	s := NewTransformSystem()
+	w.AddSystem(s)
+	w.Update(0)
+	old, _ := GetStorage[LocalTransform](w.componentRegistry)
+
+	// The type is dropped and registered anew, the system moves to the new pool
+	Unregister[LocalTransform](w.componentRegistry)
+	AddComponent(w, parent, LocalTransform{NewTransform(5, 5)})
+	AddComponent(w, child, LocalTransform{NewTransform(0, 1)})
+	w.Update(0)
+	expectWorldTransform(t, w, child, NewTransform(5, 6))
+	pool, _ := GetStorage[LocalTransform](w.componentRegistry)
+	if len(old.trackers) != 0 || len(pool.trackers) != 1 {
+		t.Fatalf("old pool has %d trackers, new pool %d, want 0 and 1", len(old.trackers), len(pool.trackers))
+	}
+
+	// Removing the system detaches it
+	w.RemoveSystem(s)
+	if len(pool.trackers) != 0 {
+		t.Fatalf("removed system still tracks the pool")
+	}
+	AddComponent(w, parent, LocalTransform{NewTransform(7, 7)})
+	if s.dirty.Size() != 0 {
+		t.Fatalf("removed system recorded %d changes", s.dirty.Size())
+	}
+}
+
+func TestTransformSystemConsumesChangeFlags(t *testing.T) {
+	w := NewWorld()
+	root, child := w.CreateEntity(), w.CreateEntity()
+	AddComponent(w, root, LocalTransform{NewTransform(0, 0)})
+	AddComponent(w, child, LocalTransform{NewTransform(1, 0)})
+	if err := SetParent(w, child, root); err != nil {
+		t.Fatal(err)
+	}
+	s := NewTransformSystem()
+	s.Update(w, 0)
+
+	recomputed := 0
+	OnReplace(w, func(Entity, WorldTransform, WorldTransform) { recomputed++ })
+	GetComponentMut[LocalTransform](w, root).X = 3
+	s.Update(w, 0)
+	if recomputed != 2 {
+		t.Fatalf("recomputed %d transforms after moving the root, want 2", recomputed)
+	}
+	expectWorldTransform(t, w, child, NewTransform(4, 0))
+
+	// The consumed flag doesn't make the subtree recompute again
+	s.Update(w, 0)
+	if recomputed != 2 || len(ChangedThisFrame[LocalTransform](w)) != 0 {
+		t.Fatalf("recomputed %d transforms without changes, flags %v", recomputed, ChangedThisFrame[LocalTransform](w))
+	}
+}
+
+func TestTransformSystemDeepHierarchy(t *testing.T) {
+	w := NewWorld()
+	const depth = 2000
+	entities := make([]Entity, depth)
+	for i := range entities {
+		entities[i] = w.CreateEntity()
+		AddComponent(w, entities[i], LocalTransform{NewTransform(1, 0)})
+		if i > 0 {
+			if err := SetParent(w, entities[i], entities[i-1]); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	NewTransformSystem().Update(w, 0)
+	expectWorldTransform(t, w, entities[depth-1], NewTransform(depth, 0))
+}
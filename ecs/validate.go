@@ -0,0 +1,104 @@
+package ecs
+
+import "fmt"
+
+// validate checks that the dense and sparse arrays map to each other one to one
+func (ss *SparseSet) validate() []error {
+	var errs []error
+	for i, entity := range ss.Data() {
+		if !entity.IsValid() {
+			errs = append(errs, fmt.Errorf("dense[%d] holds the null entity", i))
+			continue
+		}
+		if dense := ss.sparseIndex(entity.Index()); dense != int32(i) {
+			errs = append(errs, fmt.Errorf("dense[%d] is %s but its sparse slot points to %d", i, entity, dense))
+		}
+	}
+
+	for page, slots := range ss.sparse {
+		for slot, dense := range slots {
+			if dense < 0 {
+				continue
+			}
+			index := uint32(page*sparsePageSize + slot)
+			if int(dense) >= ss.size || ss.dense[dense].Index() != index {
+				errs = append(errs, fmt.Errorf("sparse slot %d points to dense %d, which doesn't hold it", index, dense))
+			}
+		}
+	}
+	return errs
+}
+
+// validate checks the live count, the free list and that no index is both alive and free
+func (em *EntityManager) validate() []error {
+	var errs []error
+	if len(em.alive) != len(em.entities) {
+		return append(errs, fmt.Errorf("%d liveness flags for %d entity slots", len(em.alive), len(em.entities)))
+	}
+
+	live := 0
+	for _, alive := range em.alive {
+		if alive {
+			live++
+		}
+	}
+	if live != em.live {
+		errs = append(errs, fmt.Errorf("live count is %d but %d slots are alive", em.live, live))
+	}
+
+	freed := make(map[uint32]bool, len(em.free))
+	for _, index := range em.free {
+		switch {
+		case index >= uint32(len(em.entities)):
+			errs = append(errs, fmt.Errorf("free index %d is out of range", index))
+		case em.alive[index]:
+			errs = append(errs, fmt.Errorf("free index %d is alive", index))
+		case freed[index]:
+			errs = append(errs, fmt.Errorf("free index %d is listed twice", index))
+		}
+		freed[index] = true
+	}
+	return errs
+}
+
+// Validate checks the world's internal invariants and returns every inconsistency
+// found, or nil: the entity manager's bookkeeping, each pool's dense/sparse mapping
+// and component slots, that pools and the disabled set only hold live entities,
+// and that entity signatures match pool membership
+// It walks all storage, so it is meant for debugging and tests, not every frame
+func (w *World) Validate() []error {
+	var errs []error
+	for _, err := range w.entityManager.validate() {
+		errs = append(errs, fmt.Errorf("ecs: entities: %w", err))
+	}
+
+	registry := w.componentRegistry
+	for _, id := range registry.order {
+		storage := registry.storages[id]
+		name := registry.names[id]
+		for _, err := range storage.validate() {
+			errs = append(errs, fmt.Errorf("ecs: pool %s: %w", name, err))
+		}
+
+		for _, entity := range storage.Entities().Data() {
+			if !w.entityManager.IsValid(entity) {
+				errs = append(errs, fmt.Errorf("ecs: pool %s holds dead entity %s", name, entity))
+				continue
+			}
+			row := registry.signatures.row(entity.Index())
+			if row == nil || int(id)/64 >= len(row) || row[id/64]&(1<<(id%64)) == 0 {
+				errs = append(errs, fmt.Errorf("ecs: pool %s holds %s but its signature lacks the component", name, entity))
+			}
+		}
+	}
+
+	for _, err := range w.disabled.validate() {
+		errs = append(errs, fmt.Errorf("ecs: disabled set: %w", err))
+	}
+	for _, entity := range w.disabled.Data() {
+		if !w.entityManager.IsValid(entity) {
+			errs = append(errs, fmt.Errorf("ecs: dead entity %s is disabled", entity))
+		}
+	}
+	return errs
+}
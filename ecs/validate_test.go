@@ -0,0 +1,79 @@
+package ecs
+
+import (
+	"strings"
+	"testing"
+)
+
+// expectInvalid checks that Validate reports an error containing want
+func expectInvalid(t *testing.T, w *World, want string) {
+	t.Helper()
+	errs := w.Validate()
+	for _, err := range errs {
+		if strings.Contains(err.Error(), want) {
+			return
+		}
+	}
+	t.Fatalf("Validate = %v, want an error containing %q", errs, want)
+}
+
+func TestValidateConsistentWorld(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 50)
+	for i, e := range entities {
+		switch i % 4 {
+		case 0:
+			w.DestroyEntity(e)
+		case 1:
+			RemoveComponent[testPosition](w, e)
+		case 2:
+			w.DisableEntity(e)
+		}
+	}
+	populateTestWorld(w, 10)
+	if errs := w.Validate(); errs != nil {
+		t.Fatalf("Validate after churn: %v", errs)
+	}
+}
+
+func TestValidateReportsCorruption(t *testing.T) {
+	corrupt := []struct {
+		name string
+		fn   func(w *World, pool *ComponentPool[testPosition], entities []Entity)
+		want string
+	}{
+		{"dead entity in pool", func(w *World, pool *ComponentPool[testPosition], entities []Entity) {
+			// Destroy behind the pool's back so it keeps the entity
+			w.entityManager.Destroy(entities[1])
+		}, "holds dead entity"},
+		{"sparse slot off", func(w *World, pool *ComponentPool[testPosition], entities []Entity) {
+			pool.entities.setSparseIndex(entities[2].Index(), 0)
+		}, "sparse slot points to"},
+		{"short component array", func(w *World, pool *ComponentPool[testPosition], entities []Entity) {
+			pool.components = pool.components[:1]
+		}, "components for"},
+		{"signature missing bit", func(w *World, pool *ComponentPool[testPosition], entities []Entity) {
+			id, _ := GetComponentID[testPosition](w.componentRegistry)
+			w.componentRegistry.signatures.unset(entities[0], id)
+		}, "signature lacks"},
+		{"live count", func(w *World, pool *ComponentPool[testPosition], entities []Entity) {
+			w.entityManager.live++
+		}, "live count is"},
+		{"alive index on free list", func(w *World, pool *ComponentPool[testPosition], entities []Entity) {
+			w.entityManager.free = append(w.entityManager.free, entities[0].Index())
+		}, "is alive"},
+		{"dead entity disabled", func(w *World, pool *ComponentPool[testPosition], entities []Entity) {
+			w.disabled.Insert(makeEntity(entities[3].Index(), 7))
+		}, "is disabled"},
+	}
+
+	for _, c := range corrupt {
+		t.Run(c.name, func(t *testing.T) {
+			w := NewWorld()
+			entities := populateTestWorld(w, 4)
+			pool, _ := GetStorage[testPosition](w.componentRegistry)
+			c.fn(w, pool, entities)
+			expectInvalid(t, w, c.want)
+		})
+	}
+}
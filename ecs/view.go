@@ -0,0 +1,100 @@
+package ecs
+
+import (
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// viewField maps one pointer field of a view struct to a component type
+type viewField struct {
+	offset        uintptr
+	componentType reflect.Type
+	optional      bool
+}
+
+// viewLayouts caches the fields of each view struct type, keyed by reflect.Type
+var viewLayouts sync.Map
+
+// viewLayout returns the cached field mapping for view struct V
+func viewLayout[V any]() []viewField {
+	viewType := reflect.TypeOf((*V)(nil)).Elem()
+	if cached, ok := viewLayouts.Load(viewType); ok {
+		return cached.([]viewField)
+	}
+
+	if viewType.Kind() != reflect.Struct {
+		panic("ecs: view type " + viewType.String() + " must be a struct")
+	}
+	fields := make([]viewField, 0, viewType.NumField())
+	for i := 0; i < viewType.NumField(); i++ {
+		field := viewType.Field(i)
+		if field.Type.Kind() != reflect.Pointer {
+			panic("ecs: view field " + viewType.String() + "." + field.Name + " must be a pointer to a component")
+		}
+		fields = append(fields, viewField{
+			offset:        field.Offset,
+			componentType: field.Type.Elem(),
+			optional:      field.Tag.Get("ecs") == "optional",
+		})
+	}
+
+	viewLayouts.Store(viewType, fields)
+	return fields
+}
+
+// Each calls fn for every entity holding the components pointed to by the fields
+// of view struct V, e.g.
+//
+//	type Movers struct {
+//		Pos *Position
+//		Vel *Velocity
+//		Tag *Name `ecs:"optional"`
+//	}
+//	ecs.Each(world, func(e ecs.Entity, m Movers) { m.Pos.X += m.Vel.X })
+//
+// Every field must be a pointer to a component type; entities missing a required
+// component are skipped, while fields tagged `ecs:"optional"` are nil when absent
+func Each[V any](w *World, fn func(Entity, V)) {
+	fields := viewLayout[V]()
+	registry := w.componentRegistry
+
+	storages := make([]IComponentStorage, len(fields))
+	query := NewQuery(w)
+	for i, field := range fields {
+		id, registered := registry.typeToID[field.componentType]
+		if !registered {
+			if field.optional {
+				continue
+			}
+			return // Nothing can hold an unregistered component
+		}
+		storages[i] = registry.storages[id]
+		if !field.optional {
+			query.include = append(query.include, id)
+		}
+	}
+	if len(query.include) == 0 {
+		panic("ecs: view needs at least one required field")
+	}
+
+	for _, entity := range query.Build().entities {
+		var view V
+		base := unsafe.Pointer(&view)
+		skip := false
+		for i, field := range fields {
+			if storages[i] == nil {
+				continue
+			}
+			ptr := storages[i].pointer(entity)
+			if ptr == nil && !field.optional {
+				skip = true
+				break
+			}
+			*(*unsafe.Pointer)(unsafe.Add(base, field.offset)) = ptr
+		}
+		if !skip {
+			fn(entity, view)
+		}
+	}
+}
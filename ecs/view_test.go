@@ -0,0 +1,96 @@
+package ecs
+
+import (
+	"slices"
+	"testing"
+)
+
+type testMovers struct {
+	Pos    *testPosition
+	Vel    *testVelocity
+	Health *testHealth
+}
+
+type testNamedMovers struct {
+	Pos  *testPosition
+	Name *testName `ecs:"optional"`
+}
+
+type testBadView struct{ X testPosition }
+
+type testOptionalView struct {
+	Pos *testPosition `ecs:"optional"`
+}
+
+func TestEachFillsViewStruct(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 6)
+	for i, e := range entities {
+		if i != 2 {
+			AddComponent(w, e, testHealth{HP: i})
+		}
+	}
+
+	var visited []Entity
+	Each(w, func(e Entity, m testMovers) {
+		visited = append(visited, e)
+		if m.Pos == nil || m.Vel == nil || m.Health == nil {
+			t.Fatalf("required field nil for %s: %+v", e, m)
+		}
+		m.Pos.X += m.Vel.X
+		if m.Health.HP != int(e.Index()) {
+			t.Fatalf("%s got health %v", e, m.Health)
+		}
+	})
+
+	// Entity 2 moves but has no health, odd entities don't move
+	want := []Entity{entities[0], entities[4]}
+	if got := sortedByIndex(visited); !slices.Equal(got, want) {
+		t.Fatalf("Each visited %v, want %v", got, want)
+	}
+	if p, _ := GetComponent[testPosition](w, entities[4]); p.X != 5 {
+		t.Fatalf("writes through view fields were lost, X = %v", p.X)
+	}
+}
+
+func TestEachOptionalFields(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 3)
+
+	// The optional type isn't registered yet, so the field is always nil
+	visited := 0
+	Each(w, func(e Entity, m testNamedMovers) {
+		visited++
+		if m.Name != nil {
+			t.Fatalf("unregistered optional field set for %s", e)
+		}
+	})
+	if visited != 3 {
+		t.Fatalf("Each visited %d entities, want 3", visited)
+	}
+
+	AddComponent(w, entities[1], testName{Value: "b"})
+	named := 0
+	Each(w, func(e Entity, m testNamedMovers) {
+		if (m.Name != nil) != (e == entities[1]) {
+			t.Fatalf("optional field for %s = %v", e, m.Name)
+		}
+		if m.Name != nil {
+			named++
+		}
+	})
+	if named != 1 {
+		t.Fatalf("optional field set for %d entities, want 1", named)
+	}
+}
+
+func TestEachRejectsInvalidViews(t *testing.T) {
+	w := NewWorld()
+	populateTestWorld(w, 1)
+	expectPanic(t, "ecs: view field ecs.testBadView.X must be a pointer to a component", func() {
+		Each(w, func(Entity, testBadView) {})
+	})
+	expectPanic(t, "ecs: view needs at least one required field", func() {
+		Each(w, func(Entity, testOptionalView) {})
+	})
+}
@@ -1,10 +1,35 @@
 package ecs
 
+import (
+	"fmt"
+	"iter"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
 // World represents the main ECS world containing entities, components, and systems
 type World struct {
 	entityManager     *EntityManager
 	componentRegistry *ComponentRegistry
 	systemManager     *SystemManager
+	events            *eventBus
+	versionBase       uint64 // Carries Version forward when Clear replaces the registry
+	logger            func(level, msg string)
+	eventLogger       Logger // Receives the structural change trace, see SetEventLogger
+	prefabs           map[string]*PrefabBuilder
+	codecs            map[string]*componentCodec            // JSON codecs by full type name
+	migrations        map[string]map[int]componentMigration // Save upgrades by type name and source version
+	deterministic     bool                                  // Sort query results by entity index
+	queryCache        map[string]cachedResult               // Query results this frame by signature, nil when disabled
+	disabled          *SparseSet                            // Entities skipped by queries until re-enabled
+	onDestroyed       []func(Entity)                        // Called by DestroyEntity before components are removed
+	destroying        []Entity                              // Entities whose OnEntityDestroyed callbacks are running
+	relations         map[reflect.Type]*relationStore       // Entity links by relation marker type
+	frame             uint64                                // Updates completed since creation, Clear or Reset
+	elapsed           float64                               // Sum of deltaTime over completed updates
+	resultPool        sync.Pool                             // Released QueryResults for Query.BuildInto
 }
 
 // NewWorld creates a new ECS world
@@ -13,22 +38,160 @@ func NewWorld() *World {
 		entityManager:     NewEntityManager(),
 		componentRegistry: NewComponentRegistry(),
 		systemManager:     NewSystemManager(),
+		events:            newEventBus(),
+		eventLogger:       NopLogger{},
+		prefabs:           make(map[string]*PrefabBuilder),
+		codecs:            make(map[string]*componentCodec),
+		migrations:        make(map[string]map[int]componentMigration),
+		disabled:          NewSparseSet(),
+		relations:         make(map[reflect.Type]*relationStore),
 	}
 }
 
 // CreateEntity creates a new entity
 func (w *World) CreateEntity() Entity {
-	return w.entityManager.Create()
+	entity := w.entityManager.Create()
+	w.eventLogger.EntityCreated(entity)
+	return entity
+}
+
+// CreateEntityWithID creates the entity with the given index and generation, see
+// EntityManager.CreateWithID
+func (w *World) CreateEntityWithID(index, generation uint32) (Entity, error) {
+	entity, err := w.entityManager.CreateWithID(index, generation)
+	if err == nil {
+		w.eventLogger.EntityCreated(entity)
+	}
+	return entity, err
 }
 
 // DestroyEntity destroys an entity and removes all its components
 func (w *World) DestroyEntity(entity Entity) bool {
 	if !w.entityManager.IsValid(entity) {
+		w.logf(LogLevelWarn, "DestroyEntity on invalid entity %s ignored", entity)
 		return false
 	}
 
+	// A callback destroying the entity again skips straight to removing it
+	if !slices.Contains(w.destroying, entity) {
+		w.destroying = append(w.destroying, entity)
+		for _, fn := range w.onDestroyed {
+			fn(entity)
+		}
+		w.destroying = w.destroying[:len(w.destroying)-1]
+		if !w.entityManager.IsValid(entity) {
+			return false // A callback already destroyed it
+		}
+	}
+
 	w.componentRegistry.RemoveAllComponents(entity)
-	return w.entityManager.Destroy(entity)
+	w.disabled.Remove(entity)
+	for _, store := range w.relations {
+		store.removeEntity(entity)
+	}
+	retired := w.entityManager.Retired()
+	destroyed := w.entityManager.Destroy(entity)
+	w.eventLogger.EntityDestroyed(entity)
+	if w.entityManager.Retired() > retired {
+		w.logf(LogLevelDebug, "entity index %d retired after exhausting its generations", entity.Index())
+	}
+	return destroyed
+}
+
+// DestroyMatching destroys every entity matching the query built by spec, e.g.
+// world.DestroyMatching(func(q *ecs.Query) { ecs.With[Transient](q) })
+// Matches are snapshotted before destroying, so spec may select by any criteria
+// Returns the number of entities destroyed
+func (w *World) DestroyMatching(spec func(*Query)) int {
+	query := NewQuery(w)
+	spec(query)
+	matches := slices.Clone(query.build().entities)
+
+	destroyed := 0
+	for _, entity := range matches {
+		if w.DestroyEntity(entity) {
+			destroyed++
+		}
+	}
+	return destroyed
+}
+
+// OnEntityDestroyed registers a callback that DestroyEntity calls before removing the
+// entity's components, so it can still read them, e.g. to release external resources
+// DestroyEntityRecursive calls it for the entity and each of its descendants
+// Callbacks run in registration order; Clear drops entities without calling them
+func (w *World) OnEntityDestroyed(fn func(Entity)) {
+	w.onDestroyed = append(w.onDestroyed, fn)
+}
+
+// CloneEntity creates a new entity with a copy of every component the source has
+// Components are value-copied, so slices, maps and pointers inside them are shared
+func (w *World) CloneEntity(src Entity) Entity {
+	if !w.entityManager.IsValid(src) {
+		w.logf(LogLevelWarn, "CloneEntity of invalid entity %s ignored", src)
+		return NullEntity
+	}
+
+	dst := w.CreateEntity()
+	for _, id := range w.componentRegistry.order {
+		w.componentRegistry.storages[id].CloneComponent(src, dst)
+	}
+	return dst
+}
+
+// SwapEntities exchanges the components of two entities: afterwards a holds what b
+// held and vice versa, including types only one of them had
+// Replaced and moved components fire the usual hooks; disabled state and relations
+// stay with the handles. Returns false if either entity is invalid
+func (w *World) SwapEntities(a, b Entity) bool {
+	if !w.entityManager.IsValid(a) || !w.entityManager.IsValid(b) {
+		w.logf(LogLevelWarn, "SwapEntities of %s and %s ignored: invalid entity", a, b)
+		return false
+	}
+	if a == b {
+		return true
+	}
+
+	for _, id := range w.componentRegistry.order {
+		w.componentRegistry.storages[id].swapComponents(a, b)
+	}
+	return true
+}
+
+// Import creates a copy of each src entity in this world, registering component
+// types that are new to it, and returns the new handle for every imported entity
+// Components are value-copied, so slices, maps and pointers inside them are shared
+// Invalid source entities are skipped
+func (w *World) Import(src *World, entities []Entity) map[Entity]Entity {
+	mapping := make(map[Entity]Entity, len(entities))
+	targets := make(map[ComponentID]IComponentStorage)
+
+	for _, entity := range entities {
+		if !src.entityManager.IsValid(entity) {
+			continue
+		}
+		if _, done := mapping[entity]; done {
+			continue
+		}
+
+		imported := w.CreateEntity()
+		mapping[entity] = imported
+		for _, id := range src.componentRegistry.order {
+			storage := src.componentRegistry.storages[id]
+			value, exists := storage.GetAny(entity)
+			if !exists {
+				continue
+			}
+
+			target, resolved := targets[id]
+			if !resolved {
+				target = storage.registerInto(w.componentRegistry)
+				targets[id] = target
+			}
+			target.SetAny(imported, value)
+		}
+	}
+	return mapping
 }
 
 // IsValidEntity checks if an entity is valid
@@ -36,9 +199,108 @@ func (w *World) IsValidEntity(entity Entity) bool {
 	return w.entityManager.IsValid(entity)
 }
 
+// DisableEntity pauses an entity: queries, iterators and systems skip it while its
+// components stay in place and remain readable with GetComponent
+func (w *World) DisableEntity(entity Entity) {
+	if !w.entityManager.IsValid(entity) {
+		w.logf(LogLevelWarn, "DisableEntity on invalid entity %s ignored", entity)
+		return
+	}
+	if w.disabled.Insert(entity) {
+		w.versionBase++
+	}
+}
+
+// EnableEntity makes a disabled entity visible to queries again
+func (w *World) EnableEntity(entity Entity) {
+	if w.disabled.Remove(entity) {
+		w.versionBase++
+	}
+}
+
+// IsEntityEnabled checks if an entity is valid and not disabled
+func (w *World) IsEntityEnabled(entity Entity) bool {
+	return w.entityManager.IsValid(entity) && !w.disabled.Contains(entity)
+}
+
+// FilterAlive returns the entities that are still valid, in their original order
+func (w *World) FilterAlive(entities []Entity) []Entity {
+	alive, _ := w.PartitionAlive(entities)
+	return alive
+}
+
+// PartitionAlive splits entities into those still valid and those that are null,
+// destroyed or stale, preserving order within each slice
+func (w *World) PartitionAlive(entities []Entity) (alive, dead []Entity) {
+	alive = make([]Entity, 0, len(entities))
+	dead = make([]Entity, 0)
+	for _, entity := range entities {
+		if w.entityManager.IsValid(entity) {
+			alive = append(alive, entity)
+		} else {
+			dead = append(dead, entity)
+		}
+	}
+	return alive, dead
+}
+
+// ForEachEntity calls fn for every live entity in index order
+func (w *World) ForEachEntity(fn func(Entity)) {
+	w.entityManager.ForEachAlive(fn)
+}
+
+// RegisterComponent registers component type T ahead of first use and sizes its
+// pool for initialCapacity components, avoiding reallocation spikes mid-frame
+func RegisterComponent[T any](w *World, initialCapacity int) ComponentID {
+	id := Register[T](w.componentRegistry)
+	if storage, exists := GetStorage[T](w.componentRegistry); exists {
+		storage.Reserve(initialCapacity)
+	}
+	return id
+}
+
+// ReserveComponent grows component T's pool to hold at least n components, e.g.
+// before a large spawn burst
+func ReserveComponent[T any](w *World, n int) {
+	Register[T](w.componentRegistry)
+	if storage, exists := GetStorage[T](w.componentRegistry); exists {
+		storage.Reserve(n)
+	}
+}
+
+// RegisterAs registers a component type under an explicit ID, so IDs stay the same
+// across runs regardless of which code touches a type first
+// Register skips explicitly claimed IDs, but an explicit ID already taken by another
+// type, or a type already registered under a different ID, is an error
+func RegisterAs[T any](w *World, id ComponentID) error {
+	cr := w.componentRegistry
+	var zero T
+	componentType := reflect.TypeOf(zero)
+
+	if cr.concurrent {
+		cr.mu.Lock()
+		defer cr.mu.Unlock()
+	}
+
+	if existing, exists := cr.typeToID[componentType]; exists {
+		if existing != id {
+			return fmt.Errorf("ecs: %s is already registered with ID %d", componentType, existing)
+		}
+		delete(cr.queryOnly, id) // Registered by a query before, now explicitly
+		return nil
+	}
+	if other := cr.idToType[id]; other != nil {
+		return fmt.Errorf("ecs: component ID %d is already used by %s", id, other)
+	}
+
+	addStorage[T](cr, id, componentType)
+	return nil
+}
+
 // AddComponent adds a component to an entity
 func AddComponent[T any](w *World, entity Entity, component T) {
 	if !w.entityManager.IsValid(entity) {
+		w.logf(LogLevelWarn, "AddComponent[%T] on invalid entity %s ignored", component, entity)
 		return
 	}
 
@@ -51,6 +313,7 @@ func AddComponent[T any](w *World, entity Entity, component T) {
 // RemoveComponent removes a component from an entity
 func RemoveComponent[T any](w *World, entity Entity) bool {
 	if !w.entityManager.IsValid(entity) {
+		w.logf(LogLevelWarn, "RemoveComponent on invalid entity %s ignored", entity)
 		return false
 	}
 
@@ -60,6 +323,98 @@ func RemoveComponent[T any](w *World, entity Entity) bool {
 	return false
 }
 
+// AddComponentErr is like AddComponent but reports an invalid entity as an error
+// wrapping ErrInvalidEntity instead of ignoring it
+func AddComponentErr[T any](w *World, entity Entity, component T) error {
+	if !w.entityManager.IsValid(entity) {
+		return fmt.Errorf("%w: AddComponent[%T] on %s", ErrInvalidEntity, component, entity)
+	}
+
+	AddComponent(w, entity, component)
+	return nil
+}
+
+// RemoveComponentErr is like RemoveComponent but reports an invalid entity as an
+// error wrapping ErrInvalidEntity; removing a component the entity lacks is not an error
+func RemoveComponentErr[T any](w *World, entity Entity) (bool, error) {
+	if !w.entityManager.IsValid(entity) {
+		var zero T
+		return false, fmt.Errorf("%w: RemoveComponent[%T] on %s", ErrInvalidEntity, zero, entity)
+	}
+
+	return RemoveComponent[T](w, entity), nil
+}
+
+// RemoveComponentBatch removes component T from every valid entity in entities,
+// resolving the storage once; returns the number of components removed
+func RemoveComponentBatch[T any](w *World, entities []Entity) int {
+	storage, exists := GetStorage[T](w.componentRegistry)
+	if !exists {
+		return 0
+	}
+
+	removed := 0
+	for _, entity := range entities {
+		if w.entityManager.IsValid(entity) && storage.Remove(entity) {
+			removed++
+		}
+	}
+	return removed
+}
+
+// ClearComponent removes component T from every entity that has it
+func ClearComponent[T any](w *World) {
+	if storage, exists := GetStorage[T](w.componentRegistry); exists {
+		storage.Clear()
+	}
+}
+
+// RemoveComponentGet removes a component from an entity and returns its old value
+func RemoveComponentGet[T any](w *World, entity Entity) (T, bool) {
+	var zero T
+	if !w.entityManager.IsValid(entity) {
+		w.logf(LogLevelWarn, "RemoveComponentGet on invalid entity %s ignored", entity)
+		return zero, false
+	}
+
+	if storage, exists := GetStorage[T](w.componentRegistry); exists {
+		return storage.RemoveAndGet(entity)
+	}
+	return zero, false
+}
+
+// CopyComponent gives `to` a copy of `from`'s component T, replacing any it already has
+// Returns false if either entity is invalid or from lacks the component
+func CopyComponent[T any](w *World, from, to Entity) bool {
+	if !w.entityManager.IsValid(from) || !w.entityManager.IsValid(to) {
+		w.logf(LogLevelWarn, "CopyComponent from %s to %s ignored: invalid entity", from, to)
+		return false
+	}
+
+	storage, exists := GetStorage[T](w.componentRegistry)
+	if !exists {
+		return false
+	}
+	component := storage.GetPtr(from)
+	if component == nil {
+		return false
+	}
+	storage.Insert(to, *component)
+	return true
+}
+
+// MoveComponent moves component T from one entity to another, replacing any the
+// target already has; returns false if either entity is invalid or from lacks it
+func MoveComponent[T any](w *World, from, to Entity) bool {
+	if !CopyComponent[T](w, from, to) {
+		return false
+	}
+	if from != to {
+		RemoveComponent[T](w, from)
+	}
+	return true
+}
+
 // GetComponent retrieves a component from an entity
 func GetComponent[T any](w *World, entity Entity) (T, bool) {
 	var zero T
@@ -73,7 +428,30 @@ func GetComponent[T any](w *World, entity Entity) (T, bool) {
 	return zero, false
 }
 
+// EnableComponentHistory keeps up to maxLength previously added values per entity
+// for component type T, for audit or replay; 0 disables history
+func EnableComponentHistory[T any](w *World, maxLength int) {
+	Register[T](w.componentRegistry)
+	if storage, exists := GetStorage[T](w.componentRegistry); exists {
+		storage.EnableHistory(maxLength)
+	}
+}
+
+// ComponentHistory returns the values added for an entity's component, oldest first
+// The last value is the current one; history must be enabled with EnableComponentHistory
+func ComponentHistory[T any](w *World, entity Entity) []T {
+	if !w.entityManager.IsValid(entity) {
+		return nil
+	}
+
+	if storage, exists := GetStorage[T](w.componentRegistry); exists {
+		return storage.History(entity)
+	}
+	return nil
+}
+
 // GetComponentPtr returns a pointer to a component for an entity
+// Writes through the pointer are not seen by change detection, use GetComponentMut for that
 func GetComponentPtr[T any](w *World, entity Entity) *T {
 	if !w.entityManager.IsValid(entity) {
 		return nil
@@ -85,6 +463,83 @@ func GetComponentPtr[T any](w *World, entity Entity) *T {
 	return nil
 }
 
+// ComponentEntry is a type-erased component value of an entity
+type ComponentEntry struct {
+	ID    ComponentID
+	Name  string
+	Value any
+}
+
+// GetAllComponents returns copies of all components of an entity keyed by component ID
+func (w *World) GetAllComponents(entity Entity) map[ComponentID]any {
+	result := make(map[ComponentID]any)
+	for _, entry := range w.GetComponentEntries(entity) {
+		result[entry.ID] = entry.Value
+	}
+	return result
+}
+
+// GetComponentEntries returns copies of all components of an entity in registration order
+func (w *World) GetComponentEntries(entity Entity) []ComponentEntry {
+	entries := make([]ComponentEntry, 0)
+	if !w.entityManager.IsValid(entity) {
+		return entries
+	}
+
+	registry := w.componentRegistry
+	for _, id := range registry.order {
+		if value, exists := registry.storages[id].GetAny(entity); exists {
+			entries = append(entries, ComponentEntry{ID: id, Name: registry.names[id], Value: value})
+		}
+	}
+	return entries
+}
+
+// GetComponentMut returns a pointer to a component and marks it changed, so the
+// entity is reported by ChangedThisFrame until the next ClearChangeFlags
+func GetComponentMut[T any](w *World, entity Entity) *T {
+	if !w.entityManager.IsValid(entity) {
+		return nil
+	}
+
+	if storage, exists := GetStorage[T](w.componentRegistry); exists {
+		ptr := storage.GetPtr(entity)
+		if ptr != nil {
+			storage.MarkChanged(entity)
+		}
+		return ptr
+	}
+	return nil
+}
+
+// UpdateComponent applies fn to an entity's component T in place and marks it
+// changed, like GetComponentMut; returns false without calling fn if the entity is
+// invalid or lacks the component, e.g. UpdateComponent(w, e, func(h *Health) { h.HP -= dmg })
+func UpdateComponent[T any](w *World, entity Entity, fn func(*T)) bool {
+	component := GetComponentMut[T](w, entity)
+	if component == nil {
+		return false
+	}
+	fn(component)
+	return true
+}
+
+// ChangedThisFrame returns the entities whose component T was accessed through
+// GetComponentMut since the last ClearChangeFlags
+func ChangedThisFrame[T any](w *World) []Entity {
+	if storage, exists := GetStorage[T](w.componentRegistry); exists {
+		return storage.Changed()
+	}
+	return nil
+}
+
+// ClearChangeFlags resets change detection for every component type
+func (w *World) ClearChangeFlags() {
+	for _, storage := range w.componentRegistry.storages {
+		storage.ClearChanged()
+	}
+}
+
 // HasComponent checks if an entity has a specific component
 func HasComponent[T any](w *World, entity Entity) bool {
 	if !w.entityManager.IsValid(entity) {
@@ -97,29 +552,93 @@ func HasComponent[T any](w *World, entity Entity) bool {
 	return false
 }
 
+// AlignPools reorders the pools of A and B so entities holding both come first, in
+// the same order in each pool, and returns how many such entities there are
+// Below that count both pools' Data refer to the same entity at each index, allowing
+// flat loops like positions[i].X += velocities[i].X * dt until either pool changes
+func AlignPools[A, B any](w *World) int {
+	poolA, existsA := GetStorage[A](w.componentRegistry)
+	poolB, existsB := GetStorage[B](w.componentRegistry)
+	if !existsA || !existsB {
+		return 0
+	}
+
+	// A moves shared entities to the front in B's order, then B follows A's prefix
+	poolA.Respect(poolB.Entities())
+	poolB.Respect(poolA.Entities())
+
+	shared := 0
+	for _, entity := range poolA.Entities().Data() {
+		if !poolB.Contains(entity) {
+			break
+		}
+		shared++
+	}
+	return shared
+}
+
 // Query creates a new query for this world
 func (w *World) Query() *Query {
 	return NewQuery(w)
 }
 
+// QueryWithin creates a query that only considers the entities of an earlier result,
+// for cheaply narrowing a broad result; with no criteria it keeps every live entity
+func (w *World) QueryWithin(result *QueryResult) *Query {
+	q := NewQuery(w)
+	q.within = append(make([]Entity, 0, result.Size()), result.Entities()...)
+	return q
+}
+
 // View creates a new view builder for this world
 func (w *World) View() *ViewBuilder {
 	return NewViewBuilder(w)
 }
 
 // Iter1 creates a new single-component iterator
-func Iter1[T1 any](w *World) *Iterator1[T1] {
-	return NewIterator1[T1](w)
+func Iter1[T1 any](w *World, options ...QueryOption) *Iterator1[T1] {
+	return NewIterator1[T1](w, options...)
 }
 
 // Iter2 creates a new two-component iterator
-func Iter2[T1, T2 any](w *World) *Iterator2[T1, T2] {
-	return NewIterator2[T1, T2](w)
+func Iter2[T1, T2 any](w *World, options ...QueryOption) *Iterator2[T1, T2] {
+	return NewIterator2[T1, T2](w, options...)
 }
 
 // Iter3 creates a new three-component iterator
-func Iter3[T1, T2, T3 any](w *World) *Iterator3[T1, T2, T3] {
-	return NewIterator3[T1, T2, T3](w)
+func Iter3[T1, T2, T3 any](w *World, options ...QueryOption) *Iterator3[T1, T2, T3] {
+	return NewIterator3[T1, T2, T3](w, options...)
+}
+
+// Iter4 creates a new four-component iterator
+func Iter4[T1, T2, T3, T4 any](w *World, options ...QueryOption) *Iterator4[T1, T2, T3, T4] {
+	return NewIterator4[T1, T2, T3, T4](w, options...)
+}
+
+// Iter5 creates a new five-component iterator
+func Iter5[T1, T2, T3, T4, T5 any](w *World, options ...QueryOption) *Iterator5[T1, T2, T3, T4, T5] {
+	return NewIterator5[T1, T2, T3, T4, T5](w, options...)
+}
+
+// Iter1Opt2 creates an iterator over entities with TReq, also yielding TOpt1 and TOpt2 when present
+func Iter1Opt2[TReq, TOpt1, TOpt2 any](w *World) *Iterator1Opt2[TReq, TOpt1, TOpt2] {
+	return NewIterator1Opt2[TReq, TOpt1, TOpt2](w)
+}
+
+// Range1 returns a sequence over entities with T1 for use with range, e.g.
+// for entity, pos := range ecs.Range1[Position](w)
+func Range1[T1 any](w *World, options ...QueryOption) iter.Seq2[Entity, *T1] {
+	return NewIterator1[T1](w, options...).All()
+}
+
+// Range2 returns a sequence over entities with T1 and T2 for use with range
+func Range2[T1, T2 any](w *World, options ...QueryOption) iter.Seq2[Entity, Row2[T1, T2]] {
+	return NewIterator2[T1, T2](w, options...).All()
+}
+
+// Range3 returns a sequence over entities with T1, T2 and T3 for use with range
+func Range3[T1, T2, T3 any](w *World, options ...QueryOption) iter.Seq2[Entity, Row3[T1, T2, T3]] {
+	return NewIterator3[T1, T2, T3](w, options...).All()
 }
 
 // GetEntityManager returns the entity manager
@@ -127,19 +646,57 @@ func (w *World) GetEntityManager() *EntityManager {
 	return w.entityManager
 }
 
+// SetConcurrentRegistration makes lazy component registration safe from multiple
+// goroutines; enable it before running systems in parallel
+func (w *World) SetConcurrentRegistration(enabled bool) {
+	w.componentRegistry.SetConcurrent(enabled)
+}
+
 // GetComponentRegistry returns the component registry
 func (w *World) GetComponentRegistry() *ComponentRegistry {
 	return w.componentRegistry
 }
 
+// ForEachComponentType calls fn for every registered component type in registration
+// order with its storage, e.g. to list types and their entity counts
+func (w *World) ForEachComponentType(fn func(id ComponentID, name string, storage IComponentStorage)) {
+	w.componentRegistry.ForEachStorage(fn)
+}
+
 // GetSystemManager returns the system manager
 func (w *World) GetSystemManager() *SystemManager {
 	return w.systemManager
 }
 
 // AddSystem adds a system to the world
-func (w *World) AddSystem(system System) {
-	w.systemManager.AddSystem(system)
+// Returns false if the system was not added, see SystemManager.AddSystemToStage
+func (w *World) AddSystem(system System) bool {
+	return w.AddSystemToStage(DefaultStage, system)
+}
+
+// AddSystemToStage adds a system to the world in the given stage
+// Returns false if the system was not added, see SystemManager.AddSystemToStage
+func (w *World) AddSystemToStage(stage string, system System) bool {
+	sm := w.systemManager
+	duplicate := sm.duplicateNames != DuplicateNamesAllow && sm.HasSystemNamed(system.GetName())
+	if !sm.AddSystemToStage(stage, system) {
+		w.logf(LogLevelWarn, "AddSystem of %q ignored: already added or name in use", system.GetName())
+		return false
+	}
+	if duplicate {
+		w.logf(LogLevelWarn, "AddSystem: another system is already named %q", system.GetName())
+	}
+	return true
+}
+
+// SetDuplicateSystemNamePolicy sets how AddSystem treats a system whose name is already in use
+func (w *World) SetDuplicateSystemNamePolicy(policy DuplicateNamePolicy) {
+	w.systemManager.SetDuplicateNamePolicy(policy)
+}
+
+// SetStageOrder sets the order in which system stages run
+func (w *World) SetStageOrder(stages []string) {
+	w.systemManager.SetStageOrder(stages)
 }
 
 // RemoveSystem removes a system from the world
@@ -147,6 +704,17 @@ func (w *World) RemoveSystem(system System) {
 	w.systemManager.RemoveSystem(system)
 }
 
+// RemoveSystemByName removes the first system with the given name from the world
+func (w *World) RemoveSystemByName(name string) bool {
+	return w.systemManager.RemoveSystemByName(name)
+}
+
+// SystemAccessProfile reports which components each system touched while profiling
+// is enabled, as access counts by system name and component ID
+func (w *World) SystemAccessProfile() map[string]map[ComponentID]int {
+	return w.systemManager.AccessProfile()
+}
+
 // EnableSystem enables a system
 func (w *World) EnableSystem(system System) {
 	w.systemManager.EnableSystem(system)
@@ -157,16 +725,175 @@ func (w *World) DisableSystem(system System) {
 	w.systemManager.DisableSystem(system)
 }
 
-// Update updates all enabled systems
+// SetSystemEnabledByName enables or disables the first system with the given name
+// Returns false if no system has that name
+func (w *World) SetSystemEnabledByName(name string, enabled bool) bool {
+	system, exists := w.systemManager.GetSystemByName(name)
+	if !exists {
+		return false
+	}
+
+	if enabled {
+		w.systemManager.EnableSystem(system)
+	} else {
+		w.systemManager.DisableSystem(system)
+	}
+	return true
+}
+
+// Update updates all enabled systems, then delivers the frame's events to subscribers
 func (w *World) Update(deltaTime float64) {
+	w.InvalidateQueries()
 	w.systemManager.Update(w, deltaTime)
+	w.events.flush()
+	w.frame++
+	w.elapsed += deltaTime
+}
+
+// Frame returns the number of completed updates since creation, Clear or Reset;
+// while systems run it is the current frame's number, starting at 0
+func (w *World) Frame() uint64 {
+	return w.frame
+}
+
+// Elapsed returns the total deltaTime of completed updates, i.e. the simulated time
+// at the start of the current frame while systems run
+func (w *World) Elapsed() float64 {
+	return w.elapsed
+}
+
+// RegisterPersistent registers a component type whose data survives Clear
+// Entities holding a persistent component are kept alive, losing only their other components
+func RegisterPersistent[T any](w *World) ComponentID {
+	id := Register[T](w.componentRegistry)
+	w.componentRegistry.persistent[id] = true
+	return id
 }
 
 // Clear removes all entities, components, and systems
+// Persistent component types and the entities holding them are preserved
 func (w *World) Clear() {
 	w.systemManager.Clear()
-	w.componentRegistry = NewComponentRegistry()
-	w.entityManager.Clear()
+	w.events = newEventBus()
+	w.frame, w.elapsed = 0, 0
+	w.relations = make(map[reflect.Type]*relationStore)
+
+	registry := w.componentRegistry
+	if len(registry.persistent) == 0 {
+		w.versionBase += registry.version + 1
+		w.componentRegistry = NewComponentRegistry()
+		w.componentRegistry.inheritSettings(registry)
+		if registry.logger != nil {
+			// The pools are dropped rather than cleared, so report their removals here
+			for _, id := range registry.order {
+				for _, entity := range registry.storages[id].Entities().Data() {
+					registry.logger.ComponentRemoved(entity, id)
+				}
+			}
+		}
+		w.entityManager.ForEachAlive(w.eventLogger.EntityDestroyed)
+		w.entityManager.Clear()
+		w.disabled.Clear()
+		return
+	}
+
+	// Collect entities that hold persistent data before dropping everything else
+	keep := NewSparseSet()
+	for id := range registry.persistent {
+		registry.storages[id].Entities().ForEach(func(entity Entity) {
+			keep.Insert(entity)
+		})
+	}
+
+	for id, storage := range registry.storages {
+		if !registry.persistent[id] {
+			storage.Clear()
+			registry.forget(id)
+		}
+	}
+
+	w.entityManager.ForEachAlive(func(entity Entity) {
+		if !keep.Contains(entity) {
+			w.entityManager.Destroy(entity)
+			w.disabled.Remove(entity)
+			w.eventLogger.EntityDestroyed(entity)
+		}
+	})
+}
+
+// Reset removes all entities and components like Clear, but keeps the registered
+// component types with their pool capacity, the systems and event subscriptions,
+// so repopulating e.g. a restarted level reuses the existing allocations
+// Persistent component types and the entities holding them are preserved; the other
+// entities are destroyed, so their handles stay invalid. Queued events are dropped
+// and Frame and Elapsed restart from 0
+func (w *World) Reset() {
+	registry := w.componentRegistry
+	var keep *SparseSet
+	if len(registry.persistent) > 0 {
+		// Collect entities that hold persistent data before dropping everything else
+		keep = NewSparseSet()
+		for id := range registry.persistent {
+			registry.storages[id].Entities().ForEach(func(entity Entity) {
+				keep.Insert(entity)
+			})
+		}
+	}
+
+	for _, id := range registry.order {
+		if !registry.persistent[id] {
+			registry.storages[id].Clear()
+		}
+	}
+
+	w.entityManager.ForEachAlive(func(entity Entity) {
+		if keep == nil || !keep.Contains(entity) {
+			w.entityManager.Destroy(entity)
+			w.disabled.Remove(entity)
+			for _, store := range w.relations {
+				store.removeEntity(entity)
+			}
+			w.eventLogger.EntityDestroyed(entity)
+		}
+	})
+
+	for _, queue := range w.events.order {
+		queue.discard()
+	}
+	w.frame, w.elapsed = 0, 0
+}
+
+// ShrinkPools releases memory every component pool kept from earlier peaks, e.g.
+// after destroying most entities at the end of a battle
+func (w *World) ShrinkPools() {
+	for _, storage := range w.componentRegistry.storages {
+		storage.Shrink()
+	}
+}
+
+// CompactPools reallocates every component pool to exactly its size, trimming
+// over-reserved capacity, e.g. after loading a save
+// Unlike ShrinkPools it reallocates any pool with spare capacity at all
+func (w *World) CompactPools() {
+	for _, storage := range w.componentRegistry.storages {
+		storage.ShrinkToFit()
+	}
+}
+
+// SetDeterministic makes query results ordered by entity index instead of pool
+// order, so runs applying the same operations see entities in the same order
+// regardless of how swap-and-pop removals reshuffled the pools; sorting costs
+// O(n log n) per query
+func (w *World) SetDeterministic(enabled bool) {
+	w.deterministic = enabled
+	w.InvalidateQueries()
+}
+
+// Version returns a counter that changes whenever entities are created or destroyed,
+// components are added or removed, or component types are registered
+// Comparing versions is a cheap way to detect structural changes
+func (w *World) Version() uint64 {
+	return w.versionBase + w.componentRegistry.version + w.entityManager.version
 }
 
 // Stats returns statistics about the world
@@ -182,15 +909,63 @@ func (w *World) Stats() WorldStats {
 
 	return WorldStats{
 		EntityCount:     entityCount,
+		LiveCount:       w.entityManager.LiveCount(),
+		DisabledCount:   w.disabled.Size(),
 		ComponentTypes:  componentTypes,
 		TotalComponents: totalComponents,
 		SystemCount:     systemCount,
 	}
 }
 
+// DebugString renders the world for bug reports: the stats followed by every live
+// entity with its components' type names and values
+func (w *World) DebugString() string {
+	var b strings.Builder
+	stats := w.Stats()
+	fmt.Fprintf(&b, "World: %d live entities (%d disabled, %d slots), %d component types, %d components, %d systems\n",
+		stats.LiveCount, stats.DisabledCount, stats.EntityCount, stats.ComponentTypes, stats.TotalComponents, stats.SystemCount)
+
+	w.entityManager.ForEachAlive(func(entity Entity) {
+		fmt.Fprintf(&b, "%s\n", entity)
+		for _, entry := range w.GetComponentEntries(entity) {
+			fmt.Fprintf(&b, "  %s: %+v\n", entry.Name, entry.Value)
+		}
+	})
+	return b.String()
+}
+
+// PoolMemoryStats describes the memory held by one component pool
+type PoolMemoryStats struct {
+	ID        ComponentID
+	Name      string
+	Len       int // Components stored
+	Cap       int // Capacity of the component array
+	SparseLen int // Entity indices covered by allocated sparse pages
+	DenseCap  int // Capacity of the dense entity array
+	ElemBytes int // Size of one component value
+	Bytes     int // Approximate bytes held by the pool's arrays
+}
+
+// MemoryStats reports per-pool memory use in registration order, and the total bytes
+// Pools whose Cap is far above Len are candidates for ShrinkPools
+func (w *World) MemoryStats() ([]PoolMemoryStats, int) {
+	registry := w.componentRegistry
+	pools := make([]PoolMemoryStats, 0, len(registry.order))
+	total := 0
+	for _, id := range registry.order {
+		stats := registry.storages[id].memoryStats()
+		stats.ID = id
+		pools = append(pools, stats)
+		total += stats.Bytes
+	}
+	return pools, total
+}
+
 // WorldStats contains statistics about the world
 type WorldStats struct {
-	EntityCount     int
+	EntityCount     int // Entity slots ever created, does not drop when entities are destroyed
+	LiveCount       int // Entities currently alive
+	DisabledCount   int // Live entities skipped by queries
 	ComponentTypes  int
 	TotalComponents int
 	SystemCount     int
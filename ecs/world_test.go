@@ -0,0 +1,1051 @@
+package ecs
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type testPosition struct{ X, Y float64 }
+type testVelocity struct{ X, Y float64 }
+type testSettings struct{ Volume int }
+
+// populateTestWorld creates n entities with a position, every other one also moving
+func populateTestWorld(w *World, n int) []Entity {
+	entities := make([]Entity, n)
+	for i := range entities {
+		entities[i] = w.CreateEntity()
+		AddComponent(w, entities[i], testPosition{X: float64(i)})
+		if i%2 == 0 {
+			AddComponent(w, entities[i], testVelocity{X: 1})
+		}
+	}
+	return entities
+}
+
+func TestResetKeepsRegistryAndPersistentData(t *testing.T) {
+	w := NewWorld()
+	populateTestWorld(w, 10)
+	settingsID := RegisterPersistent[testSettings](w)
+	config := w.CreateEntity()
+	AddComponent(w, config, testSettings{Volume: 7})
+	positionID, _ := GetComponentID[testPosition](w.componentRegistry)
+
+	w.Reset()
+
+	if got := w.entityManager.LiveCount(); got != 1 {
+		t.Fatalf("LiveCount after Reset = %d, want 1 (the persistent entity)", got)
+	}
+	if settings, ok := GetComponent[testSettings](w, config); !ok || settings.Volume != 7 {
+		t.Fatalf("persistent component after Reset = %v, %v", settings, ok)
+	}
+	if id, ok := GetComponentID[testPosition](w.componentRegistry); !ok || id != positionID {
+		t.Fatalf("testPosition ID after Reset = %d, %v, want %d", id, ok, positionID)
+	}
+	if id, _ := GetComponentID[testSettings](w.componentRegistry); id != settingsID {
+		t.Fatalf("testSettings ID changed across Reset")
+	}
+	if pool, _ := GetStorage[testPosition](w.componentRegistry); pool.Size() != 0 {
+		t.Fatalf("testPosition pool holds %d components after Reset", pool.Size())
+	}
+}
+
+func TestResetInvalidatesOldHandles(t *testing.T) {
+	w := NewWorld()
+	old := populateTestWorld(w, 5)
+
+	w.Reset()
+	fresh := populateTestWorld(w, 5)
+
+	for _, entity := range old {
+		if w.IsValidEntity(entity) {
+			t.Fatalf("handle %s from before Reset is still valid", entity)
+		}
+	}
+	for _, entity := range fresh {
+		for _, stale := range old {
+			if entity == stale {
+				t.Fatalf("new entity %s equals a handle from before Reset", entity)
+			}
+		}
+	}
+}
+
+func TestResetDropsQueuedEventsKeepsSubscribers(t *testing.T) {
+	w := NewWorld()
+	delivered := 0
+	SubscribeEvent(w, func(int) { delivered++ })
+	PublishEvent(w, 1)
+
+	w.Reset()
+	if delivered != 0 || len(DrainEvents[int](w)) != 0 {
+		t.Fatalf("queued event survived Reset")
+	}
+
+	PublishEvent(w, 2)
+	w.Update(0)
+	if delivered != 1 {
+		t.Fatalf("subscriber called %d times after Reset, want 1", delivered)
+	}
+}
+
+func BenchmarkResetRepopulate(b *testing.B) {
+	w := NewWorld()
+	populateTestWorld(w, 1000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		w.Reset()
+		populateTestWorld(w, 1000)
+	}
+}
+
+func BenchmarkClearRepopulate(b *testing.B) {
+	w := NewWorld()
+	populateTestWorld(w, 1000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		w.Clear()
+		populateTestWorld(w, 1000)
+	}
+}
+
+func TestClearKeepsPersistentComponents(t *testing.T) {
+	w := NewWorld()
+	RegisterPersistent[testSettings](w)
+	config := w.CreateEntity()
+	AddComponent(w, config, testSettings{Volume: 3})
+	transient := w.CreateEntity()
+	AddComponent(w, transient, testPosition{X: 1})
+
+	w.Clear()
+
+	if settings, ok := GetComponent[testSettings](w, config); !ok || settings.Volume != 3 {
+		t.Fatalf("persistent component after Clear = %v, %v", settings, ok)
+	}
+	if w.IsValidEntity(transient) {
+		t.Fatalf("transient entity survived Clear")
+	}
+	if _, registered := GetComponentID[testPosition](w.componentRegistry); registered {
+		t.Fatalf("transient component type still registered after Clear")
+	}
+}
+
+func TestClearKeepsRegistrySettings(t *testing.T) {
+	w := NewWorld()
+	w.componentRegistry.SetRecycleIDs(true)
+	w.SetConcurrentRegistration(true)
+
+	w.Clear()
+
+	if !w.componentRegistry.recycleIDs || !w.componentRegistry.concurrent {
+		t.Fatalf("registry settings lost by Clear: recycleIDs=%v concurrent=%v",
+			w.componentRegistry.recycleIDs, w.componentRegistry.concurrent)
+	}
+}
+
+// countIter2 returns how many entities an iterator visits
+func countIter2[T1, T2 any](it *Iterator2[T1, T2]) int {
+	count := 0
+	it.ForEach(func(Entity, *T1, *T2) { count++ })
+	return count
+}
+
+func TestDisabledEntitiesSkippedByIterators(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 6)
+	w.DisableEntity(entities[0])
+
+	visited := 0
+	Iter2[testPosition, testVelocity](w).ForEach(func(entity Entity, _ *testPosition, _ *testVelocity) {
+		if entity == entities[0] {
+			t.Fatalf("Iter2 visited a disabled entity")
+		}
+		visited++
+	})
+	if visited != 2 {
+		t.Fatalf("Iter2 visited %d entities, want 2", visited)
+	}
+	if _, ok := GetComponent[testPosition](w, entities[0]); !ok {
+		t.Fatalf("GetComponent lost the disabled entity's component")
+	}
+	if w.IsEntityEnabled(entities[0]) {
+		t.Fatalf("disabled entity reported as enabled")
+	}
+
+	if got := countIter2(Iter2[testPosition, testVelocity](w, IncludeDisabled())); got != 3 {
+		t.Fatalf("Iter2 with IncludeDisabled = %d entities, want 3", got)
+	}
+
+	w.EnableEntity(entities[0])
+	if got := countIter2(Iter2[testPosition, testVelocity](w)); got != 3 {
+		t.Fatalf("Iter2 after EnableEntity = %d entities, want 3", got)
+	}
+}
+
+func TestResetForgetsDisabledEntities(t *testing.T) {
+	w := NewWorld()
+	RegisterPersistent[testSettings](w)
+	config := w.CreateEntity()
+	AddComponent(w, config, testSettings{})
+	w.DisableEntity(config)
+	w.DisableEntity(populateTestWorld(w, 3)[0])
+
+	w.Reset()
+
+	if w.Stats().DisabledCount != 1 || w.IsEntityEnabled(config) {
+		t.Fatalf("after Reset %d entities disabled, want only the persistent one", w.Stats().DisabledCount)
+	}
+	// A recycled index must not inherit the destroyed entity's disabled state
+	populateTestWorld(w, 3)
+	if got := With[testPosition](NewQuery(w)).Build().Size(); got != 3 {
+		t.Fatalf("query after Reset = %d entities, want 3", got)
+	}
+}
+
+func TestFrameAndElapsedCountUpdates(t *testing.T) {
+	w := NewWorld()
+	var seen []uint64
+	w.AddSystem(&testFrameRecorder{frames: &seen})
+
+	for i := 0; i < 4; i++ {
+		w.Update(0.25)
+	}
+	if w.Frame() != 4 || w.Elapsed() != 1 {
+		t.Fatalf("after 4 updates frame = %d, elapsed = %v, want 4 and 1", w.Frame(), w.Elapsed())
+	}
+	if !slices.Equal(seen, []uint64{0, 1, 2, 3}) {
+		t.Fatalf("systems saw frames %v, want [0 1 2 3]", seen)
+	}
+
+	w.Reset()
+	if w.Frame() != 0 || w.Elapsed() != 0 {
+		t.Fatalf("after Reset frame = %d, elapsed = %v, want 0", w.Frame(), w.Elapsed())
+	}
+	w.Update(0.5)
+	w.Clear()
+	if w.Frame() != 0 || w.Elapsed() != 0 {
+		t.Fatalf("after Clear frame = %d, elapsed = %v, want 0", w.Frame(), w.Elapsed())
+	}
+}
+
+// testFrameRecorder records the frame number each update runs in
+type testFrameRecorder struct {
+	frames *[]uint64
+}
+
+func (r *testFrameRecorder) Update(w *World, deltaTime float64) {
+	*r.frames = append(*r.frames, w.Frame())
+}
+
+func (r *testFrameRecorder) GetName() string { return "testFrameRecorder" }
+
+func TestComponentHistoryKeepsInsertedValues(t *testing.T) {
+	w := NewWorld()
+	EnableComponentHistory[testPosition](w, 3)
+	entity := w.CreateEntity()
+
+	for i := 1; i <= 4; i++ {
+		AddComponent(w, entity, testPosition{X: float64(i)})
+	}
+
+	history := ComponentHistory[testPosition](w, entity)
+	want := []testPosition{{X: 2}, {X: 3}, {X: 4}}
+	if !slices.Equal(history, want) {
+		t.Fatalf("history = %v, want %v", history, want)
+	}
+	if current, _ := GetComponent[testPosition](w, entity); current != history[len(history)-1] {
+		t.Fatalf("current value %v is not the last history entry", current)
+	}
+
+	RemoveComponent[testPosition](w, entity)
+	if history := ComponentHistory[testPosition](w, entity); len(history) != 0 {
+		t.Fatalf("history after removal = %v, want none", history)
+	}
+	if history := ComponentHistory[testVelocity](w, entity); history != nil {
+		t.Fatalf("history of a type without history = %v, want nil", history)
+	}
+}
+
+func TestCloneEntityCopiesComponents(t *testing.T) {
+	w := NewWorld()
+	src := w.CreateEntity()
+	AddComponent(w, src, testPosition{X: 1, Y: 2})
+	AddComponent(w, src, testVelocity{X: 3})
+	AddComponent(w, src, testSettings{Volume: 7})
+	AddComponent(w, w.CreateEntity(), testHealth{HP: 9}) // Not held by src
+
+	clone := w.CloneEntity(src)
+	if clone == src || !w.IsValidEntity(clone) {
+		t.Fatalf("CloneEntity = %v, want a new valid entity", clone)
+	}
+	if pos, _ := GetComponent[testPosition](w, clone); pos != (testPosition{X: 1, Y: 2}) {
+		t.Fatalf("cloned position = %v", pos)
+	}
+	if vel, _ := GetComponent[testVelocity](w, clone); vel != (testVelocity{X: 3}) {
+		t.Fatalf("cloned velocity = %v", vel)
+	}
+	if settings, _ := GetComponent[testSettings](w, clone); settings.Volume != 7 {
+		t.Fatalf("cloned settings = %v", settings)
+	}
+	if HasComponent[testHealth](w, clone) {
+		t.Fatalf("clone gained a component the source lacks")
+	}
+
+	// The clone holds copies, not shared values
+	GetComponentPtr[testPosition](w, clone).X = 100
+	RemoveComponent[testVelocity](w, clone)
+	if pos, _ := GetComponent[testPosition](w, src); pos.X != 1 || !HasComponent[testVelocity](w, src) {
+		t.Fatalf("mutating the clone changed the source")
+	}
+
+	if w.CloneEntity(NullEntity) != NullEntity {
+		t.Fatalf("cloning an invalid entity should return NullEntity")
+	}
+}
+
+func TestGetAllComponents(t *testing.T) {
+	w := NewWorld()
+	velocity := Register[testVelocity](w.componentRegistry)
+	position := Register[testPosition](w.componentRegistry)
+	entity := w.CreateEntity()
+	AddComponent(w, entity, testPosition{X: 1})
+	AddComponent(w, entity, testVelocity{Y: 2})
+	empty := w.CreateEntity()
+
+	all := w.GetAllComponents(entity)
+	if len(all) != 2 || all[position] != (testPosition{X: 1}) || all[velocity] != (testVelocity{Y: 2}) {
+		t.Fatalf("GetAllComponents = %v", all)
+	}
+
+	// Entries follow registration order, not insertion order
+	entries := w.GetComponentEntries(entity)
+	if len(entries) != 2 || entries[0].ID != velocity || entries[1].ID != position {
+		t.Fatalf("GetComponentEntries = %v, want velocity then position", entries)
+	}
+	if entries[1].Name != "ecs.testPosition" || entries[1].Value != (testPosition{X: 1}) {
+		t.Fatalf("position entry = %+v", entries[1])
+	}
+
+	if got := w.GetAllComponents(empty); len(got) != 0 {
+		t.Fatalf("GetAllComponents of an entity without components = %v", got)
+	}
+	w.DestroyEntity(entity)
+	if got := w.GetComponentEntries(entity); len(got) != 0 {
+		t.Fatalf("GetComponentEntries of a destroyed entity = %v", got)
+	}
+}
+
+func TestChangeDetectionTracksMutations(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 4)
+
+	GetComponentMut[testPosition](w, entities[1]).X = 10
+	UpdateComponent(w, entities[3], func(pos *testPosition) { pos.X = 30 })
+	GetComponentMut[testPosition](w, entities[1]).Y = 1  // Marking twice reports once
+	GetComponentPtr[testPosition](w, entities[2]).X = 20 // Untracked path
+	GetComponentMut[testVelocity](w, entities[0])
+
+	changed := sortedByIndex(ChangedThisFrame[testPosition](w))
+	if !slices.Equal(changed, []Entity{entities[1], entities[3]}) {
+		t.Fatalf("ChangedThisFrame = %v, want [%v %v]", changed, entities[1], entities[3])
+	}
+	if got := ChangedThisFrame[testVelocity](w); !slices.Equal(got, []Entity{entities[0]}) {
+		t.Fatalf("ChangedThisFrame[testVelocity] = %v, want [%v]", got, entities[0])
+	}
+
+	w.ClearChangeFlags()
+	if got := ChangedThisFrame[testPosition](w); len(got) != 0 {
+		t.Fatalf("ChangedThisFrame after ClearChangeFlags = %v", got)
+	}
+
+	// Removing the component drops its change flag
+	GetComponentMut[testPosition](w, entities[0])
+	RemoveComponent[testPosition](w, entities[0])
+	if got := ChangedThisFrame[testPosition](w); len(got) != 0 {
+		t.Fatalf("ChangedThisFrame after removal = %v", got)
+	}
+}
+
+func TestRemoveComponentGet(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 3)
+
+	if pos, ok := RemoveComponentGet[testPosition](w, entities[0]); !ok || pos.X != 0 {
+		t.Fatalf("RemoveComponentGet = %v, %v", pos, ok)
+	}
+	if pos, ok := RemoveComponentGet[testPosition](w, entities[2]); !ok || pos.X != 2 {
+		t.Fatalf("RemoveComponentGet = %v, %v, want X 2", pos, ok)
+	}
+	if HasComponent[testPosition](w, entities[0]) || w.EntitySignature(entities[0])&1 != 0 {
+		t.Fatalf("component still present after RemoveComponentGet")
+	}
+	if _, ok := RemoveComponentGet[testVelocity](w, entities[1]); ok {
+		t.Fatalf("RemoveComponentGet of a missing component succeeded")
+	}
+}
+
+func TestRemoveComponentBatch(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 6)
+	destroyed := entities[5]
+	w.DestroyEntity(destroyed)
+
+	batch := []Entity{entities[0], NullEntity, entities[2], destroyed, entities[0], makeEntity(999, 0)}
+	if removed := RemoveComponentBatch[testPosition](w, batch); removed != 2 {
+		t.Fatalf("RemoveComponentBatch removed %d, want 2", removed)
+	}
+	for i, entity := range entities[:5] {
+		if has := HasComponent[testPosition](w, entity); has == (i == 0 || i == 2) {
+			t.Fatalf("entity %d has position %v after the batch", i, has)
+		}
+	}
+	if removed := RemoveComponentBatch[testHealth](w, entities); removed != 0 {
+		t.Fatalf("RemoveComponentBatch of an unregistered type removed %d", removed)
+	}
+}
+
+func TestClearComponent(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 6)
+
+	ClearComponent[testVelocity](w)
+	if got := With[testVelocity](NewQuery(w)).Build().Size(); got != 0 {
+		t.Fatalf("%d entities still have velocity", got)
+	}
+	if got := With[testPosition](NewQuery(w)).Build().Size(); got != 6 {
+		t.Fatalf("ClearComponent touched other types: %d positions, want 6", got)
+	}
+	if w.EntitySignatureBits(entities[0])[0] != 1 {
+		t.Fatalf("signature not updated by ClearComponent: %b", w.EntitySignatureBits(entities[0]))
+	}
+	ClearComponent[testHealth](w) // Unregistered types are a no-op
+}
+
+func TestStatsLiveCountDropsOnDestroy(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 10)
+	for _, e := range entities[:7] {
+		w.DestroyEntity(e)
+	}
+
+	stats := w.Stats()
+	if stats.LiveCount != 3 {
+		t.Fatalf("LiveCount = %d, want 3", stats.LiveCount)
+	}
+	if stats.EntityCount != 10 {
+		t.Fatalf("EntityCount = %d, want 10 slots", stats.EntityCount)
+	}
+
+	// Recycling slots raises the live count without adding slots
+	w.CreateEntity()
+	if stats = w.Stats(); stats.LiveCount != 4 || stats.EntityCount != 10 {
+		t.Fatalf("after reuse LiveCount = %d, EntityCount = %d, want 4 and 10", stats.LiveCount, stats.EntityCount)
+	}
+}
+
+func TestAlignPoolsLinesUpSharedEntities(t *testing.T) {
+	w := NewWorld()
+	entities := make([]Entity, 20)
+	for i := range entities {
+		entities[i] = w.CreateEntity()
+		AddComponent(w, entities[i], testPosition{X: float64(i)})
+	}
+	// Velocities go on in reverse order so the pools start out misaligned
+	shared := 0
+	for i := len(entities) - 1; i >= 0; i-- {
+		if i%3 != 0 {
+			AddComponent(w, entities[i], testVelocity{X: float64(i)})
+			shared++
+		}
+	}
+	extra := w.CreateEntity()
+	AddComponent(w, extra, testVelocity{X: -1})
+
+	if got := AlignPools[testPosition, testVelocity](w); got != shared {
+		t.Fatalf("AlignPools = %d, want %d", got, shared)
+	}
+
+	positions, _ := GetStorage[testPosition](w.componentRegistry)
+	velocities, _ := GetStorage[testVelocity](w.componentRegistry)
+	positionEntities, positionData := positions.DataWithEntities()
+	velocityEntities, velocityData := velocities.DataWithEntities()
+	for i := range shared {
+		if positionEntities[i] != velocityEntities[i] {
+			t.Fatalf("index %d holds %s in positions but %s in velocities", i, positionEntities[i], velocityEntities[i])
+		}
+		// Both component values were set from the entity's creation order
+		if positionData[i].X != velocityData[i].X {
+			t.Fatalf("index %d components misaligned: position %v, velocity %v", i, positionData[i], velocityData[i])
+		}
+	}
+	for _, entity := range positionEntities[shared:] {
+		if HasComponent[testVelocity](w, entity) {
+			t.Fatalf("%s holds both components but is past the shared prefix", entity)
+		}
+	}
+	if errs := w.Validate(); errs != nil {
+		t.Fatalf("Validate after AlignPools: %v", errs)
+	}
+}
+
+func TestAlignPoolsUnregistered(t *testing.T) {
+	w := NewWorld()
+	populateTestWorld(w, 4)
+	if got := AlignPools[testPosition, testHealth](w); got != 0 {
+		t.Fatalf("AlignPools with an unregistered type = %d, want 0", got)
+	}
+}
+
+func TestRegisterComponentReservesCapacity(t *testing.T) {
+	w := NewWorld()
+	id := RegisterComponent[testHealth](w, 500)
+
+	if name, ok := w.componentRegistry.GetRegisteredTypes()[id]; !ok || name != "ecs.testHealth" {
+		t.Fatalf("registered types lack testHealth before first use: %v", w.componentRegistry.GetRegisteredTypes())
+	}
+	if again := RegisterComponent[testHealth](w, 10); again != id {
+		t.Fatalf("registering again returned ID %d, want %d", again, id)
+	}
+
+	pool, _ := GetStorage[testHealth](w.componentRegistry)
+	if cap(pool.components) < 500 || cap(pool.entities.dense) < 500 {
+		t.Fatalf("capacity = %d components, %d entities, want at least 500", cap(pool.components), cap(pool.entities.dense))
+	}
+
+	// Filling the reserved capacity doesn't reallocate
+	AddComponent(w, w.CreateEntity(), testHealth{})
+	first := &pool.components[0]
+	for range 499 {
+		AddComponent(w, w.CreateEntity(), testHealth{})
+	}
+	if &pool.components[0] != first {
+		t.Fatalf("pool reallocated within its reserved capacity")
+	}
+}
+
+func TestShrinkPoolsKeepsComponents(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 1000)
+	for _, e := range entities[10:] {
+		w.DestroyEntity(e)
+	}
+	w.ShrinkPools()
+
+	positions, _ := GetStorage[testPosition](w.componentRegistry)
+	if cap(positions.components) > 20 || cap(positions.entities.dense) > 20 {
+		t.Fatalf("capacity after ShrinkPools = %d components, %d entities", cap(positions.components), cap(positions.entities.dense))
+	}
+	for i, e := range entities[:10] {
+		if p, ok := GetComponent[testPosition](w, e); !ok || p.X != float64(i) {
+			t.Fatalf("position of %s after ShrinkPools = %v, %v", e, p, ok)
+		}
+	}
+	if errs := w.Validate(); errs != nil {
+		t.Fatalf("Validate after ShrinkPools: %v", errs)
+	}
+}
+
+func TestImportCopiesComponentsAcrossWorlds(t *testing.T) {
+	src := NewWorld()
+	entities := populateTestWorld(src, 4)
+	AddComponent(src, entities[1], testName{Value: "scout"})
+	dead := entities[3]
+	src.DestroyEntity(dead)
+
+	// dst already knows testVelocity under a different ID and has its own entities
+	dst := NewWorld()
+	AddComponent(dst, dst.CreateEntity(), testHealth{HP: 9})
+	AddComponent(dst, dst.CreateEntity(), testVelocity{X: 5})
+
+	mapping := dst.Import(src, []Entity{entities[0], entities[1], entities[1], dead})
+	if len(mapping) != 2 {
+		t.Fatalf("Import mapped %d entities, want 2", len(mapping))
+	}
+	if _, ok := mapping[dead]; ok {
+		t.Fatalf("Import copied a destroyed entity")
+	}
+
+	for i, old := range entities[:2] {
+		imported, ok := mapping[old]
+		if !ok || !dst.IsValidEntity(imported) {
+			t.Fatalf("%s has no valid imported entity", old)
+		}
+		if p, ok := GetComponent[testPosition](dst, imported); !ok || p.X != float64(i) {
+			t.Fatalf("imported position = %v, %v", p, ok)
+		}
+		if _, hasVelocity := GetComponent[testVelocity](dst, imported); hasVelocity != (i%2 == 0) {
+			t.Fatalf("imported %s velocity presence = %v", imported, hasVelocity)
+		}
+		if HasComponent[testHealth](dst, imported) {
+			t.Fatalf("imported %s picked up a component it never had", imported)
+		}
+	}
+	if n, _ := GetComponent[testName](dst, mapping[entities[1]]); n.Value != "scout" {
+		t.Fatalf("imported name = %q, want scout", n.Value)
+	}
+	if !HasComponent[testPosition](src, entities[0]) {
+		t.Fatalf("Import removed components from the source world")
+	}
+	if errs := dst.Validate(); errs != nil {
+		t.Fatalf("Validate after Import: %v", errs)
+	}
+}
+
+func TestFilterAndPartitionAlive(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 4)
+	w.DestroyEntity(entities[1])
+	reused := w.CreateEntity() // Takes entities[1]'s slot, so that handle stays stale
+	w.DestroyEntity(entities[3])
+
+	handles := []Entity{entities[0], NullEntity, entities[1], reused, entities[3], entities[2]}
+	wantAlive := []Entity{entities[0], reused, entities[2]}
+	wantDead := []Entity{NullEntity, entities[1], entities[3]}
+
+	if got := w.FilterAlive(handles); !slices.Equal(got, wantAlive) {
+		t.Fatalf("FilterAlive = %v, want %v", got, wantAlive)
+	}
+	alive, dead := w.PartitionAlive(handles)
+	if !slices.Equal(alive, wantAlive) || !slices.Equal(dead, wantDead) {
+		t.Fatalf("PartitionAlive = %v, %v, want %v, %v", alive, dead, wantAlive, wantDead)
+	}
+	if got := w.FilterAlive(nil); len(got) != 0 {
+		t.Fatalf("FilterAlive(nil) = %v", got)
+	}
+}
+
+func TestComponentErrVariantsRejectInvalidEntities(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 2)
+	destroyed := entities[1]
+	w.DestroyEntity(destroyed)
+
+	for _, entity := range []Entity{destroyed, NullEntity} {
+		if err := AddComponentErr(w, entity, testHealth{HP: 1}); !errors.Is(err, ErrInvalidEntity) {
+			t.Fatalf("AddComponentErr on %s = %v, want ErrInvalidEntity", entity, err)
+		}
+		if removed, err := RemoveComponentErr[testPosition](w, entity); removed || !errors.Is(err, ErrInvalidEntity) {
+			t.Fatalf("RemoveComponentErr on %s = %v, %v, want ErrInvalidEntity", entity, removed, err)
+		}
+	}
+	if health, ok := GetStorage[testHealth](w.componentRegistry); ok && health.Size() != 0 {
+		t.Fatalf("AddComponentErr stored %d components for invalid entities", health.Size())
+	}
+
+	if err := AddComponentErr(w, entities[0], testHealth{HP: 3}); err != nil {
+		t.Fatalf("AddComponentErr on a live entity: %v", err)
+	}
+	if h, _ := GetComponent[testHealth](w, entities[0]); h.HP != 3 {
+		t.Fatalf("health = %v, want 3", h)
+	}
+	if removed, err := RemoveComponentErr[testHealth](w, entities[0]); !removed || err != nil {
+		t.Fatalf("RemoveComponentErr on a live entity = %v, %v", removed, err)
+	}
+	if removed, err := RemoveComponentErr[testHealth](w, entities[0]); removed || err != nil {
+		t.Fatalf("removing a missing component = %v, %v, want false and no error", removed, err)
+	}
+}
+
+func TestDebugStringListsComponents(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 3)
+	AddComponent(w, entities[2], testName{Value: "goblin"})
+	w.DestroyEntity(entities[1])
+
+	dump := w.DebugString()
+	for _, want := range []string{
+		"World: 2 live entities (0 disabled, 3 slots), 3 component types",
+		entities[2].String() + "\n",
+		"testPosition: {X:2 Y:0}",
+		"testName: {Value:goblin}",
+		"testVelocity: {X:1 Y:0}",
+	} {
+		if !strings.Contains(dump, want) {
+			t.Fatalf("DebugString lacks %q:\n%s", want, dump)
+		}
+	}
+	if strings.Contains(dump, entities[1].String()+"\n") {
+		t.Fatalf("DebugString lists destroyed entity %s:\n%s", entities[1], dump)
+	}
+}
+
+func TestMemoryStatsReflectPoolCapacity(t *testing.T) {
+	w := NewWorld()
+	populateTestWorld(w, 10)
+	RegisterComponent[testHealth](w, 1000)
+
+	pools, total := w.MemoryStats()
+	if len(pools) != 3 {
+		t.Fatalf("MemoryStats reported %d pools, want 3", len(pools))
+	}
+	sum := 0
+	for _, stats := range pools {
+		sum += stats.Bytes
+	}
+	if sum != total {
+		t.Fatalf("total = %d bytes, pools add up to %d", total, sum)
+	}
+
+	health := pools[2]
+	pool, _ := GetStorage[testHealth](w.componentRegistry)
+	if health.Name != "ecs.testHealth" || health.Len != 0 || health.Cap != cap(pool.components) || health.Cap < 1000 {
+		t.Fatalf("health pool stats = %+v, want an empty pool with capacity %d", health, cap(pool.components))
+	}
+	if health.ElemBytes != 8 || health.Bytes < health.Cap*health.ElemBytes {
+		t.Fatalf("health pool bytes = %+v", health)
+	}
+
+	positions := pools[0]
+	if positions.Len != 10 || positions.Cap < 10 || positions.SparseLen != sparsePageSize || positions.ElemBytes != 16 {
+		t.Fatalf("position pool stats = %+v", positions)
+	}
+
+	// Growing the pool past its capacity shows up in the next report
+	for range 2000 {
+		AddComponent(w, w.CreateEntity(), testHealth{})
+	}
+	pools, _ = w.MemoryStats()
+	if pools[2].Len != 2000 || pools[2].Cap != cap(pool.components) || pools[2].SparseLen != 2*sparsePageSize {
+		t.Fatalf("health pool stats after growing = %+v, capacity %d", pools[2], cap(pool.components))
+	}
+}
+
+func TestRegisterAsGivesStableIDs(t *testing.T) {
+	// Two runs touch the types in a different order but pin the same IDs
+	ids := func(touchHealthFirst bool) [3]ComponentID {
+		w := NewWorld()
+		if touchHealthFirst {
+			AddComponent(w, w.CreateEntity(), testHealth{})
+		}
+		for _, err := range []error{
+			RegisterAs[testPosition](w, 5),
+			RegisterAs[testVelocity](w, 3),
+		} {
+			if err != nil {
+				t.Fatalf("RegisterAs: %v", err)
+			}
+		}
+		if !touchHealthFirst {
+			AddComponent(w, w.CreateEntity(), testHealth{})
+		}
+		position, _ := GetComponentID[testPosition](w.componentRegistry)
+		velocity, _ := GetComponentID[testVelocity](w.componentRegistry)
+		health, _ := GetComponentID[testHealth](w.componentRegistry)
+		return [3]ComponentID{position, velocity, health}
+	}
+
+	first := ids(false)
+	if first[0] != 5 || first[1] != 3 {
+		t.Fatalf("explicit IDs = %d, %d, want 5 and 3", first[0], first[1])
+	}
+	if first[2] == 3 || first[2] == 5 {
+		t.Fatalf("auto ID %d collides with an explicit one", first[2])
+	}
+	if second := ids(true); second[0] != first[0] || second[1] != first[1] {
+		t.Fatalf("explicit IDs differ between runs: %v and %v", first, second)
+	}
+}
+
+func TestRegisterAsCollisions(t *testing.T) {
+	w := NewWorld()
+	auto := Register[testHealth](w.componentRegistry)
+	if err := RegisterAs[testPosition](w, auto); err == nil {
+		t.Fatalf("RegisterAs took ID %d already used by testHealth", auto)
+	}
+	if err := RegisterAs[testHealth](w, auto+1); err == nil {
+		t.Fatalf("RegisterAs moved testHealth to another ID")
+	}
+	if err := RegisterAs[testHealth](w, auto); err != nil {
+		t.Fatalf("RegisterAs with the type's own ID: %v", err)
+	}
+	if _, registered := GetComponentID[testPosition](w.componentRegistry); registered {
+		t.Fatalf("a failed RegisterAs registered the type")
+	}
+}
+
+func TestOnEntityDestroyedSeesComponents(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 6)
+
+	var calls []string
+	released := map[Entity]float64{}
+	w.OnEntityDestroyed(func(e Entity) {
+		calls = append(calls, "first")
+		pos, ok := GetComponent[testPosition](w, e)
+		if !ok {
+			t.Fatalf("callback for %s can't read its position", e)
+		}
+		released[e] = pos.X
+	})
+	w.OnEntityDestroyed(func(Entity) { calls = append(calls, "second") })
+
+	w.DestroyEntity(entities[3])
+	if !slices.Equal(calls, []string{"first", "second"}) || released[entities[3]] != 3 {
+		t.Fatalf("calls = %v, released = %v", calls, released)
+	}
+
+	// Destroying a dead entity doesn't call back again
+	calls = nil
+	w.DestroyEntity(entities[3])
+	if len(calls) != 0 {
+		t.Fatalf("destroying a dead entity called back: %v", calls)
+	}
+
+	// Bulk destruction calls back once per entity
+	destroyed := w.DestroyMatching(func(q *Query) { With[testVelocity](q) })
+	if destroyed != 3 || len(calls) != 6 {
+		t.Fatalf("DestroyMatching destroyed %d with %d calls, want 3 and 6", destroyed, len(calls))
+	}
+	for _, e := range []Entity{entities[0], entities[2], entities[4]} {
+		if released[e] != float64(e.Index()) {
+			t.Fatalf("callback for %s read position %v", e, released[e])
+		}
+	}
+}
+
+func TestOnEntityDestroyedCallbackMayDestroy(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 2)
+	// A callback destroying the entity itself must not destroy it twice
+	w.OnEntityDestroyed(func(e Entity) {
+		if e == entities[0] {
+			w.DestroyEntity(e)
+		}
+	})
+
+	w.DestroyEntity(entities[0])
+	if w.IsValidEntity(entities[0]) || w.entityManager.LiveCount() != 1 {
+		t.Fatalf("re-entrant destroy left LiveCount %d", w.entityManager.LiveCount())
+	}
+	if errs := w.Validate(); errs != nil {
+		t.Fatalf("Validate: %v", errs)
+	}
+}
+
+func TestDisabledCountInStatsAndQueries(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 10)
+	for _, e := range entities[:4] {
+		w.DisableEntity(e)
+	}
+	w.DisableEntity(entities[0]) // Disabling twice counts once
+
+	if stats := w.Stats(); stats.DisabledCount != 4 || stats.LiveCount != 10 {
+		t.Fatalf("stats = %+v, want 4 disabled of 10 live", stats)
+	}
+	if got := With[testPosition](NewQuery(w)).Count(); got != 6 {
+		t.Fatalf("Count = %d, want 6 enabled entities", got)
+	}
+	if got := With[testPosition](NewQuery(w)).IncludeDisabled().Count(); got != 10 {
+		t.Fatalf("Count with IncludeDisabled = %d, want 10", got)
+	}
+	// Entities 0 and 2 move but are disabled
+	if got := With[testVelocity](NewQuery(w)).Count(); got != 3 {
+		t.Fatalf("moving Count = %d, want 3", got)
+	}
+
+	// Destroying a disabled entity drops it from the count
+	w.DestroyEntity(entities[1])
+	w.EnableEntity(entities[2])
+	if stats := w.Stats(); stats.DisabledCount != 2 {
+		t.Fatalf("DisabledCount = %d after destroy and enable, want 2", stats.DisabledCount)
+	}
+	if got := With[testPosition](NewQuery(w)).Count(); got != 7 {
+		t.Fatalf("Count = %d, want 7", got)
+	}
+}
+
+func TestMoveAndCopyComponent(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 4)
+	AddComponent(w, entities[0], testHealth{HP: 7})
+	AddComponent(w, entities[2], testHealth{HP: 1})
+
+	if !CopyComponent[testHealth](w, entities[0], entities[1]) {
+		t.Fatalf("CopyComponent failed")
+	}
+	if h, _ := GetComponent[testHealth](w, entities[1]); h.HP != 7 || !HasComponent[testHealth](w, entities[0]) {
+		t.Fatalf("after copy: target health %v, source kept %v", h, HasComponent[testHealth](w, entities[0]))
+	}
+
+	// Moving replaces the target's component and removes the source's
+	if !MoveComponent[testHealth](w, entities[0], entities[2]) {
+		t.Fatalf("MoveComponent failed")
+	}
+	if h, _ := GetComponent[testHealth](w, entities[2]); h.HP != 7 || HasComponent[testHealth](w, entities[0]) {
+		t.Fatalf("after move: target health %v, source kept %v", h, HasComponent[testHealth](w, entities[0]))
+	}
+	if !MoveComponent[testHealth](w, entities[2], entities[2]) || !HasComponent[testHealth](w, entities[2]) {
+		t.Fatalf("moving a component onto its own entity lost it")
+	}
+
+	// A source lacking the component leaves the target alone
+	if CopyComponent[testHealth](w, entities[3], entities[1]) || MoveComponent[testHealth](w, entities[3], entities[1]) {
+		t.Fatalf("copy or move from an entity without the component succeeded")
+	}
+	if h, _ := GetComponent[testHealth](w, entities[1]); h.HP != 7 {
+		t.Fatalf("failed move changed the target's health to %v", h)
+	}
+	if CopyComponent[testName](w, entities[0], entities[1]) {
+		t.Fatalf("copying an unregistered type succeeded")
+	}
+
+	w.DestroyEntity(entities[3])
+	if MoveComponent[testPosition](w, entities[0], entities[3]) || !HasComponent[testPosition](w, entities[0]) {
+		t.Fatalf("moving to a destroyed entity removed the source's component")
+	}
+}
+
+func TestDestroyMatching(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 10)
+	for i, e := range entities {
+		if i%3 == 0 {
+			AddComponent(w, e, testTag{})
+		}
+	}
+
+	if n := w.DestroyMatching(func(q *Query) { With[testTag](q) }); n != 4 {
+		t.Fatalf("DestroyMatching destroyed %d entities, want 4", n)
+	}
+	for i, e := range entities {
+		if w.IsValidEntity(e) != (i%3 != 0) {
+			t.Fatalf("entity %d valid = %v", i, w.IsValidEntity(e))
+		}
+	}
+	if With[testTag](NewQuery(w)).Build().Size() != 0 || w.Stats().LiveCount != 6 {
+		t.Fatalf("tagged entities survived, %d live", w.Stats().LiveCount)
+	}
+
+	// The destroyed slots are reused with new generations
+	for range 4 {
+		e := w.CreateEntity()
+		if e.Index()%3 != 0 || e.Index() >= 10 || e.Generation() == 0 {
+			t.Fatalf("new entity %s didn't recycle a destroyed slot", e)
+		}
+	}
+
+	if n := w.DestroyMatching(func(q *Query) { With[testTag](q) }); n != 0 {
+		t.Fatalf("second DestroyMatching destroyed %d entities", n)
+	}
+}
+
+func TestForEachComponentType(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 6)
+	AddComponent(w, entities[0], testHealth{})
+	AddComponent(w, entities[1], testTag{})
+	Unregister[testTag](w.componentRegistry)
+
+	type visit struct {
+		id   ComponentID
+		name string
+		size int
+	}
+	var got []visit
+	w.ForEachComponentType(func(id ComponentID, name string, storage IComponentStorage) {
+		got = append(got, visit{id, name, storage.Size()})
+	})
+	position, _ := GetComponentID[testPosition](w.componentRegistry)
+	velocity, _ := GetComponentID[testVelocity](w.componentRegistry)
+	health, _ := GetComponentID[testHealth](w.componentRegistry)
+	want := []visit{
+		{position, "ecs.testPosition", 6},
+		{velocity, "ecs.testVelocity", 3},
+		{health, "ecs.testHealth", 1},
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("ForEachComponentType visited %v, want %v", got, want)
+	}
+}
+
+func TestSwapEntitiesPartialOverlap(t *testing.T) {
+	w := NewWorld()
+	a, b := w.CreateEntity(), w.CreateEntity()
+	// Both have position, only a has velocity, only b has health and name
+	AddComponent(w, a, testPosition{X: 1})
+	AddComponent(w, a, testVelocity{X: 2})
+	AddComponent(w, b, testPosition{X: 10})
+	AddComponent(w, b, testHealth{HP: 20})
+	AddComponent(w, b, testName{Value: "b"})
+	bystander := w.CreateEntity()
+	AddComponent(w, bystander, testVelocity{X: 99})
+
+	if !w.SwapEntities(a, b) {
+		t.Fatalf("SwapEntities failed")
+	}
+	if p, _ := GetComponent[testPosition](w, a); p.X != 10 {
+		t.Fatalf("a position = %v, want b's", p)
+	}
+	if p, _ := GetComponent[testPosition](w, b); p.X != 1 {
+		t.Fatalf("b position = %v, want a's", p)
+	}
+	if HasComponent[testVelocity](w, a) || !HasComponent[testHealth](w, a) || !HasComponent[testName](w, a) {
+		t.Fatalf("a didn't take over b's component set")
+	}
+	if v, _ := GetComponent[testVelocity](w, b); v.X != 2 || HasComponent[testHealth](w, b) || HasComponent[testName](w, b) {
+		t.Fatalf("b didn't take over a's component set")
+	}
+	if v, _ := GetComponent[testVelocity](w, bystander); v.X != 99 {
+		t.Fatalf("bystander velocity changed to %v", v)
+	}
+	if got := With[testHealth](NewQuery(w)).Build().Entities(); !slices.Equal(got, []Entity{a}) {
+		t.Fatalf("health query = %v, want %v", got, a)
+	}
+	if errs := w.Validate(); errs != nil {
+		t.Fatalf("world inconsistent after swap: %v", errs)
+	}
+
+	w.DestroyEntity(b)
+	if w.SwapEntities(a, b) || !HasComponent[testHealth](w, a) {
+		t.Fatalf("swapping with a destroyed entity changed a")
+	}
+}
+
+func TestCompactPools(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 100)
+	for _, e := range entities[:70] {
+		w.DestroyEntity(e)
+	}
+
+	w.CompactPools()
+	w.ForEachComponentType(func(id ComponentID, name string, storage IComponentStorage) {
+		if stats := storage.memoryStats(); stats.Cap != stats.Len || stats.DenseCap != stats.Len {
+			t.Fatalf("%s capacity %d, dense capacity %d, size %d", name, stats.Cap, stats.DenseCap, stats.Len)
+		}
+	})
+	if n := countIter2(Iter2[testPosition, testVelocity](w)); n != 15 {
+		t.Fatalf("%d movers after CompactPools, want 15", n)
+	}
+}
+
+func TestUpdateComponentAccumulates(t *testing.T) {
+	w := NewWorld()
+	entities := populateTestWorld(w, 2)
+	AddComponent(w, entities[0], testHealth{HP: 100})
+
+	for _, damage := range []int{10, 15, 5} {
+		if !UpdateComponent(w, entities[0], func(h *testHealth) { h.HP -= damage }) {
+			t.Fatalf("UpdateComponent on an entity with health did nothing")
+		}
+	}
+	if h, _ := GetComponent[testHealth](w, entities[0]); h.HP != 70 {
+		t.Fatalf("health = %d after 30 damage, want 70", h.HP)
+	}
+	if !slices.Equal(ChangedThisFrame[testHealth](w), entities[:1]) {
+		t.Fatalf("UpdateComponent didn't mark the component changed")
+	}
+
+	// Missing components and invalid entities are no-ops
+	called := false
+	mark := func(*testHealth) { called = true }
+	w.DestroyEntity(entities[0])
+	if UpdateComponent(w, entities[1], mark) || UpdateComponent(w, entities[0], mark) || called {
+		t.Fatalf("UpdateComponent ran without a component to update")
+	}
+	if HasComponent[testHealth](w, entities[1]) {
+		t.Fatalf("UpdateComponent added the missing component")
+	}
+}